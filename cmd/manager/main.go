@@ -1,8 +1,11 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"os"
+	"strings"
+	"time"
 
 	cdPipeApi "github.com/epam/edp-cd-pipeline-operator/v2/pkg/apis/edp/v1"
 	codebaseApi "github.com/epam/edp-codebase-operator/v2/pkg/apis/edp/v1"
@@ -11,7 +14,10 @@ import (
 	"github.com/epam/edp-gerrit-operator/v2/pkg/controller/helper"
 	jenkinsApi "github.com/epam/edp-jenkins-operator/v2/pkg/apis/v2/v1"
 	perfApi "github.com/epam/edp-perf-operator/v2/pkg/apis/edp/v1"
+	"github.com/epam/edp-reconciler/v2/pkg/api"
+	"github.com/epam/edp-reconciler/v2/pkg/api/changefeed"
 	reconcilerApi "github.com/epam/edp-reconciler/v2/pkg/apis/edp/v1alpha1"
+	"github.com/epam/edp-reconciler/v2/pkg/controller/actionmessage"
 	"github.com/epam/edp-reconciler/v2/pkg/controller/cdpipeline"
 	"github.com/epam/edp-reconciler/v2/pkg/controller/codebase"
 	"github.com/epam/edp-reconciler/v2/pkg/controller/codebasebranch"
@@ -21,10 +27,14 @@ import (
 	jenkinsJob "github.com/epam/edp-reconciler/v2/pkg/controller/jenkins_job"
 	jiraserver "github.com/epam/edp-reconciler/v2/pkg/controller/jira-server"
 	job_provisioning "github.com/epam/edp-reconciler/v2/pkg/controller/job-provisioning"
-	"github.com/epam/edp-reconciler/v2/pkg/controller/perfdatasourcejenkins"
-	"github.com/epam/edp-reconciler/v2/pkg/controller/perfdatasourcesonar"
+	"github.com/epam/edp-reconciler/v2/pkg/controller/perfdatasource"
 	perfserverCtrl "github.com/epam/edp-reconciler/v2/pkg/controller/perfserver"
 	"github.com/epam/edp-reconciler/v2/pkg/controller/stage"
+	"github.com/epam/edp-reconciler/v2/pkg/db"
+	"github.com/epam/edp-reconciler/v2/pkg/service/actionlog"
+	"github.com/epam/edp-reconciler/v2/pkg/service/integrity"
+	"github.com/epam/edp-reconciler/v2/pkg/service/outbox"
+	"github.com/epam/edp-reconciler/v2/pkg/service/webhook"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/client-go/rest"
 
@@ -71,10 +81,26 @@ func main() {
 		metricsAddr          string
 		enableLeaderElection bool
 		probeAddr            string
+		apiAddr              string
+		apiAudiences         string
+		tlsCertFile          string
+		tlsKeyFile           string
+		changeFeedAddr       string
+		integrityInterval    time.Duration
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&apiAddr, "api-bind-address", "", "The address the read-only REST API binds to. "+
+		"API is disabled if empty.")
+	flag.StringVar(&apiAudiences, "api-token-audiences", "", "Comma separated audiences API bearer tokens "+
+		"are reviewed against. API server audiences are used if empty.")
+	flag.StringVar(&tlsCertFile, "tls-cert-file", "", "The PEM encoded certificate the REST API and the change feed are served with.")
+	flag.StringVar(&tlsKeyFile, "tls-key-file", "", "The PEM encoded private key of the certificate.")
+	flag.StringVar(&changeFeedAddr, "change-feed-bind-address", "", "The address the gRPC change feed binds to. "+
+		"Change feed is disabled if empty.")
+	flag.DurationVar(&integrityInterval, "integrity-check-interval", time.Hour, "How often integrity of "+
+		"the tenant data is verified. Verification is disabled if zero.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", helper.RunningInCluster(),
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -131,12 +157,13 @@ func main() {
 
 	ctrlLog := ctrl.Log.WithName("controllers")
 
-	pipelineCtrl, err := cdpipeline.NewReconcileCDPipeline(mgr.GetClient(), mgr.GetScheme(), ctrlLog)
-	if err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "cd-pipeline")
+	actionMessageCtrl := actionmessage.NewReconcileActionMessages(mgr.GetClient(), ctrlLog)
+	if err := actionMessageCtrl.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "action-messages")
 		os.Exit(1)
 	}
 
+	pipelineCtrl := cdpipeline.NewReconcileCDPipeline(mgr.GetClient(), mgr.GetScheme(), ctrlLog)
 	if err := pipelineCtrl.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "cd-pipeline")
 		os.Exit(1)
@@ -190,16 +217,12 @@ func main() {
 		os.Exit(1)
 	}
 
-	pdsjCtrl := perfdatasourcejenkins.NewReconcilePerfDataSourceJenkins(mgr.GetClient(), ctrlLog)
-	if err := pdsjCtrl.SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "perf-data-source-jenkins")
-		os.Exit(1)
-	}
-
-	pdssCtrl := perfdatasourcesonar.NewReconcilePerfDataSourceSonar(mgr.GetClient(), ctrlLog)
-	if err := pdssCtrl.SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "perf-data-source-sonar")
-		os.Exit(1)
+	for _, kind := range perfdatasource.Kinds() {
+		pdsCtrl := perfdatasource.NewReconcilePerfDataSource(mgr.GetClient(), kind, ctrlLog)
+		if err := pdsCtrl.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "perf-data-source", "kind", kind.GVK.Kind)
+			os.Exit(1)
+		}
 	}
 
 	psCtrl := perfserverCtrl.NewReconcilePerfServer(mgr.GetClient(), ctrlLog)
@@ -208,17 +231,79 @@ func main() {
 		os.Exit(1)
 	}
 
-	stageCtrl, err := stage.NewReconcileStage(mgr.GetClient(), mgr.GetScheme(), ctrlLog)
-	if err != nil {
+	stageCtrl := stage.NewReconcileStage(mgr.GetClient(), mgr.GetScheme(), ctrlLog)
+	if err := stageCtrl.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "cd-stage")
 		os.Exit(1)
 	}
 
-	if err := stageCtrl.SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "cd-stage")
+	webhooks, err := webhook.ConfigFromEnv()
+	if err != nil {
+		setupLog.Error(err, "unable to read webhook config")
 		os.Exit(1)
 	}
 
+	retention, err := actionlog.PolicyFromEnv()
+	if err != nil {
+		setupLog.Error(err, "unable to read action log retention policy")
+		os.Exit(1)
+	}
+	if retention.Enabled() {
+		pruner := actionlog.NewPruner(db.Instance, *retention)
+		for _, s := range webhooks.Sinks() {
+			pruner.OutboxSinks = append(pruner.OutboxSinks, s.Name())
+		}
+		if err := mgr.Add(pruner); err != nil {
+			setupLog.Error(err, "unable to set up action log pruner")
+			os.Exit(1)
+		}
+	}
+
+	if webhooks.Enabled() {
+		if err := mgr.Add(outbox.NewDispatcher(db.Instance, webhooks.Options(), webhooks.Sinks()...)); err != nil {
+			setupLog.Error(err, "unable to set up outbox dispatcher")
+			os.Exit(1)
+		}
+	}
+
+	if integrityInterval > 0 {
+		if err := mgr.Add(integrity.NewJob(db.Instance, integrityInterval)); err != nil {
+			setupLog.Error(err, "unable to set up integrity verifier")
+			os.Exit(1)
+		}
+	}
+
+	var audiences []string
+	if apiAudiences != "" {
+		audiences = strings.Split(apiAudiences, ",")
+	}
+	authenticator := api.NewTokenReviewAuthenticator(mgr.GetClient(), audiences)
+	authorizer := api.NewSubjectAccessReviewAuthorizer(mgr.GetClient())
+
+	tlsFiles := api.TLSFiles{CertFile: tlsCertFile, KeyFile: tlsKeyFile}
+
+	if apiAddr != "" {
+		if tlsCertFile == "" || tlsKeyFile == "" {
+			setupLog.Error(errors.New("--tls-cert-file and --tls-key-file are required"), "unable to set up API server")
+			os.Exit(1)
+		}
+		if err := mgr.Add(api.NewServer(apiAddr, tlsFiles, db.Instance, authenticator, authorizer, ctrl.Log)); err != nil {
+			setupLog.Error(err, "unable to set up API server")
+			os.Exit(1)
+		}
+	}
+
+	if changeFeedAddr != "" {
+		if tlsCertFile == "" || tlsKeyFile == "" {
+			setupLog.Error(errors.New("--tls-cert-file and --tls-key-file are required"), "unable to set up change feed server")
+			os.Exit(1)
+		}
+		if err := mgr.Add(changefeed.NewServer(changeFeedAddr, tlsFiles, db.Instance, authenticator, authorizer, ctrl.Log)); err != nil {
+			setupLog.Error(err, "unable to set up change feed server")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
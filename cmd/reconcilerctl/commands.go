@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/epam/edp-reconciler/v2/pkg/controller/helper"
+	"github.com/epam/edp-reconciler/v2/pkg/service/query"
+)
+
+func status(ctx context.Context, e env, args []string) error {
+	f := newFlags("status")
+	args, err := f.parse(args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("expected kind and name of the CR")
+	}
+	k, err := lookupKind(args[0])
+	if err != nil {
+		return err
+	}
+
+	obj := k.object()
+	if err := e.client.Get(ctx, types.NamespacedName{Namespace: f.namespace, Name: args[1]}, obj); err != nil {
+		return err
+	}
+	tenant, err := helper.GetEDPName(e.client, f.namespace)
+	if err != nil {
+		return err
+	}
+	paused, err := helper.IsPaused(e.client, obj)
+	if err != nil {
+		return err
+	}
+	s, err := k.status(ctx, e.query, obj, *tenant)
+	if err != nil {
+		return err
+	}
+
+	result := struct {
+		*query.SyncStatus
+		Paused bool `json:"paused"`
+	}{s, paused}
+	return f.print(e.out, result, func(w io.Writer) {
+		fmt.Fprintf(w, "Kind:\t%v\n", s.Kind)
+		fmt.Fprintf(w, "Name:\t%v\n", s.Name)
+		fmt.Fprintf(w, "Tenant:\t%v\n", s.Tenant)
+		fmt.Fprintf(w, "Paused:\t%v\n", paused)
+		fmt.Fprintf(w, "Synced:\t%v\n", s.Row != nil)
+		if s.LastAction != nil {
+			fmt.Fprintf(w, "Last action:\t%v %v %v: %v\n", s.LastAction.UpdatedAt.Format(time.RFC3339),
+				s.LastAction.Action, s.LastAction.Result, s.LastAction.ActionMessage)
+		}
+		if len(s.Drift) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\nFIELD\tEXPECTED\tACTUAL\n")
+		for _, d := range s.Drift {
+			fmt.Fprintf(w, "%v\t%v\t%v\n", d.Field, d.Expected, d.Actual)
+		}
+	})
+}
+
+// resync annotates either the CR or, for the whole tenant, edp-config CM, so the reconciler syncs them once more
+func resync(ctx context.Context, e env, args []string) error {
+	f := newFlags("resync")
+	all := f.Bool("all", false, "resync every CR of the tenant")
+	args, err := f.parse(args)
+	if err != nil {
+		return err
+	}
+
+	var obj client.Object
+	switch {
+	case *all && len(args) == 0:
+		obj = &v1.ConfigMap{}
+		args = []string{"configmap", helper.EDPConfigCM}
+	case !*all && len(args) == 2:
+		k, err := lookupKind(args[0])
+		if err != nil {
+			return err
+		}
+		obj = k.object()
+	default:
+		return fmt.Errorf("expected either kind and name of the CR or --all")
+	}
+
+	if err := e.client.Get(ctx, types.NamespacedName{Namespace: f.namespace, Name: args[1]}, obj); err != nil {
+		return err
+	}
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[helper.ResyncAnnotation] = time.Now().UTC().Format(time.RFC3339Nano)
+	obj.SetAnnotations(annotations)
+	if err := e.client.Patch(ctx, obj, patch); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(e.out, "%v %v resync requested\n", args[0], args[1])
+	return nil
+}
+
+func orphans(ctx context.Context, e env, args []string) error {
+	f := newFlags("orphans")
+	args, err := f.parse(args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		return fmt.Errorf("unexpected arguments %v", args)
+	}
+
+	tenant, err := helper.GetEDPName(e.client, f.namespace)
+	if err != nil {
+		return err
+	}
+
+	type orphan struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	}
+	result := []orphan{}
+	for _, name := range kindNames {
+		k := kinds[name]
+		existing, err := k.names(ctx, e.client, f.namespace)
+		if err != nil {
+			return err
+		}
+		names, err := e.query.Orphans(ctx, *tenant, k.entity, existing)
+		if err != nil {
+			return err
+		}
+		for _, n := range names {
+			result = append(result, orphan{Kind: string(k.entity), Name: n})
+		}
+	}
+
+	return f.print(e.out, result, func(w io.Writer) {
+		fmt.Fprintf(w, "KIND\tNAME\n")
+		for _, o := range result {
+			fmt.Fprintf(w, "%v\t%v\n", o.Kind, o.Name)
+		}
+	})
+}
+
+func lineage(ctx context.Context, e env, args []string) error {
+	f := newFlags("lineage", "dot")
+	args, err := f.parse(args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("expected name of the CD pipeline")
+	}
+
+	tenant, err := helper.GetEDPName(e.client, f.namespace)
+	if err != nil {
+		return err
+	}
+	graph, err := e.query.CDPipelineLineage(ctx, *tenant, args[0])
+	if err != nil {
+		return err
+	}
+	if f.output == "dot" {
+		return graph.WriteDOT(e.out)
+	}
+
+	return f.print(e.out, graph, func(w io.Writer) {
+		fmt.Fprintf(w, "STAGE\tCODEBASE\tINPUT\tOUTPUT\tPROMOTED\n")
+		for _, edge := range graph.Edges {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", edge.Stage, edge.Codebase, edge.From, edge.To, edge.Promoted)
+		}
+	})
+}
+
+// verify fails if any violation is found, so it could be used in scripts
+func verify(ctx context.Context, e env, args []string) error {
+	f := newFlags("verify")
+	args, err := f.parse(args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		return fmt.Errorf("unexpected arguments %v", args)
+	}
+
+	tenant, err := helper.GetEDPName(e.client, f.namespace)
+	if err != nil {
+		return err
+	}
+	violations, err := e.verifier.Verify(ctx, *tenant)
+	if err != nil {
+		return err
+	}
+
+	err = f.print(e.out, violations, func(w io.Writer) {
+		fmt.Fprintf(w, "CHECK\tENTITY\tPROBLEM\tFIX\n")
+		for _, v := range violations {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", v.Check, v.Entity, v.Problem, v.Fix)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("%v integrity violations found", len(violations))
+	}
+	return nil
+}
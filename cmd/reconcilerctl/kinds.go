@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	cdPipeApi "github.com/epam/edp-cd-pipeline-operator/v2/pkg/apis/edp/v1"
+	codebaseApi "github.com/epam/edp-codebase-operator/v2/pkg/apis/edp/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/cdpipeline"
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+	"github.com/epam/edp-reconciler/v2/pkg/model/codebase"
+	"github.com/epam/edp-reconciler/v2/pkg/model/codebasebranch"
+	"github.com/epam/edp-reconciler/v2/pkg/model/stage"
+	"github.com/epam/edp-reconciler/v2/pkg/service/changefeed"
+	"github.com/epam/edp-reconciler/v2/pkg/service/query"
+)
+
+// kind describes the CR kind synced into the tenant schema
+type kind struct {
+	entity change.Kind
+	object func() client.Object
+	list   func() client.ObjectList
+	// name returns name of the entity in the tenant schema, which may differ from the CR name
+	name func(obj client.Object) string
+	// status converts the CR the way its controller does and compares it with the stored row
+	status func(ctx context.Context, q query.QueryService, obj client.Object, tenant string) (*query.SyncStatus, error)
+}
+
+var kindNames = []string{"codebase", "codebasebranch", "cdpipeline", "stage"}
+
+var kinds = map[string]kind{
+	"codebase": {
+		entity: change.Codebase,
+		object: func() client.Object { return &codebaseApi.Codebase{} },
+		list:   func() client.ObjectList { return &codebaseApi.CodebaseList{} },
+		name:   func(obj client.Object) string { return obj.GetName() },
+		status: func(ctx context.Context, q query.QueryService, obj client.Object, tenant string) (*query.SyncStatus, error) {
+			c, err := codebase.Convert(*obj.(*codebaseApi.Codebase), tenant)
+			if err != nil {
+				return nil, err
+			}
+			return q.CodebaseStatus(ctx, *c)
+		},
+	},
+	"codebasebranch": {
+		entity: change.CodebaseBranch,
+		object: func() client.Object { return &codebaseApi.CodebaseBranch{} },
+		list:   func() client.ObjectList { return &codebaseApi.CodebaseBranchList{} },
+		name: func(obj client.Object) string {
+			spec := obj.(*codebaseApi.CodebaseBranch).Spec
+			return changefeed.Name(spec.CodebaseName, spec.BranchName)
+		},
+		status: func(ctx context.Context, q query.QueryService, obj client.Object, tenant string) (*query.SyncStatus, error) {
+			b, err := codebasebranch.ConvertToCodebaseBranch(*obj.(*codebaseApi.CodebaseBranch), tenant)
+			if err != nil {
+				return nil, err
+			}
+			return q.CodebaseBranchStatus(ctx, *b)
+		},
+	},
+	"cdpipeline": {
+		entity: change.CDPipeline,
+		object: func() client.Object { return &cdPipeApi.CDPipeline{} },
+		list:   func() client.ObjectList { return &cdPipeApi.CDPipelineList{} },
+		name:   func(obj client.Object) string { return obj.(*cdPipeApi.CDPipeline).Spec.Name },
+		status: func(ctx context.Context, q query.QueryService, obj client.Object, tenant string) (*query.SyncStatus, error) {
+			p, err := cdpipeline.ConvertToCDPipeline(*obj.(*cdPipeApi.CDPipeline), tenant)
+			if err != nil {
+				return nil, err
+			}
+			return q.CDPipelineStatus(ctx, *p)
+		},
+	},
+	"stage": {
+		entity: change.CDStage,
+		object: func() client.Object { return &cdPipeApi.Stage{} },
+		list:   func() client.ObjectList { return &cdPipeApi.StageList{} },
+		name: func(obj client.Object) string {
+			spec := obj.(*cdPipeApi.Stage).Spec
+			return changefeed.Name(spec.CdPipeline, spec.Name)
+		},
+		status: func(ctx context.Context, q query.QueryService, obj client.Object, tenant string) (*query.SyncStatus, error) {
+			s, err := stage.ConvertToStage(*obj.(*cdPipeApi.Stage), tenant)
+			if err != nil {
+				return nil, err
+			}
+			return q.StageStatus(ctx, *s)
+		},
+	},
+}
+
+func lookupKind(name string) (kind, error) {
+	k, ok := kinds[name]
+	if !ok {
+		return kind{}, fmt.Errorf("unsupported kind %v", name)
+	}
+	return k, nil
+}
+
+// names returns names in the tenant schema of all CRs of the kind in the namespace
+func (k kind) names(ctx context.Context, c client.Client, namespace string) ([]string, error) {
+	l := k.list()
+	if err := c.List(ctx, l, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	items, err := meta.ExtractList(l)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, item := range items {
+		result = append(result, k.name(item.(client.Object)))
+	}
+	return result, nil
+}
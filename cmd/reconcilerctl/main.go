@@ -0,0 +1,166 @@
+// reconcilerctl inspects and repairs the database state the reconciler maintains for EDP custom resources.
+// It connects to the database with the same DB_* env variables as the reconciler and to the cluster
+// with the current kubeconfig.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	cdPipeApi "github.com/epam/edp-cd-pipeline-operator/v2/pkg/apis/edp/v1"
+	codebaseApi "github.com/epam/edp-codebase-operator/v2/pkg/apis/edp/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+
+	"github.com/epam/edp-reconciler/v2/pkg/db"
+	"github.com/epam/edp-reconciler/v2/pkg/service/integrity"
+	"github.com/epam/edp-reconciler/v2/pkg/service/query"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(codebaseApi.AddToScheme(scheme))
+	utilruntime.Must(cdPipeApi.AddToScheme(scheme))
+}
+
+// env is what every command runs against
+type env struct {
+	client   client.Client
+	query    query.QueryService
+	verifier integrity.Verifier
+	out      io.Writer
+}
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, e env, args []string) error
+}
+
+var commands = map[string]command{
+	"status":  {usage: "status <kind> <name> -n <namespace>\tsync status of the CR: DB row, last action log and drift", run: status},
+	"resync":  {usage: "resync (<kind> <name> | --all) -n <namespace>\tforce resync of the CR or every CR of the tenant", run: resync},
+	"orphans": {usage: "orphans -n <namespace>\tlist DB rows of the tenant which have no CR", run: orphans},
+	"lineage": {usage: "lineage <pipeline> -n <namespace> [-o dot]\tprint docker stream promotion graph of the CD pipeline", run: lineage},
+	"verify":  {usage: "verify -n <namespace>\tcheck integrity of the tenant data and suggest fixes", run: verify},
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	ctrl.SetLogger(zap.New(zap.WriteTo(os.Stderr)))
+
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to get kubeconfig: %v\n", err)
+		os.Exit(1)
+	}
+	cl, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to create client: %v\n", err)
+		os.Exit(1)
+	}
+
+	e := env{
+		client:   cl,
+		query:    query.QueryService{DB: db.Instance},
+		verifier: integrity.Verifier{DB: db.Instance},
+		out:      os.Stdout,
+	}
+	if err := cmd.run(ctrl.SetupSignalHandler(), e, flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%v: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: reconcilerctl [--kubeconfig <path>] <command> [flags]\n\nCommands:\n")
+	w := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	for _, name := range []string{"status", "resync", "orphans", "lineage", "verify"} {
+		fmt.Fprintf(w, "  %v\n", commands[name].usage)
+	}
+	_ = w.Flush()
+	fmt.Fprintf(os.Stderr, "\nKinds: %v\n", strings.Join(kindNames, ", "))
+}
+
+// flags are common flags of the commands
+type flags struct {
+	*flag.FlagSet
+	namespace string
+	output    string
+	formats   []string
+}
+
+// newFlags creates flags of the command, which supports table and json output besides the extra formats
+func newFlags(name string, extraFormats ...string) *flags {
+	f := &flags{
+		FlagSet: flag.NewFlagSet(name, flag.ContinueOnError),
+		formats: append([]string{"table", "json"}, extraFormats...),
+	}
+	f.StringVar(&f.namespace, "n", "", "namespace of the tenant")
+	f.StringVar(&f.output, "o", "table", "output format: "+strings.Join(f.formats, ", "))
+	return f
+}
+
+// parse parses flags placed anywhere among the positional arguments and returns the latter
+func (f *flags) parse(args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := f.Parse(args); err != nil {
+			return nil, err
+		}
+		if f.NArg() == 0 {
+			break
+		}
+		positional = append(positional, f.Arg(0))
+		args = f.Args()[1:]
+	}
+
+	if f.namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	for _, format := range f.formats {
+		if f.output == format {
+			return positional, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported output format %v", f.output)
+}
+
+// print writes v as indented JSON if requested, otherwise renders it with table func
+func (f *flags) print(out io.Writer, v interface{}, table func(w io.Writer)) error {
+	if f.output == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	table(w)
+	return w.Flush()
+}
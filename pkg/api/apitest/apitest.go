@@ -0,0 +1,32 @@
+// Package apitest provides authenticators and authorizers for tests of the API servers
+package apitest
+
+import (
+	"context"
+
+	authV1 "k8s.io/api/authentication/v1"
+
+	"github.com/epam/edp-reconciler/v2/pkg/api"
+)
+
+// StaticAuthenticator maps bearer tokens to names of the users they belong to
+type StaticAuthenticator map[string]string
+
+func (a StaticAuthenticator) Authenticate(_ context.Context, token string) (authV1.UserInfo, error) {
+	if u, ok := a[token]; ok {
+		return authV1.UserInfo{Username: u}, nil
+	}
+	return authV1.UserInfo{}, api.ErrUnauthenticated
+}
+
+// StaticAuthorizer grants users access to the listed tenants
+type StaticAuthorizer map[string][]string
+
+func (a StaticAuthorizer) Authorize(_ context.Context, user authV1.UserInfo, tenant string) (bool, error) {
+	for _, t := range a[user.Username] {
+		if t == tenant {
+			return true, nil
+		}
+	}
+	return false, nil
+}
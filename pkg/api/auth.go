@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	authV1 "k8s.io/api/authentication/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const tokenReviewCacheTTL = time.Minute
+
+// ErrUnauthenticated is returned when the bearer token is rejected by Kubernetes
+var ErrUnauthenticated = errors.New("token is not authenticated")
+
+// Authenticator resolves bearer token to the user it has been issued for
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (authV1.UserInfo, error)
+}
+
+type userKey struct{}
+
+// WithUser returns copy of the context carrying the authenticated user
+func WithUser(ctx context.Context, user authV1.UserInfo) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFrom returns the authenticated user carried by the context
+func UserFrom(ctx context.Context) (authV1.UserInfo, bool) {
+	user, ok := ctx.Value(userKey{}).(authV1.UserInfo)
+	return user, ok
+}
+
+type cachedReview struct {
+	user    authV1.UserInfo
+	expires time.Time
+}
+
+// TokenReviewAuthenticator validates tokens with Kubernetes TokenReview API.
+// Successful reviews are cached for a minute, so clients polling the API don't flood the API server.
+type TokenReviewAuthenticator struct {
+	client    client.Client
+	audiences []string
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]cachedReview
+	now   func() time.Time
+}
+
+func NewTokenReviewAuthenticator(client client.Client, audiences []string) *TokenReviewAuthenticator {
+	return &TokenReviewAuthenticator{
+		client:    client,
+		audiences: audiences,
+		cache:     map[[sha256.Size]byte]cachedReview{},
+		now:       time.Now,
+	}
+}
+
+func (a *TokenReviewAuthenticator) Authenticate(ctx context.Context, token string) (authV1.UserInfo, error) {
+	key := sha256.Sum256([]byte(token))
+	if user, ok := a.cached(key); ok {
+		return user, nil
+	}
+
+	review := &authV1.TokenReview{
+		Spec: authV1.TokenReviewSpec{
+			Token:     token,
+			Audiences: a.audiences,
+		},
+	}
+	if err := a.client.Create(ctx, review); err != nil {
+		return authV1.UserInfo{}, errors.Wrap(err, "couldn't review token")
+	}
+	if !review.Status.Authenticated {
+		return authV1.UserInfo{}, ErrUnauthenticated
+	}
+
+	a.store(key, review.Status.User)
+	return review.Status.User, nil
+}
+
+func (a *TokenReviewAuthenticator) cached(key [sha256.Size]byte) (authV1.UserInfo, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	r, ok := a.cache[key]
+	if !ok || a.now().After(r.expires) {
+		return authV1.UserInfo{}, false
+	}
+	return r.user, true
+}
+
+func (a *TokenReviewAuthenticator) store(key [sha256.Size]byte, user authV1.UserInfo) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	for k, r := range a.cache {
+		if now.After(r.expires) {
+			delete(a.cache, k)
+		}
+	}
+	a.cache[key] = cachedReview{user: user, expires: now.Add(tokenReviewCacheTTL)}
+}
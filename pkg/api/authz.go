@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	authV1 "k8s.io/api/authentication/v1"
+	authzV1 "k8s.io/api/authorization/v1"
+	coreV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/epam/edp-reconciler/v2/pkg/controller/helper"
+)
+
+const accessReviewCacheTTL = time.Minute
+
+// tenantAccess is the access to the tenant namespace the user has to be granted to read the tenant data
+var tenantAccess = authzV1.ResourceAttributes{Verb: "get", Group: "v2.edp.epam.com", Resource: "codebases"}
+
+// Authorizer checks whether the user may read data of the tenant (edp name)
+type Authorizer interface {
+	Authorize(ctx context.Context, user authV1.UserInfo, tenant string) (bool, error)
+}
+
+type accessKey struct {
+	username string
+	tenant   string
+}
+
+// SubjectAccessReviewAuthorizer asks Kubernetes SubjectAccessReview API whether the user is granted tenantAccess
+// to the namespace of the tenant. Tenant is served from the namespace named after it, which has to have
+// edp-config CM naming the same tenant. Allowed reviews are cached for a minute.
+type SubjectAccessReviewAuthorizer struct {
+	client client.Client
+
+	mu    sync.Mutex
+	cache map[accessKey]time.Time
+	now   func() time.Time
+}
+
+func NewSubjectAccessReviewAuthorizer(client client.Client) *SubjectAccessReviewAuthorizer {
+	return &SubjectAccessReviewAuthorizer{
+		client: client,
+		cache:  map[accessKey]time.Time{},
+		now:    time.Now,
+	}
+}
+
+func (a *SubjectAccessReviewAuthorizer) Authorize(ctx context.Context, user authV1.UserInfo, tenant string) (bool, error) {
+	key := accessKey{username: user.Username, tenant: tenant}
+	if a.cached(key) {
+		return true, nil
+	}
+
+	cm := &coreV1.ConfigMap{}
+	if err := a.client.Get(ctx, types.NamespacedName{Namespace: tenant, Name: helper.EDPConfigCM}, cm); err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "couldn't get namespace of %v tenant", tenant)
+	}
+	if cm.Data[helper.EDPNameKey] != tenant {
+		return false, nil
+	}
+
+	attributes := tenantAccess
+	attributes.Namespace = tenant
+	extra := map[string]authzV1.ExtraValue{}
+	for k, v := range user.Extra {
+		extra[k] = authzV1.ExtraValue(v)
+	}
+	review := &authzV1.SubjectAccessReview{
+		Spec: authzV1.SubjectAccessReviewSpec{
+			ResourceAttributes: &attributes,
+			User:               user.Username,
+			Groups:             user.Groups,
+			UID:                user.UID,
+			Extra:              extra,
+		},
+	}
+	if err := a.client.Create(ctx, review); err != nil {
+		return false, errors.Wrap(err, "couldn't review access")
+	}
+	if !review.Status.Allowed {
+		return false, nil
+	}
+
+	a.store(key)
+	return true, nil
+}
+
+func (a *SubjectAccessReviewAuthorizer) cached(key accessKey) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	expires, ok := a.cache[key]
+	return ok && !a.now().After(expires)
+}
+
+func (a *SubjectAccessReviewAuthorizer) store(key accessKey) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	for k, expires := range a.cache {
+		if now.After(expires) {
+			delete(a.cache, k)
+		}
+	}
+	a.cache[key] = now.Add(accessReviewCacheTTL)
+}
@@ -0,0 +1,66 @@
+package changefeed
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+)
+
+// Client consumes the change feed served by Server
+type Client struct {
+	conn  *grpc.ClientConn
+	token string
+}
+
+// Dial connects to the change feed server, the token is passed as bearer token with every call
+func Dial(ctx context.Context, addr, token string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.DialContext(ctx, addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, token: token}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Subscribe passes events of the stream to handle until the stream or ctx is done or handle fails
+func (c *Client) Subscribe(ctx context.Context, req SubscribeRequest, handle func(change.Event) error) error {
+	stream, err := c.conn.NewStream(c.authorize(ctx), &subscribeStreamDesc, subscribeMethod,
+		grpc.CallContentSubtype(codecName))
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(&req); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+
+	for {
+		var e change.Event
+		if err := stream.RecvMsg(&e); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := handle(e); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) Ack(ctx context.Context, req AckRequest) error {
+	return c.conn.Invoke(c.authorize(ctx), ackMethod, &req, &AckResponse{}, grpc.CallContentSubtype(codecName))
+}
+
+func (c *Client) authorize(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.token)
+}
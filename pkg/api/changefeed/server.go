@@ -0,0 +1,208 @@
+// Package changefeed serves the tenant change feed over gRPC server-streaming API
+package changefeed
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	authV1 "k8s.io/api/authentication/v1"
+
+	"github.com/epam/edp-reconciler/v2/pkg/api"
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+	"github.com/epam/edp-reconciler/v2/pkg/repository"
+	feed "github.com/epam/edp-reconciler/v2/pkg/service/changefeed"
+	"github.com/epam/edp-reconciler/v2/pkg/service/outbox"
+)
+
+// Server is the change feed gRPC server run by the manager. Every replica serves the feed from the DB,
+// so leader election isn't needed. Every call is authorized against the tenant, subscribers belong to the user
+// which has acknowledged them first.
+type Server struct {
+	addr  string
+	tls   api.TLSFiles
+	feed  feed.Feed
+	auth  api.Authenticator
+	authz api.Authorizer
+	log   logr.Logger
+}
+
+func NewServer(addr string, tls api.TLSFiles, db *sql.DB, auth api.Authenticator, authz api.Authorizer, log logr.Logger) *Server {
+	return &Server{
+		addr: addr,
+		tls:  tls,
+		feed: feed.Feed{
+			DB:  db,
+			Bus: feed.DefaultBus,
+		},
+		auth:  auth,
+		authz: authz,
+		log:   log.WithName("change-feed"),
+	}
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	creds, err := credentials.NewServerTLSFromFile(s.tls.CertFile, s.tls.KeyFile)
+	if err != nil {
+		return errors.Wrap(err, "couldn't load TLS certificate")
+	}
+
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't listen on %v", s.addr)
+	}
+
+	srv := grpc.NewServer(
+		grpc.Creds(creds),
+		grpc.UnaryInterceptor(s.authenticateUnary),
+		grpc.StreamInterceptor(s.authenticateStream),
+	)
+	RegisterChangeFeedServer(srv, s)
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.log.Info("starting change feed server", "address", s.addr)
+		errCh <- srv.Serve(lis)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		s.log.Info("shutting down change feed server")
+		srv.GracefulStop()
+		return nil
+	}
+}
+
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *Server) Subscribe(req *SubscribeRequest, stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	user, err := s.authorize(ctx, req.Tenant)
+	if err != nil {
+		return err
+	}
+
+	cursor := req.After
+	if cursor == 0 && req.Subscriber != "" {
+		c, err := s.feed.Cursor(ctx, req.Tenant, user, req.Subscriber)
+		if err != nil {
+			return s.status(err)
+		}
+		cursor = c
+	}
+
+	s.log.V(2).Info("subscriber has connected", "tenant", req.Tenant, "subscriber", req.Subscriber, "after", cursor)
+	err = s.feed.Follow(ctx, req.Tenant, cursor, func(e change.Event) error {
+		return stream.SendMsg(&e)
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return s.status(err)
+}
+
+func (s *Server) Ack(ctx context.Context, req *AckRequest) (*AckResponse, error) {
+	if req.Subscriber == "" {
+		return nil, status.Error(codes.InvalidArgument, "subscriber is required")
+	}
+	if outbox.IsReservedName(req.Subscriber) {
+		return nil, status.Errorf(codes.InvalidArgument, "subscriber name %v is reserved", req.Subscriber)
+	}
+	user, err := s.authorize(ctx, req.Tenant)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.feed.Ack(ctx, req.Tenant, user, req.Subscriber, req.EventId); err != nil {
+		return nil, s.status(err)
+	}
+	return &AckResponse{}, nil
+}
+
+func (s *Server) status(err error) error {
+	switch errors.Cause(err) {
+	case feed.ErrTenantNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case feed.ErrCursorPruned:
+		return status.Error(codes.OutOfRange, err.Error())
+	case repository.ErrChangeFeedCursorTaken:
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	s.log.Error(err, "change feed request has failed")
+	return status.Error(codes.Internal, "internal error")
+}
+
+func (s *Server) authenticateUnary(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	user, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(api.WithUser(ctx, user), req)
+}
+
+func (s *Server) authenticateStream(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	user, err := s.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: api.WithUser(stream.Context(), user)})
+}
+
+// authenticatedStream carries the authenticated user in its context
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate checks bearer token passed in authorization metadata of the call
+func (s *Server) authenticate(ctx context.Context) (authV1.UserInfo, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 || !strings.HasPrefix(values[0], "Bearer ") {
+		return authV1.UserInfo{}, status.Error(codes.Unauthenticated, "bearer token is required")
+	}
+
+	user, err := s.auth.Authenticate(ctx, strings.TrimPrefix(values[0], "Bearer "))
+	if err != nil {
+		if errors.Cause(err) != api.ErrUnauthenticated {
+			s.log.Error(err, "couldn't authenticate change feed request")
+		}
+		return authV1.UserInfo{}, status.Error(codes.Unauthenticated, "token is not authenticated")
+	}
+	s.log.V(2).Info("change feed request has been authenticated", "user", user.Username)
+	return user, nil
+}
+
+// authorize checks the authenticated user of the call may read the tenant feed and returns the user name
+func (s *Server) authorize(ctx context.Context, tenant string) (string, error) {
+	user, ok := api.UserFrom(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "call is not authenticated")
+	}
+	allowed, err := s.authz.Authorize(ctx, user, tenant)
+	if err != nil {
+		s.log.Error(err, "couldn't authorize change feed request")
+		return "", status.Error(codes.Internal, "internal error")
+	}
+	if !allowed {
+		return "", status.Error(codes.PermissionDenied, "access to the tenant is forbidden")
+	}
+	return user.Username, nil
+}
@@ -0,0 +1,167 @@
+package changefeed
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	authV1 "k8s.io/api/authentication/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/epam/edp-reconciler/v2/pkg/api"
+	"github.com/epam/edp-reconciler/v2/pkg/api/apitest"
+	"github.com/epam/edp-reconciler/v2/pkg/repository"
+)
+
+type fakeStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []interface{}
+}
+
+func (s *fakeStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeStream) SendMsg(m interface{}) error {
+	s.sent = append(s.sent, m)
+	return nil
+}
+
+func newTestServer(t *testing.T) (*Server, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return NewServer(":0", api.TLSFiles{}, db, apitest.StaticAuthenticator{"token": "user"},
+		apitest.StaticAuthorizer{"user": {"edp", "edp\"; drop"}}, ctrl.Log), mock
+}
+
+func withToken(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func asUser(name string) context.Context {
+	return api.WithUser(context.Background(), authV1.UserInfo{Username: name})
+}
+
+func TestServer_Authenticate(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	user, err := s.authenticate(withToken("token"))
+	assert.NoError(t, err)
+	assert.Equal(t, "user", user.Username)
+	_, err = s.authenticate(withToken("unknown"))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = s.authenticate(context.Background())
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestServer_AuthenticateStream(t *testing.T) {
+	s, _ := newTestServer(t)
+	called := false
+	handler := func(interface{}, grpc.ServerStream) error {
+		called = true
+		return nil
+	}
+
+	err := s.authenticateStream(s, &fakeStream{ctx: context.Background()}, &grpc.StreamServerInfo{}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.False(t, called)
+
+	assert.NoError(t, s.authenticateStream(s, &fakeStream{ctx: withToken("token")}, &grpc.StreamServerInfo{}, handler))
+	assert.True(t, called)
+}
+
+func TestServer_SubscribeToUnknownTenant(t *testing.T) {
+	s, mock := newTestServer(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select exists\(select 1 from pg_namespace`).WithArgs("edp").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectRollback()
+
+	stream := &fakeStream{ctx: asUser("user")}
+	err := s.Subscribe(&SubscribeRequest{Tenant: "edp", Subscriber: "cli"}, stream)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Empty(t, stream.sent)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	err = s.Subscribe(&SubscribeRequest{Tenant: "edp\"; drop", Subscriber: "cli"}, stream)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServer_AckRequiresSubscriber(t *testing.T) {
+	s, mock := newTestServer(t)
+
+	_, err := s.Ack(asUser("user"), &AckRequest{Tenant: "edp", EventId: 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = s.Ack(asUser("user"), &AckRequest{Tenant: "edp", Subscriber: "webhook/bot", EventId: 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServer_RejectsUnauthorizedTenant(t *testing.T) {
+	s, mock := newTestServer(t)
+
+	err := s.Subscribe(&SubscribeRequest{Tenant: "other", Subscriber: "cli"}, &fakeStream{ctx: asUser("user")})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = s.Ack(asUser("user"), &AckRequest{Tenant: "other", Subscriber: "cli", EventId: 1})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = s.Ack(context.Background(), &AckRequest{Tenant: "edp", Subscriber: "cli", EventId: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServer_StatusOfTakenSubscriber(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	err := s.status(errors.Wrap(repository.ErrChangeFeedCursorTaken, "subscriber cli"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestServer_SubscribeAfterPrunedEvents(t *testing.T) {
+	s, mock := newTestServer(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select exists\(select 1 from pg_namespace`).WithArgs("edp").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+	// every migration has been applied already
+	entries, err := os.ReadDir("../../db/migration/sql")
+	assert.NoError(t, err)
+	mock.ExpectBegin()
+	mock.ExpectExec(`pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`create table if not exists "edp".reconciler_migration`).WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, e := range entries {
+		mock.ExpectQuery(`from "edp".reconciler_migration`).WithArgs(e.Name()).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	}
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`from "edp".change_event_retention`).
+		WillReturnRows(sqlmock.NewRows([]string{"last_pruned_id"}).AddRow(10))
+	mock.ExpectRollback()
+
+	stream := &fakeStream{ctx: asUser("user")}
+	err = s.Subscribe(&SubscribeRequest{Tenant: "edp", After: 7}, stream)
+	assert.Equal(t, codes.OutOfRange, status.Code(err))
+	assert.Empty(t, stream.sent)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServer_StartFailsWithoutCertificate(t *testing.T) {
+	s := NewServer("127.0.0.1:0", api.TLSFiles{CertFile: "missing.crt", KeyFile: "missing.key"}, nil,
+		apitest.StaticAuthenticator{}, apitest.StaticAuthorizer{}, ctrl.Log)
+
+	assert.Error(t, s.Start(context.Background()))
+}
@@ -0,0 +1,89 @@
+package changefeed
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	serviceName     = "reconciler.changefeed.v1.ChangeFeed"
+	subscribeMethod = "/" + serviceName + "/Subscribe"
+	ackMethod       = "/" + serviceName + "/Ack"
+)
+
+// SubscribeRequest starts streaming of the tenant changes following After event id.
+// If After is zero, the stream resumes from the cursor acknowledged by the Subscriber.
+// Stream starting after events which have been pruned fails with OutOfRange status,
+// the subscriber could resume by acknowledging a later event.
+type SubscribeRequest struct {
+	Tenant     string `json:"tenant"`
+	Subscriber string `json:"subscriber,omitempty"`
+	After      int64  `json:"after,omitempty"`
+}
+
+// AckRequest stores id of the last event processed by the subscriber
+type AckRequest struct {
+	Tenant     string `json:"tenant"`
+	Subscriber string `json:"subscriber"`
+	EventId    int64  `json:"eventId"`
+}
+
+type AckResponse struct{}
+
+// ChangeFeedServer is the server API of the change feed service
+type ChangeFeedServer interface {
+	Subscribe(*SubscribeRequest, grpc.ServerStream) error
+	Ack(context.Context, *AckRequest) (*AckResponse, error)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*ChangeFeedServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Ack",
+			Handler:    ackHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       subscribeHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+var subscribeStreamDesc = serviceDesc.Streams[0]
+
+// RegisterChangeFeedServer registers the change feed service implementation on the gRPC server
+func RegisterChangeFeedServer(s *grpc.Server, srv ChangeFeedServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+func subscribeHandler(srv interface{}, stream grpc.ServerStream) error {
+	req := new(SubscribeRequest)
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	return srv.(ChangeFeedServer).Subscribe(req, stream)
+}
+
+func ackHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(AckRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChangeFeedServer).Ack(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ackMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChangeFeedServer).Ack(ctx, req.(*AckRequest))
+	}
+	return interceptor(ctx, req, info, handler)
+}
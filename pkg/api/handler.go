@@ -0,0 +1,247 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/view"
+	"github.com/epam/edp-reconciler/v2/pkg/service/query"
+)
+
+const (
+	tenantsPath  = "/api/v1/tenants/"
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+type listResponse struct {
+	Items      interface{} `json:"items"`
+	Limit      int         `json:"limit"`
+	Offset     int         `json:"offset"`
+	NextOffset *int        `json:"nextOffset,omitempty"`
+}
+
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+// Handler returns handler of the API routes:
+//
+//	GET /api/v1/tenants/{tenant}/codebases
+//	GET /api/v1/tenants/{tenant}/codebases/{codebase}/branches
+//	GET /api/v1/tenants/{tenant}/codebases/{codebase}/action-logs
+//	GET /api/v1/tenants/{tenant}/codebases/{codebase}/branches/{branch}/action-logs
+//	GET /api/v1/tenants/{tenant}/cd-pipelines
+//	GET /api/v1/tenants/{tenant}/cd-pipelines/{pipeline}/stages
+//	GET /api/v1/tenants/{tenant}/cd-pipelines/{pipeline}/docker-streams
+//	GET /api/v1/tenants/{tenant}/cd-pipelines/{pipeline}/lineage
+//	GET /api/v1/tenants/{tenant}/cd-pipelines/{pipeline}/action-logs
+//
+// Lists are paginated with limit and offset query parameters. Lineage is rendered in Graphviz DOT
+// if format=dot query parameter is set. Path segments containing slashes, e.g. branch names,
+// have to be URL-encoded. Users have to be granted access to the tenant namespace.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(tenantsPath, s.authenticate(s.authorize(http.HandlerFunc(s.route))))
+	return mux
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || token == r.Header.Get("Authorization") {
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Message: "bearer token is required"})
+			return
+		}
+
+		user, err := s.auth.Authenticate(r.Context(), token)
+		if err != nil {
+			if err != ErrUnauthenticated {
+				s.log.Error(err, "couldn't authenticate request")
+			}
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Message: "token is not authenticated"})
+			return
+		}
+
+		s.log.V(1).Info("serving API request", "user", user.Username, "path", r.URL.Path)
+		next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
+	})
+}
+
+// authorize checks the authenticated user may read data of the tenant the path starts with
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		segments, err := pathSegments(strings.TrimPrefix(r.URL.EscapedPath(), tenantsPath))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Message: err.Error()})
+			return
+		}
+
+		user, _ := UserFrom(r.Context())
+		allowed, err := s.authz.Authorize(r.Context(), user, segments[0])
+		if err != nil {
+			s.log.Error(err, "couldn't authorize request")
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Message: "internal error"})
+			return
+		}
+		if !allowed {
+			writeJSON(w, http.StatusForbidden, errorResponse{Message: "access to the tenant is forbidden"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Message: "only GET requests are supported"})
+		return
+	}
+
+	segments, err := pathSegments(strings.TrimPrefix(r.URL.EscapedPath(), tenantsPath))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Message: err.Error()})
+		return
+	}
+
+	p, err := pageFromQuery(r.URL.Query())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Message: err.Error()})
+		return
+	}
+
+	ctx := r.Context()
+	switch {
+	case match(segments, "", "codebases"):
+		items, err := s.query.Codebases(ctx, segments[0], fetchPage(p))
+		writePage(s, w, items, p, err)
+	case match(segments, "", "codebases", "", "branches"):
+		items, err := s.query.CodebaseBranches(ctx, segments[0], segments[2], fetchPage(p))
+		writePage(s, w, items, p, err)
+	case match(segments, "", "codebases", "", "action-logs"):
+		items, err := s.query.CodebaseActionLogs(ctx, segments[0], segments[2], fetchPage(p))
+		writePage(s, w, items, p, err)
+	case match(segments, "", "codebases", "", "branches", "", "action-logs"):
+		items, err := s.query.CodebaseBranchActionLogs(ctx, segments[0], segments[2], segments[4], fetchPage(p))
+		writePage(s, w, items, p, err)
+	case match(segments, "", "cd-pipelines"):
+		items, err := s.query.CDPipelines(ctx, segments[0], fetchPage(p))
+		writePage(s, w, items, p, err)
+	case match(segments, "", "cd-pipelines", "", "stages"):
+		items, err := s.query.CDPipelineStages(ctx, segments[0], segments[2], fetchPage(p))
+		writePage(s, w, items, p, err)
+	case match(segments, "", "cd-pipelines", "", "docker-streams"):
+		items, err := s.query.CDPipelineDockerStreams(ctx, segments[0], segments[2], fetchPage(p))
+		writePage(s, w, items, p, err)
+	case match(segments, "", "cd-pipelines", "", "lineage"):
+		graph, err := s.query.CDPipelineLineage(ctx, segments[0], segments[2])
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+		if r.URL.Query().Get("format") != "dot" {
+			writeJSON(w, http.StatusOK, graph)
+			return
+		}
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		w.WriteHeader(http.StatusOK)
+		_ = graph.WriteDOT(w)
+	case match(segments, "", "cd-pipelines", "", "action-logs"):
+		items, err := s.query.CDPipelineActionLogs(ctx, segments[0], segments[2], fetchPage(p))
+		writePage(s, w, items, p, err)
+	default:
+		writeJSON(w, http.StatusNotFound, errorResponse{Message: "route not found"})
+	}
+}
+
+// writePage writes the page of items fetched with fetchPage, the extra item tells there is a next page
+func writePage[T any](s *Server, w http.ResponseWriter, items []T, p view.Page, err error) {
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	more := len(items) > p.Limit
+	if more {
+		items = items[:p.Limit]
+	}
+	writeList(w, items, p, more)
+}
+
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	if cause := errors.Cause(err); cause == query.ErrNotFound || cause == query.ErrTenantNotFound {
+		writeJSON(w, http.StatusNotFound, errorResponse{Message: err.Error()})
+		return
+	}
+	s.log.Error(err, "couldn't serve API request")
+	writeJSON(w, http.StatusInternalServerError, errorResponse{Message: "internal error"})
+}
+
+func writeList(w http.ResponseWriter, items interface{}, p view.Page, more bool) {
+	resp := listResponse{Items: items, Limit: p.Limit, Offset: p.Offset}
+	if more {
+		next := p.Offset + p.Limit
+		resp.NextOffset = &next
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// match checks the path segments match the pattern, empty pattern segments match any value
+func match(segments []string, pattern ...string) bool {
+	if len(segments) != len(pattern) {
+		return false
+	}
+	for i, p := range pattern {
+		if segments[i] == "" || (p != "" && p != segments[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func pathSegments(escapedPath string) ([]string, error) {
+	parts := strings.Split(strings.TrimSuffix(escapedPath, "/"), "/")
+	segments := make([]string, 0, len(parts))
+	for _, p := range parts {
+		s, err := url.PathUnescape(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid path segment %v", p)
+		}
+		segments = append(segments, s)
+	}
+	return segments, nil
+}
+
+func pageFromQuery(values url.Values) (view.Page, error) {
+	p := view.Page{Limit: defaultLimit}
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return p, errors.Errorf("limit has to be a number from 1 to %v", maxLimit)
+		}
+		p.Limit = limit
+	}
+	if v := values.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return p, errors.New("offset has to be a non-negative number")
+		}
+		p.Offset = offset
+	}
+	return p, nil
+}
+
+// fetchPage asks for one extra row to find out whether there is a next page
+func fetchPage(p view.Page) view.Page {
+	return view.Page{Limit: p.Limit + 1, Offset: p.Offset}
+}
@@ -0,0 +1,221 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/epam/edp-reconciler/v2/pkg/api"
+	"github.com/epam/edp-reconciler/v2/pkg/api/apitest"
+)
+
+func newTestServer(t *testing.T) (*api.Server, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return api.NewServer(":0", api.TLSFiles{}, db,
+		apitest.StaticAuthenticator{"token": "user", "other-token": "other"},
+		apitest.StaticAuthorizer{"user": {"edp", "missing", `bad"name`}, "other": {"other"}}, ctrl.Log), mock
+}
+
+func serve(s *api.Server, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler_RejectsUnauthenticatedRequests(t *testing.T) {
+	s, mock := newTestServer(t)
+
+	assert.Equal(t, http.StatusUnauthorized, serve(s, "/api/v1/tenants/edp/codebases", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(s, "/api/v1/tenants/edp/codebases", "unknown").Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandler_RejectsUnauthorizedRequests(t *testing.T) {
+	s, mock := newTestServer(t)
+
+	rec := serve(s, "/api/v1/tenants/edp/codebases", "other-token")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandler_ListsCodebasesPageByPage(t *testing.T) {
+	s, mock := newTestServer(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select exists\(select 1 from pg_namespace`).WithArgs("edp").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	columns := []string{"id", "name", "type", "language", "framework", "build_tool", "strategy", "repository_url", "status",
+		"description", "versioning_type", "default_branch", "ci_tool", "git_server", "jenkins_slave", "job_provisioning",
+		"jira_server", "perf_server"}
+	mock.ExpectQuery(`from "edp".codebase c`).WithArgs(2, 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "app", "application", "java", nil, "maven", "create", nil, "created", nil, "default", "master",
+				"jenkins", "gerrit", "maven", "default", nil, nil).
+			AddRow(2, "lib", "library", "go", nil, "go", "create", nil, "created", nil, "default", "master",
+				"jenkins", "gerrit", "go", "default", nil, nil))
+	mock.ExpectRollback()
+
+	rec := serve(s, "/api/v1/tenants/edp/codebases?limit=1&offset=2", "token")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Items      []map[string]interface{} `json:"items"`
+		NextOffset *int                     `json:"nextOffset"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp.Items, 1)
+	assert.Equal(t, "app", resp.Items[0]["name"])
+	assert.NotContains(t, resp.Items[0], "framework")
+	assert.Equal(t, 3, *resp.NextOffset)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandler_ListsBranchActionLogs(t *testing.T) {
+	s, mock := newTestServer(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select exists\(select 1 from pg_namespace`).WithArgs("edp").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectPrepare(`select cb.id as codebase_branch_id`).ExpectQuery().WithArgs("feature/x", "app").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectQuery(`join "edp".codebase_branch_action_log l`).WithArgs(4, 51, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "detailed_message", "username", "updated_at", "action",
+			"action_message", "result"}).
+			AddRow(1, "", "admin", time.Now(), "codebase_branch_registration", "registered", "success"))
+	mock.ExpectRollback()
+
+	rec := serve(s, "/api/v1/tenants/edp/codebases/app/branches/feature%2Fx/action-logs", "token")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"actionMessage":"registered"`)
+	assert.NotContains(t, rec.Body.String(), "nextOffset")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandler_ListsStagesPageByPage(t *testing.T) {
+	s, mock := newTestServer(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select exists\(select 1 from pg_namespace`).WithArgs("edp").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectPrepare(`from "edp".cd_pipeline cdp`).ExpectQuery().WithArgs("pipe").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deployment_type", "status"}).
+			AddRow(1, "pipe", "container", "created"))
+	mock.ExpectQuery(`from "edp".cd_stage cs`).WithArgs("pipe", 2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "trigger_type", "order", "status",
+			"job_provisioning", "library", "library_branch"}).
+			AddRow(7, "qa", "", "manual", 1, "created", "default", nil, nil).
+			AddRow(8, "prod", "", "manual", 2, "created", "default", nil, nil))
+	mock.ExpectQuery(`where cs.id = any\(\$1\)`).WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"cd_stage_id", "quality_gate", "step_name", "codebase", "branch"}).
+			AddRow(7, "manual", "approve", nil, nil))
+	mock.ExpectRollback()
+
+	rec := serve(s, "/api/v1/tenants/edp/cd-pipelines/pipe/stages?limit=1&offset=1", "token")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Items      []map[string]interface{} `json:"items"`
+		Limit      int                      `json:"limit"`
+		NextOffset *int                     `json:"nextOffset"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp.Items, 1)
+	assert.Equal(t, "qa", resp.Items[0]["name"])
+	assert.Equal(t, 1, resp.Limit)
+	assert.Equal(t, 2, *resp.NextOffset)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandler_ListsDockerStreamsPageByPage(t *testing.T) {
+	s, mock := newTestServer(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select exists\(select 1 from pg_namespace`).WithArgs("edp").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectPrepare(`from "edp".cd_pipeline cdp`).ExpectQuery().WithArgs("pipe").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deployment_type", "status"}).
+			AddRow(1, "pipe", "container", "created"))
+	mock.ExpectQuery(`from "edp".stage_codebase_docker_stream scds`).WithArgs("pipe", 3, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"stage", "order", "codebase", "input", "output"}).
+			AddRow("qa", 1, "app", "app-master", "pipe-qa-app-verified").
+			AddRow("qa", 1, "lib", "lib-master", "pipe-qa-lib-verified"))
+	mock.ExpectRollback()
+
+	rec := serve(s, "/api/v1/tenants/edp/cd-pipelines/pipe/docker-streams?limit=2", "token")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Items      []map[string]interface{} `json:"items"`
+		Limit      int                      `json:"limit"`
+		NextOffset *int                     `json:"nextOffset"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp.Items, 2)
+	assert.Equal(t, 2, resp.Limit)
+	assert.Nil(t, resp.NextOffset)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandler_UnknownTenant(t *testing.T) {
+	s, mock := newTestServer(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select exists\(select 1 from pg_namespace`).WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	assert.Equal(t, http.StatusNotFound, serve(s, "/api/v1/tenants/missing/cd-pipelines", "token").Code)
+	assert.Equal(t, http.StatusNotFound, serve(s, `/api/v1/tenants/bad"name/cd-pipelines`, "token").Code)
+	assert.Equal(t, http.StatusNotFound, serve(s, "/api/v1/tenants/edp/unknown", "token").Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandler_RendersLineageInDOT(t *testing.T) {
+	s, mock := newTestServer(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select exists\(select 1 from pg_namespace`).WithArgs("edp").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectPrepare(`from "edp".cd_pipeline cdp`).ExpectQuery().WithArgs("pipe").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deployment_type", "status"}).
+			AddRow(1, "pipe", "container", "created"))
+	mock.ExpectQuery(`from "edp".cd_pipeline_docker_stream cpds`).WithArgs("pipe").
+		WillReturnRows(sqlmock.NewRows([]string{"oc_image_stream_name", "codebase", "branch"}).
+			AddRow("app-master", "app", "master"))
+	mock.ExpectQuery(`from "edp".stage_codebase_docker_stream scds`).WithArgs("pipe").
+		WillReturnRows(sqlmock.NewRows([]string{"stage", "order", "codebase", "input", "output"}).
+			AddRow("qa", 0, "app", "app-master", "pipe-qa-app-verified"))
+	mock.ExpectQuery(`from "edp".applications_to_promote atp`).WithArgs("pipe").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("app"))
+	mock.ExpectRollback()
+
+	rec := serve(s, "/api/v1/tenants/edp/cd-pipelines/pipe/lineage?format=dot", "token")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/vnd.graphviz", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `"app-master" -> "pipe-qa-app-verified" [label="qa", style=dashed];`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServer_StartFailsWithoutCertificate(t *testing.T) {
+	s := api.NewServer("127.0.0.1:0", api.TLSFiles{CertFile: "missing.crt", KeyFile: "missing.key"}, nil,
+		apitest.StaticAuthenticator{}, apitest.StaticAuthorizer{}, ctrl.Log)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Error(t, s.Start(ctx))
+}
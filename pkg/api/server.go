@@ -0,0 +1,72 @@
+// Package api serves read-only REST API over the tenant data stored by the reconciler
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/epam/edp-reconciler/v2/pkg/service/query"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+// TLSFiles are paths to PEM encoded certificate and private key the REST API and the change feed are served with
+type TLSFiles struct {
+	CertFile string
+	KeyFile  string
+}
+
+// Server is the API HTTP server run by the manager. It's served by every replica, so leader election isn't needed.
+type Server struct {
+	addr  string
+	tls   TLSFiles
+	query query.QueryService
+	auth  Authenticator
+	authz Authorizer
+	log   logr.Logger
+}
+
+func NewServer(addr string, tls TLSFiles, db *sql.DB, auth Authenticator, authz Authorizer, log logr.Logger) *Server {
+	return &Server{
+		addr: addr,
+		tls:  tls,
+		query: query.QueryService{
+			DB: db,
+		},
+		auth:  auth,
+		authz: authz,
+		log:   log.WithName("api"),
+	}
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.log.Info("starting API server", "address", s.addr)
+		errCh <- srv.ListenAndServeTLS(s.tls.CertFile, s.tls.KeyFile)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		s.log.Info("shutting down API server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
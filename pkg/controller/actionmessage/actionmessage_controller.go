@@ -0,0 +1,138 @@
+package actionmessage
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	coreV1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/epam/edp-reconciler/v2/pkg/controller/helper"
+	"github.com/epam/edp-reconciler/v2/pkg/model/actionmessage"
+)
+
+const (
+	// MessagesCM contains action message templates overriding the built-in ones.
+	// messages.yaml key holds default messages, messages.<locale>.yaml keys hold localized ones.
+	MessagesCM = "reconciler-action-messages"
+	// LocaleKey of edp-config CM selects locale of the tenant action messages
+	LocaleKey = "action_message_locale"
+
+	messagesKey    = "messages.yaml"
+	messagesPrefix = "messages."
+	messagesSuffix = ".yaml"
+)
+
+func NewReconcileActionMessages(client client.Client, log logr.Logger) *ReconcileActionMessages {
+	return &ReconcileActionMessages{
+		client: client,
+		log:    log.WithName("action-messages"),
+	}
+}
+
+// ReconcileActionMessages rebuilds the action message catalog of the tenant once messages or edp-config CM
+// of its namespace is changed. edp-config CM names the tenant and selects its locale.
+type ReconcileActionMessages struct {
+	client client.Client
+	log    logr.Logger
+}
+
+func (r *ReconcileActionMessages) SetupWithManager(mgr ctrl.Manager) error {
+	p := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return o.GetName() == MessagesCM || o.GetName() == helper.EDPConfigCM
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("action-messages").
+		For(&coreV1.ConfigMap{}, builder.WithPredicates(p)).
+		Complete(r)
+}
+
+func (r *ReconcileActionMessages) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log := r.log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	log.Info("Reconciling action messages")
+
+	config, err := r.getConfigMap(ctx, request.Namespace, helper.EDPConfigCM)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if config == nil || config.Data[helper.EDPNameKey] == "" {
+		log.Info("Namespace has no tenant. Skip action messages")
+		return reconcile.Result{}, nil
+	}
+	tenant := config.Data[helper.EDPNameKey]
+
+	messages, err := r.getConfigMap(ctx, request.Namespace, MessagesCM)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	c, err := buildCatalog(messages, config.Data[LocaleKey])
+	if err != nil {
+		// the previous catalog is kept until the CM is fixed, so there is nothing to retry
+		log.Error(err, "invalid action messages. Keep using the previous ones")
+		return reconcile.Result{}, nil
+	}
+	actionmessage.Set(tenant, c)
+
+	log.Info("Action messages have been updated", "tenant", tenant)
+	return reconcile.Result{}, nil
+}
+
+// getConfigMap returns nil if there is no such CM in the namespace
+func (r *ReconcileActionMessages) getConfigMap(ctx context.Context, namespace, name string) (*coreV1.ConfigMap, error) {
+	cm := &coreV1.ConfigMap{}
+	if err := r.client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, cm); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "couldn't get %v/%v config map", namespace, name)
+	}
+	return cm, nil
+}
+
+// buildCatalog compiles messages of the CM in the locale, missing CM leaves the built-in messages
+func buildCatalog(cm *coreV1.ConfigMap, locale string) (*actionmessage.Catalog, error) {
+	var messages actionmessage.Messages
+	localized := map[string]actionmessage.Messages{}
+	if cm != nil {
+		for key, data := range cm.Data {
+			l, ok := messagesLocale(key)
+			if !ok {
+				continue
+			}
+
+			m, err := actionmessage.ParseMessages(data)
+			if err != nil {
+				return nil, errors.Wrapf(err, "couldn't parse %v of %v/%v", key, cm.Namespace, cm.Name)
+			}
+			if l == "" {
+				messages = m
+				continue
+			}
+			localized[l] = m
+		}
+	}
+
+	return actionmessage.NewCatalog(messages, localized, locale)
+}
+
+// messagesLocale returns locale of the messages CM key, empty locale stands for default messages
+func messagesLocale(key string) (string, bool) {
+	if key == messagesKey {
+		return "", true
+	}
+	if !strings.HasPrefix(key, messagesPrefix) || !strings.HasSuffix(key, messagesSuffix) {
+		return "", false
+	}
+	locale := strings.TrimSuffix(strings.TrimPrefix(key, messagesPrefix), messagesSuffix)
+	return locale, locale != ""
+}
@@ -0,0 +1,51 @@
+package actionmessage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/epam/edp-reconciler/v2/pkg/controller/helper"
+	"github.com/epam/edp-reconciler/v2/pkg/model/actionmessage"
+)
+
+func configMap(ns, name string, data map[string]string) *coreV1.ConfigMap {
+	return &coreV1.ConfigMap{
+		ObjectMeta: metaV1.ObjectMeta{Name: name, Namespace: ns},
+		Data:       data,
+	}
+}
+
+func TestReconcile_OverridesOnlyOwnTenantMessages(t *testing.T) {
+	cl := fake.NewClientBuilder().WithRuntimeObjects(
+		configMap("ns-a", helper.EDPConfigCM, map[string]string{helper.EDPNameKey: "tenant-a", LocaleKey: "uk"}),
+		configMap("ns-a", MessagesCM, map[string]string{
+			messagesKey:        "codebase:\n  clean_data: Tenant clean {{.Name}}\n",
+			"messages.uk.yaml": "codebase:\n  put_s2i: S2I {{.Name}}\n",
+		}),
+		configMap("ns-b", helper.EDPConfigCM, map[string]string{helper.EDPNameKey: "tenant-b"}),
+	).Build()
+	r := NewReconcileActionMessages(cl, ctrl.Log)
+
+	for _, ns := range []string{"ns-a", "ns-b"} {
+		_, err := r.Reconcile(context.Background(), reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: ns, Name: helper.EDPConfigCM},
+		})
+		assert.NoError(t, err)
+	}
+
+	d := actionmessage.Data{Name: "app", Action: "clean_data"}
+	assert.Equal(t, "Tenant clean app", actionmessage.Render("tenant-a", actionmessage.Codebase, d))
+	assert.Equal(t, "Clean temporary data for app codebase", actionmessage.Render("tenant-b", actionmessage.Codebase, d))
+
+	d.Action = "put_s2i"
+	assert.Equal(t, "S2I app", actionmessage.Render("tenant-a", actionmessage.Codebase, d))
+	assert.Equal(t, "Put s2i for app codebase", actionmessage.Render("tenant-b", actionmessage.Codebase, d))
+}
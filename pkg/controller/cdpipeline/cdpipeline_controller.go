@@ -8,6 +8,7 @@ import (
 	cdPipeApi "github.com/epam/edp-cd-pipeline-operator/v2/pkg/apis/edp/v1"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	coreV1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -16,31 +17,29 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/epam/edp-reconciler/v2/pkg/controller/helper"
 	"github.com/epam/edp-reconciler/v2/pkg/db"
 	"github.com/epam/edp-reconciler/v2/pkg/model/cdpipeline"
-	"github.com/epam/edp-reconciler/v2/pkg/platform"
 	"github.com/epam/edp-reconciler/v2/pkg/service/cd-pipeline"
 )
 
-const cdPipelineReconcileFinalizerName = "cdpipeline.reconciler.finalizer.name"
-
-func NewReconcileCDPipeline(client client.Client, scheme *runtime.Scheme, log logr.Logger) (*ReconcileCDPipeline, error) {
-	cs, err := platform.CreateOpenshiftClients()
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to create openshift clients")
-	}
+const (
+	cdPipelineReconcileFinalizerName = "cdpipeline.reconciler.finalizer.name"
+	missingDockerStreamsDelay        = 30 * time.Second
+)
 
+func NewReconcileCDPipeline(client client.Client, scheme *runtime.Scheme, log logr.Logger) *ReconcileCDPipeline {
 	return &ReconcileCDPipeline{
 		client: client,
 		scheme: scheme,
 		pipe: cd_pipeline.CdPipelineService{
-			DB:        db.Instance,
-			ClientSet: *cs,
+			DB:     db.Instance,
+			Client: client,
 		},
 		log: log.WithName("cd-pipeline"),
-	}, nil
+	}
 }
 
 type ReconcileCDPipeline struct {
@@ -67,12 +66,20 @@ func (r *ReconcileCDPipeline) SetupWithManager(mgr ctrl.Manager) error {
 			if newObject.DeletionTimestamp != nil {
 				return true
 			}
+
+			if helper.PausedAnnotationChanged(oldObject, newObject) ||
+				helper.ResyncRequested(oldObject, newObject) {
+				return true
+			}
 			return false
 		},
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&cdPipeApi.CDPipeline{}, builder.WithPredicates(p)).
+		Watches(&source.Kind{Type: &coreV1.ConfigMap{}},
+			helper.EnqueueAllOnResume(r.client, &cdPipeApi.CDPipelineList{}),
+			builder.WithPredicates(helper.EDPConfigResumePredicate())).
 		Complete(r)
 }
 
@@ -99,10 +106,21 @@ func (r *ReconcileCDPipeline) Reconcile(ctx context.Context, request reconcile.R
 		return reconcile.Result{RequeueAfter: 2 * time.Second}, nil
 	}
 
-	if res, err := r.tryToDeleteCDPipeline(ctx, instance, *edpN); err != nil || res != nil {
+	paused, err := helper.IsPaused(r.client, instance)
+	if err != nil {
+		log.Error(err, "cannot check whether cd pipeline sync is paused")
+		return reconcile.Result{RequeueAfter: 2 * time.Second}, nil
+	}
+
+	if res, err := r.tryToDeleteCDPipeline(ctx, instance, *edpN, paused); err != nil || res != nil {
 		return *res, err
 	}
 
+	if paused {
+		log.Info("CD pipeline sync is paused. Skip reconciling")
+		return reconcile.Result{}, nil
+	}
+
 	cdp, err := cdpipeline.ConvertToCDPipeline(*instance, *edpN)
 	if err != nil {
 		log.Error(err, "cannot convert to cd pipeline dto")
@@ -110,6 +128,10 @@ func (r *ReconcileCDPipeline) Reconcile(ctx context.Context, request reconcile.R
 	}
 	err = r.pipe.PutCDPipeline(*cdp)
 	if err != nil {
+		if errors.Cause(err) == cd_pipeline.ErrDockerStreamsNotFound {
+			log.Info("Input docker streams of CD pipeline have not been synced yet. Retrying later", "reason", err.Error())
+			return reconcile.Result{RequeueAfter: missingDockerStreamsDelay}, nil
+		}
 		log.Error(err, "cannot put cd pipeline")
 		return reconcile.Result{RequeueAfter: 2 * time.Second}, nil
 	}
@@ -118,7 +140,7 @@ func (r *ReconcileCDPipeline) Reconcile(ctx context.Context, request reconcile.R
 	return reconcile.Result{}, nil
 }
 
-func (r *ReconcileCDPipeline) tryToDeleteCDPipeline(ctx context.Context, p *cdPipeApi.CDPipeline, schema string) (*reconcile.Result, error) {
+func (r *ReconcileCDPipeline) tryToDeleteCDPipeline(ctx context.Context, p *cdPipeApi.CDPipeline, schema string, paused bool) (*reconcile.Result, error) {
 	if p.GetDeletionTimestamp().IsZero() {
 		if !helper.ContainsString(p.ObjectMeta.Finalizers, cdPipelineReconcileFinalizerName) {
 			p.ObjectMeta.Finalizers = append(p.ObjectMeta.Finalizers, cdPipelineReconcileFinalizerName)
@@ -129,7 +151,9 @@ func (r *ReconcileCDPipeline) tryToDeleteCDPipeline(ctx context.Context, p *cdPi
 		return nil, nil
 	}
 
-	if err := r.pipe.DeleteCDPipeline(p.Name, schema); err != nil {
+	if paused {
+		r.log.Info("cd pipeline sync is paused. skip deleting db record", "name", p.Name)
+	} else if err := r.pipe.DeleteCDPipeline(p.Name, schema); err != nil {
 		return &reconcile.Result{RequeueAfter: 2 * time.Second}, err
 	}
 
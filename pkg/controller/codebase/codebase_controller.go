@@ -6,18 +6,17 @@ import (
 	"github.com/epam/edp-reconciler/v2/pkg/db"
 	"github.com/epam/edp-reconciler/v2/pkg/model/codebase"
 	"github.com/epam/edp-reconciler/v2/pkg/service"
-	"github.com/epam/edp-reconciler/v2/pkg/service/codebaseperfdatasource"
-	"github.com/epam/edp-reconciler/v2/pkg/service/perfdatasource"
-	"github.com/epam/edp-reconciler/v2/pkg/service/perfserver"
 	"github.com/go-logr/logr"
 	"reflect"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 	"time"
 
 	codebaseApi "github.com/epam/edp-codebase-operator/v2/pkg/apis/edp/v1"
+	coreV1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -32,15 +31,6 @@ func NewReconcileCodebase(client client.Client, scheme *runtime.Scheme, log logr
 		scheme: scheme,
 		codebase: service.CodebaseService{
 			DB: db.Instance,
-			DataSourceService: perfdatasource.PerfDataSourceService{
-				DB: db.Instance,
-			},
-			PerfService: perfserver.PerfServerService{
-				DB: db.Instance,
-			},
-			CodebaseDsService: codebaseperfdatasource.CodebasePerfDataSourceService{
-				DB: db.Instance,
-			},
 		},
 		log: log.WithName("codebase"),
 	}
@@ -71,12 +61,20 @@ func (r *ReconcileCodebase) SetupWithManager(mgr ctrl.Manager) error {
 			if newObject.DeletionTimestamp != nil {
 				return true
 			}
+
+			if helper.PausedAnnotationChanged(oldObject, newObject) ||
+				helper.ResyncRequested(oldObject, newObject) {
+				return true
+			}
 			return false
 		},
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&codebaseApi.Codebase{}, builder.WithPredicates(p)).
+		Watches(&source.Kind{Type: &coreV1.ConfigMap{}},
+			helper.EnqueueAllOnResume(r.client, &codebaseApi.CodebaseList{}),
+			builder.WithPredicates(helper.EDPConfigResumePredicate())).
 		Complete(r)
 }
 
@@ -99,11 +97,22 @@ func (r *ReconcileCodebase) Reconcile(ctx context.Context, request reconcile.Req
 		return reconcile.Result{RequeueAfter: 2 * time.Second}, nil
 	}
 
-	result, err := r.tryToDeleteCodebase(ctx, i, *edpN)
+	paused, err := helper.IsPaused(r.client, i)
+	if err != nil {
+		log.Error(err, "cannot check whether codebase sync is paused")
+		return reconcile.Result{RequeueAfter: 2 * time.Second}, nil
+	}
+
+	result, err := r.tryToDeleteCodebase(ctx, i, *edpN, paused)
 	if err != nil || result != nil {
 		return *result, err
 	}
 
+	if paused {
+		log.Info("Codebase sync is paused. Skip reconciling")
+		return reconcile.Result{}, nil
+	}
+
 	c, err := codebase.Convert(*i, *edpN)
 	if err != nil {
 		log.Error(err, "cannot convert codebase to dto")
@@ -119,7 +128,7 @@ func (r *ReconcileCodebase) Reconcile(ctx context.Context, request reconcile.Req
 	return reconcile.Result{}, nil
 }
 
-func (r *ReconcileCodebase) tryToDeleteCodebase(ctx context.Context, i *codebaseApi.Codebase, schema string) (*reconcile.Result, error) {
+func (r *ReconcileCodebase) tryToDeleteCodebase(ctx context.Context, i *codebaseApi.Codebase, schema string, paused bool) (*reconcile.Result, error) {
 	if i.GetDeletionTimestamp().IsZero() {
 		if !helper.ContainsString(i.ObjectMeta.Finalizers, codebaseReconcileFinalizerName) {
 			i.ObjectMeta.Finalizers = append(i.ObjectMeta.Finalizers, codebaseReconcileFinalizerName)
@@ -129,7 +138,9 @@ func (r *ReconcileCodebase) tryToDeleteCodebase(ctx context.Context, i *codebase
 		}
 		return nil, nil
 	}
-	if err := r.codebase.Delete(i.Spec.Perf, i.Name, schema); err != nil {
+	if paused {
+		r.log.Info("codebase sync is paused. skip deleting db record", "name", i.Name)
+	} else if err := r.codebase.Delete(i.Spec.Perf, i.Name, schema); err != nil {
 		return &reconcile.Result{}, err
 	}
 
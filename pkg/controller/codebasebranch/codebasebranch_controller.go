@@ -8,6 +8,7 @@ import (
 	codebaseApi "github.com/epam/edp-codebase-operator/v2/pkg/apis/edp/v1"
 	"github.com/go-logr/logr"
 	errWrap "github.com/pkg/errors"
+	coreV1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -16,6 +17,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/epam/edp-reconciler/v2/pkg/controller/helper"
 	"github.com/epam/edp-reconciler/v2/pkg/db"
@@ -23,7 +25,12 @@ import (
 	cbs "github.com/epam/edp-reconciler/v2/pkg/service/codebasebranch"
 )
 
-const codebaseBranchReconcileFinalizerName = "codebasebranch.reconciler.finalizer.name"
+const (
+	codebaseBranchReconcileFinalizerName = "codebasebranch.reconciler.finalizer.name"
+	// syncedCodebaseAnnotation keeps the codebase name the branch has been stored under in DB,
+	// so the branch row could be moved once spec.codebaseName is changed
+	syncedCodebaseAnnotation = "reconciler.edp.epam.com/codebase"
+)
 
 func NewReconcileCodebaseBranch(client client.Client, scheme *runtime.Scheme, log logr.Logger) *ReconcileCodebaseBranch {
 	return &ReconcileCodebaseBranch{
@@ -69,12 +76,20 @@ func (r *ReconcileCodebaseBranch) SetupWithManager(mgr ctrl.Manager) error {
 			if newObject.DeletionTimestamp != nil {
 				return true
 			}
+
+			if helper.PausedAnnotationChanged(oldObject, newObject) ||
+				helper.ResyncRequested(oldObject, newObject) {
+				return true
+			}
 			return false
 		},
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&codebaseApi.CodebaseBranch{}, builder.WithPredicates(p)).
+		Watches(&source.Kind{Type: &coreV1.ConfigMap{}},
+			helper.EnqueueAllOnResume(r.client, &codebaseApi.CodebaseBranchList{}),
+			builder.WithPredicates(helper.EDPConfigResumePredicate())).
 		Complete(r)
 }
 
@@ -95,22 +110,51 @@ func (r *ReconcileCodebaseBranch) Reconcile(ctx context.Context, request reconci
 		return reconcile.Result{RequeueAfter: 2 * time.Second}, errWrap.Wrap(err, "couldn't get edp name")
 	}
 
-	if res, err := r.tryToDeleteCodebaseBranch(ctx, i, *edpN); err != nil || res != nil {
+	paused, err := helper.IsPaused(r.client, i)
+	if err != nil {
+		return reconcile.Result{RequeueAfter: 2 * time.Second}, errWrap.Wrap(err, "couldn't check whether sync is paused")
+	}
+
+	if res, err := r.tryToDeleteCodebaseBranch(ctx, i, *edpN, paused); err != nil || res != nil {
 		return *res, err
 	}
 
+	if paused {
+		log.Info("CodebaseBranch sync is paused. Skip reconciling")
+		return reconcile.Result{}, nil
+	}
+
 	app, err := codebasebranch.ConvertToCodebaseBranch(*i, *edpN)
 	if err != nil {
 		return reconcile.Result{RequeueAfter: 2 * time.Second}, errWrap.Wrap(err, "cannot convert to codebase branch dto")
 	}
+	app.PreviousAppName = i.GetAnnotations()[syncedCodebaseAnnotation]
 	if err := r.branch.PutCodebaseBranch(*app); err != nil {
 		return reconcile.Result{RequeueAfter: 2 * time.Second}, errWrap.Wrap(err, "couldn't insert codebase branch")
 	}
+
+	if err := r.setSyncedCodebase(ctx, i); err != nil {
+		return reconcile.Result{RequeueAfter: 2 * time.Second}, errWrap.Wrap(err, "couldn't set synced codebase annotation")
+	}
 	log.Info("Reconciling has been finished successfully")
 	return reconcile.Result{}, nil
 }
 
-func (r *ReconcileCodebaseBranch) tryToDeleteCodebaseBranch(ctx context.Context, cb *codebaseApi.CodebaseBranch, schema string) (*reconcile.Result, error) {
+func (r *ReconcileCodebaseBranch) setSyncedCodebase(ctx context.Context, cb *codebaseApi.CodebaseBranch) error {
+	if cb.GetAnnotations()[syncedCodebaseAnnotation] == cb.Spec.CodebaseName {
+		return nil
+	}
+
+	annotations := cb.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[syncedCodebaseAnnotation] = cb.Spec.CodebaseName
+	cb.SetAnnotations(annotations)
+	return r.client.Update(ctx, cb)
+}
+
+func (r *ReconcileCodebaseBranch) tryToDeleteCodebaseBranch(ctx context.Context, cb *codebaseApi.CodebaseBranch, schema string, paused bool) (*reconcile.Result, error) {
 	if cb.GetDeletionTimestamp().IsZero() {
 		if !helper.ContainsString(cb.ObjectMeta.Finalizers, codebaseBranchReconcileFinalizerName) {
 			cb.ObjectMeta.Finalizers = append(cb.ObjectMeta.Finalizers, codebaseBranchReconcileFinalizerName)
@@ -121,7 +165,9 @@ func (r *ReconcileCodebaseBranch) tryToDeleteCodebaseBranch(ctx context.Context,
 		return nil, nil
 	}
 
-	if err := r.branch.Delete(cb.Spec.CodebaseName, cb.Spec.BranchName, schema); err != nil {
+	if paused {
+		r.log.Info("codebase branch sync is paused. skip deleting db record", "name", cb.Name)
+	} else if err := r.branch.Delete(syncedCodebase(cb), cb.Spec.BranchName, schema); err != nil {
 		return &reconcile.Result{RequeueAfter: 2 * time.Second}, err
 	}
 
@@ -131,3 +177,11 @@ func (r *ReconcileCodebaseBranch) tryToDeleteCodebaseBranch(ctx context.Context,
 	}
 	return &reconcile.Result{}, nil
 }
+
+// syncedCodebase returns the codebase the branch is stored under in DB
+func syncedCodebase(cb *codebaseApi.CodebaseBranch) string {
+	if name, ok := cb.GetAnnotations()[syncedCodebaseAnnotation]; ok && name != "" {
+		return name
+	}
+	return cb.Spec.CodebaseName
+}
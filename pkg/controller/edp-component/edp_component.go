@@ -7,6 +7,7 @@ import (
 
 	edpCompApi "github.com/epam/edp-component-operator/pkg/apis/v1/v1"
 	"github.com/go-logr/logr"
+	coreV1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -14,6 +15,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/epam/edp-reconciler/v2/pkg/controller/helper"
 	"github.com/epam/edp-reconciler/v2/pkg/db"
@@ -40,14 +42,19 @@ type EDPComponent struct {
 func (r *EDPComponent) SetupWithManager(mgr ctrl.Manager) error {
 	p := predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
-			old := e.ObjectOld.(*edpCompApi.EDPComponent).Spec
-			new := e.ObjectNew.(*edpCompApi.EDPComponent).Spec
-			return !reflect.DeepEqual(old, new)
+			oldObject := e.ObjectOld.(*edpCompApi.EDPComponent)
+			newObject := e.ObjectNew.(*edpCompApi.EDPComponent)
+			return !reflect.DeepEqual(oldObject.Spec, newObject.Spec) ||
+				helper.PausedAnnotationChanged(oldObject, newObject) ||
+				helper.ResyncRequested(oldObject, newObject)
 		},
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&edpCompApi.EDPComponent{}, builder.WithPredicates(p)).
+		Watches(&source.Kind{Type: &coreV1.ConfigMap{}},
+			helper.EnqueueAllOnResume(r.client, &edpCompApi.EDPComponentList{}),
+			builder.WithPredicates(helper.EDPConfigResumePredicate())).
 		Complete(r)
 }
 
@@ -63,6 +70,15 @@ func (r *EDPComponent) Reconcile(ctx context.Context, request reconcile.Request)
 		return reconcile.Result{}, err
 	}
 
+	paused, err := helper.IsPaused(r.client, i)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if paused {
+		log.Info("EDPComponent sync is paused. Skip reconciling")
+		return reconcile.Result{}, nil
+	}
+
 	c, err := model.ConvertToEDPComponent(*i)
 	if err != nil {
 		return reconcile.Result{}, err
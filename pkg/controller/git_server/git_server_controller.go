@@ -6,10 +6,13 @@ import (
 	codebaseApi "github.com/epam/edp-codebase-operator/v2/pkg/apis/edp/v1"
 	"github.com/go-logr/logr"
 	errWrap "github.com/pkg/errors"
+	coreV1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/epam/edp-reconciler/v2/pkg/controller/helper"
 	"github.com/epam/edp-reconciler/v2/pkg/db"
@@ -18,6 +21,8 @@ import (
 	"github.com/epam/edp-reconciler/v2/pkg/service/infrastructure"
 )
 
+const gitServerReconcileFinalizerName = "gitserver.reconciler.finalizer.name"
+
 func NewReconcileGitServer(client client.Client, log logr.Logger) *ReconcileGitServer {
 	return &ReconcileGitServer{
 		client: client,
@@ -32,15 +37,25 @@ func NewReconcileGitServer(client client.Client, log logr.Logger) *ReconcileGitS
 }
 
 type ReconcileGitServer struct {
-	client  client.Client
-	git     git.GitServerService
-	infraDb infrastructure.InfrastructureDbService
-	log     logr.Logger
+	client    client.Client
+	git       git.GitServerService
+	infraDb   infrastructure.InfrastructureDbService
+	finalizer helper.ServerFinalizer
+	log       logr.Logger
 }
 
 func (r *ReconcileGitServer) SetupWithManager(mgr ctrl.Manager) error {
+	r.finalizer = helper.ServerFinalizer{
+		Name:     gitServerReconcileFinalizerName,
+		Client:   r.client,
+		Recorder: mgr.GetEventRecorderFor("git-server-reconciler"),
+		Log:      r.log,
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&codebaseApi.GitServer{}).
+		Watches(&source.Kind{Type: &coreV1.ConfigMap{}},
+			helper.EnqueueAllOnResume(r.client, &codebaseApi.GitServerList{}),
+			builder.WithPredicates(helper.EDPConfigResumePredicate())).
 		Complete(r)
 }
 
@@ -59,6 +74,23 @@ func (r *ReconcileGitServer) Reconcile(ctx context.Context, request reconcile.Re
 		return reconcile.Result{}, err
 	}
 	log.WithValues("GitServer", instance)
+
+	paused, err := helper.IsPaused(r.client, instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if res, err := r.finalizer.TryToDelete(ctx, instance, paused, func() error {
+		return r.deleteGitServer(instance)
+	}); err != nil || res != nil {
+		return *res, err
+	}
+
+	if paused {
+		log.Info("GitServer sync is paused. Skip reconciling")
+		return reconcile.Result{}, nil
+	}
+
 	edpN, err := helper.GetEDPName(r.client, instance.Namespace)
 	if err != nil {
 		return reconcile.Result{}, err
@@ -84,3 +116,27 @@ func (r *ReconcileGitServer) Reconcile(ctx context.Context, request reconcile.Re
 
 	return reconcile.Result{}, nil
 }
+
+// deleteGitServer skips deleting the record if edp-config or the tenant schema has been already removed,
+// so the finalizer of the GitServer is dropped anyway
+func (r *ReconcileGitServer) deleteGitServer(gs *codebaseApi.GitServer) error {
+	edpN, err := helper.GetEDPName(r.client, gs.Namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			r.log.Info("edp-config doesn't exist. skip deleting db record", "name", gs.Name)
+			return nil
+		}
+		return err
+	}
+	schema := *edpN
+
+	exists, err := r.infraDb.DoesSchemaExist(schema)
+	if err != nil {
+		return errWrap.Wrap(err, "an error has occurred while checking schema in BD")
+	}
+	if !exists {
+		r.log.Info("schema doesn't exist. skip deleting db record", "name", gs.Name, "schema", schema)
+		return nil
+	}
+	return r.git.DeleteGitServer(gs.Name, schema, helper.DetachOnDelete(gs))
+}
@@ -1,5 +1,21 @@
 package helper
 
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/epam/edp-reconciler/v2/pkg/repository"
+)
+
 func ContainsString(slice []string, s string) bool {
 	for _, item := range slice {
 		if item == s {
@@ -18,3 +34,61 @@ func RemoveString(slice []string, s string) (result []string) {
 	}
 	return
 }
+
+// DetachOnDeleteAnnotation being set to true on a server CR makes its deletion null references
+// of codebases which still use the server instead of waiting until they stop using it
+const DetachOnDeleteAnnotation = "reconciler.edp.epam.com/detach-on-delete"
+
+// DetachOnDelete checks whether the object is annotated with truthy detach-on-delete annotation
+func DetachOnDelete(obj metav1.Object) bool {
+	detach, err := strconv.ParseBool(obj.GetAnnotations()[DetachOnDeleteAnnotation])
+	return err == nil && detach
+}
+
+// serverInUseDelay is the delay of the next attempt to delete the server which is still used by codebases
+const serverInUseDelay = 30 * time.Second
+
+// ServerFinalizer keeps the server CR until its DB record is deleted
+type ServerFinalizer struct {
+	Name     string
+	Client   client.Client
+	Recorder record.EventRecorder
+	Log      logr.Logger
+}
+
+// TryToDelete adds the finalizer to the server CR which isn't being deleted and returns nil result, so the reconcile
+// goes on. Once the CR is being deleted, its DB record is removed by deleteServer unless the sync is paused, then
+// the finalizer is removed. Deletion of the server which is still used by codebases is retried later.
+func (f ServerFinalizer) TryToDelete(ctx context.Context, obj client.Object, paused bool,
+	deleteServer func() error) (*reconcile.Result, error) {
+	if obj.GetDeletionTimestamp().IsZero() {
+		if !ContainsString(obj.GetFinalizers(), f.Name) {
+			obj.SetFinalizers(append(obj.GetFinalizers(), f.Name))
+			if err := f.Client.Update(ctx, obj); err != nil {
+				return &reconcile.Result{}, err
+			}
+		}
+		return nil, nil
+	}
+
+	if !ContainsString(obj.GetFinalizers(), f.Name) {
+		return &reconcile.Result{}, nil
+	}
+
+	if paused {
+		f.Log.Info("server sync is paused. skip deleting db record", "name", obj.GetName())
+	} else if err := deleteServer(); err != nil {
+		if errors.Cause(err) == repository.ErrServerInUse {
+			f.Log.Info("server is still in use. Retrying later", "name", obj.GetName(), "reason", err.Error())
+			f.Recorder.Event(obj, coreV1.EventTypeWarning, "InUse", err.Error())
+			return &reconcile.Result{RequeueAfter: serverInUseDelay}, nil
+		}
+		return &reconcile.Result{RequeueAfter: 2 * time.Second}, err
+	}
+
+	obj.SetFinalizers(RemoveString(obj.GetFinalizers(), f.Name))
+	if err := f.Client.Update(ctx, obj); err != nil {
+		return &reconcile.Result{RequeueAfter: 2 * time.Second}, err
+	}
+	return &reconcile.Result{}, nil
+}
@@ -0,0 +1,115 @@
+package helper
+
+import (
+	"context"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// PausedAnnotation stops database sync for the annotated CR or, being set on edp-config CM,
+// for every CR in the namespace.
+const PausedAnnotation = "reconciler.edp.epam.com/paused"
+
+var log = ctrl.Log.WithName("controller-helper")
+
+// IsPaused checks whether database sync is paused for the object either by its own
+// annotation or by the annotation on edp-config CM of the object namespace
+func IsPaused(client client.Client, obj metav1.Object) (bool, error) {
+	if HasPausedAnnotation(obj) {
+		return true, nil
+	}
+
+	cm := &v1.ConfigMap{}
+	err := client.Get(context.TODO(), types.NamespacedName{
+		Namespace: obj.GetNamespace(),
+		Name:      EDPConfigCM,
+	}, cm)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return HasPausedAnnotation(cm), nil
+}
+
+// HasPausedAnnotation checks whether the object is annotated with truthy paused annotation
+func HasPausedAnnotation(obj metav1.Object) bool {
+	paused, err := strconv.ParseBool(obj.GetAnnotations()[PausedAnnotation])
+	return err == nil && paused
+}
+
+// PausedAnnotationChanged checks whether the paused annotation has been set or removed,
+// so controllers could let such updates through their predicates
+func PausedAnnotationChanged(oldObj, newObj metav1.Object) bool {
+	return HasPausedAnnotation(oldObj) != HasPausedAnnotation(newObj)
+}
+
+// EDPConfigResumePredicate lets through only edp-config CM updates which remove the paused annotation
+// or request resync of the namespace
+func EDPConfigResumePredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectNew.GetName() != EDPConfigCM {
+				return false
+			}
+			resumed := HasPausedAnnotation(e.ObjectOld) && !HasPausedAnnotation(e.ObjectNew)
+			return resumed || ResyncRequested(e.ObjectOld, e.ObjectNew)
+		},
+	}
+}
+
+// EnqueueAllOnResume returns handler which enqueues every object of the provided list type
+// located in the namespace of the event object. It is used to force full sync of a tenant
+// once edp-config CM is not paused anymore or resync of the tenant is requested.
+func EnqueueAllOnResume(c client.Client, list client.ObjectList) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(o client.Object) []reconcile.Request {
+		l := list.DeepCopyObject().(client.ObjectList)
+		if err := c.List(context.TODO(), l, client.InNamespace(o.GetNamespace())); err != nil {
+			log.Error(err, "unable to list objects to resume sync", "namespace", o.GetNamespace())
+			return nil
+		}
+
+		items, err := meta.ExtractList(l)
+		if err != nil {
+			log.Error(err, "unable to extract objects to resume sync", "namespace", o.GetNamespace())
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, item := range items {
+			accessor, err := meta.Accessor(item)
+			if err != nil {
+				continue
+			}
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: accessor.GetNamespace(),
+					Name:      accessor.GetName(),
+				},
+			})
+		}
+		log.Info("resuming sync", "namespace", o.GetNamespace(), "objects", len(requests))
+		return requests
+	})
+}
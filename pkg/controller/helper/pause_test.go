@@ -0,0 +1,129 @@
+package helper
+
+import (
+	"testing"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestIsPausedByObjectAnnotation(t *testing.T) {
+	// given
+	obj := &coreV1.Secret{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   "test-ns",
+			Annotations: map[string]string{PausedAnnotation: "true"},
+		},
+	}
+	cl := fake.NewClientBuilder().Build()
+
+	// when
+	paused, err := IsPaused(cl, obj)
+
+	//then
+	if err != nil {
+		t.Errorf("IsPaused() error = %v, wantErr %v", err, nil)
+	}
+	if !paused {
+		t.Errorf("IsPaused() expected = %v, actual = %v", true, paused)
+	}
+}
+
+func TestIsPausedByEDPConfigAnnotation(t *testing.T) {
+	// given
+	ns := "test-ns"
+	cm := &coreV1.ConfigMap{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:        EDPConfigCM,
+			Namespace:   ns,
+			Annotations: map[string]string{PausedAnnotation: "true"},
+		},
+	}
+	obj := &coreV1.Secret{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      "foo",
+			Namespace: ns,
+		},
+	}
+	cl := fake.NewClientBuilder().WithRuntimeObjects(cm).Build()
+
+	// when
+	paused, err := IsPaused(cl, obj)
+
+	//then
+	if err != nil {
+		t.Errorf("IsPaused() error = %v, wantErr %v", err, nil)
+	}
+	if !paused {
+		t.Errorf("IsPaused() expected = %v, actual = %v", true, paused)
+	}
+}
+
+func TestIsPausedNotAnnotated(t *testing.T) {
+	// given
+	obj := &coreV1.Secret{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   "test-ns",
+			Annotations: map[string]string{PausedAnnotation: "false"},
+		},
+	}
+	cl := fake.NewClientBuilder().Build()
+
+	// when
+	paused, err := IsPaused(cl, obj)
+
+	//then
+	if err != nil {
+		t.Errorf("IsPaused() error = %v, wantErr %v", err, nil)
+	}
+	if paused {
+		t.Errorf("IsPaused() expected = %v, actual = %v", false, paused)
+	}
+}
+
+func TestEDPConfigResumePredicate(t *testing.T) {
+	paused := &coreV1.ConfigMap{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:        EDPConfigCM,
+			Annotations: map[string]string{PausedAnnotation: "true"},
+		},
+	}
+	resumed := &coreV1.ConfigMap{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: EDPConfigCM,
+		},
+	}
+	p := EDPConfigResumePredicate()
+
+	if !p.Update(event.UpdateEvent{ObjectOld: paused, ObjectNew: resumed}) {
+		t.Error("expected resume of edp-config to pass predicate")
+	}
+	if p.Update(event.UpdateEvent{ObjectOld: resumed, ObjectNew: paused}) {
+		t.Error("expected pause of edp-config to be filtered out")
+	}
+}
+
+func TestEDPConfigResumePredicate_Resync(t *testing.T) {
+	cm := &coreV1.ConfigMap{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: EDPConfigCM,
+		},
+	}
+	resync := cm.DeepCopy()
+	resync.Annotations = map[string]string{ResyncAnnotation: "2022-10-01T10:00:00Z"}
+	p := EDPConfigResumePredicate()
+
+	if !p.Update(event.UpdateEvent{ObjectOld: cm, ObjectNew: resync}) {
+		t.Error("expected resync of edp-config to pass predicate")
+	}
+	if p.Update(event.UpdateEvent{ObjectOld: resync, ObjectNew: resync.DeepCopy()}) {
+		t.Error("expected unchanged resync annotation to be filtered out")
+	}
+	if p.Update(event.UpdateEvent{ObjectOld: resync, ObjectNew: cm}) {
+		t.Error("expected removal of resync annotation to be filtered out")
+	}
+}
@@ -0,0 +1,16 @@
+package helper
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResyncAnnotation forces database sync of the annotated CR or, being set on edp-config CM,
+// of every CR in the namespace. Any new value, e.g. the current time, requests one more sync.
+const ResyncAnnotation = "reconciler.edp.epam.com/resync"
+
+// ResyncRequested checks whether the resync annotation has been set to a new value,
+// so controllers could let such updates through their predicates
+func ResyncRequested(oldObj, newObj metav1.Object) bool {
+	value := newObj.GetAnnotations()[ResyncAnnotation]
+	return value != "" && value != oldObj.GetAnnotations()[ResyncAnnotation]
+}
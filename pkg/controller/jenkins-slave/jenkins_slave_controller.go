@@ -9,6 +9,7 @@ import (
 	jenkinsApi "github.com/epam/edp-jenkins-operator/v2/pkg/apis/v2/v1"
 	"github.com/go-logr/logr"
 	errWrap "github.com/pkg/errors"
+	coreV1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -16,6 +17,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/epam/edp-reconciler/v2/pkg/controller/helper"
 	"github.com/epam/edp-reconciler/v2/pkg/db"
@@ -41,8 +43,15 @@ type ReconcileJenkinsSlave struct {
 func (r *ReconcileJenkinsSlave) SetupWithManager(mgr ctrl.Manager) error {
 	p := predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
-			old := e.ObjectOld.(*jenkinsApi.Jenkins).Status.Slaves
-			new := e.ObjectNew.(*jenkinsApi.Jenkins).Status.Slaves
+			oldObject := e.ObjectOld.(*jenkinsApi.Jenkins)
+			newObject := e.ObjectNew.(*jenkinsApi.Jenkins)
+			if helper.PausedAnnotationChanged(oldObject, newObject) ||
+				helper.ResyncRequested(oldObject, newObject) {
+				return true
+			}
+
+			old := oldObject.Status.Slaves
+			new := newObject.Status.Slaves
 
 			sort.Slice(old, func(i, j int) bool {
 				return old[i].Name < old[j].Name
@@ -57,6 +66,9 @@ func (r *ReconcileJenkinsSlave) SetupWithManager(mgr ctrl.Manager) error {
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&jenkinsApi.Jenkins{}, builder.WithPredicates(p)).
+		Watches(&source.Kind{Type: &coreV1.ConfigMap{}},
+			helper.EnqueueAllOnResume(r.client, &jenkinsApi.JenkinsList{}),
+			builder.WithPredicates(helper.EDPConfigResumePredicate())).
 		Complete(r)
 }
 
@@ -73,12 +85,21 @@ func (r *ReconcileJenkinsSlave) Reconcile(ctx context.Context, request reconcile
 	}
 	log.WithValues("Jenkins", jenkins)
 
+	paused, err := helper.IsPaused(r.client, jenkins)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if paused {
+		log.Info("Jenkins slaves sync is paused. Skip reconciling")
+		return reconcile.Result{}, nil
+	}
+
 	edpN, err := helper.GetEDPName(r.client, jenkins.Namespace)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
-	if err := r.jenkinsSlave.CreateSlavesOrDoNothing(jenkins.Status.Slaves, *edpN); err != nil {
+	if err := r.jenkinsSlave.PutSlaves(jenkins.Name, jenkins.Status.Slaves, *edpN); err != nil {
 		return reconcile.Result{RequeueAfter: time.Second * 120},
 			errWrap.Wrapf(err, "an error has occurred while adding {%v} slaves into DB", jenkins.Status.Slaves)
 	}
@@ -6,6 +6,7 @@ import (
 
 	jenkinsApi "github.com/epam/edp-jenkins-operator/v2/pkg/apis/v2/v1"
 	"github.com/go-logr/logr"
+	coreV1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -14,7 +15,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	"github.com/epam/edp-reconciler/v2/pkg/controller/helper"
 	"github.com/epam/edp-reconciler/v2/pkg/controller/jenkins_job/service"
 	"github.com/epam/edp-reconciler/v2/pkg/db"
 )
@@ -47,12 +50,19 @@ func (r *ReconcileJenkinsJob) SetupWithManager(mgr ctrl.Manager) error {
 				oldObject.Status.Value != newObject.Status.Value {
 				return true
 			}
+			if helper.PausedAnnotationChanged(oldObject, newObject) ||
+				helper.ResyncRequested(oldObject, newObject) {
+				return true
+			}
 			return false
 		},
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&jenkinsApi.JenkinsJob{}, builder.WithPredicates(p)).
+		Watches(&source.Kind{Type: &coreV1.ConfigMap{}},
+			helper.EnqueueAllOnResume(r.client, &jenkinsApi.JenkinsJobList{}),
+			builder.WithPredicates(helper.EDPConfigResumePredicate())).
 		Complete(r)
 }
 
@@ -67,6 +77,15 @@ func (r *ReconcileJenkinsJob) Reconcile(ctx context.Context, request reconcile.R
 		return reconcile.Result{}, err
 	}
 
+	paused, err := helper.IsPaused(r.client, i)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if paused {
+		log.Info("JenkinsJob sync is paused. Skip reconciling")
+		return reconcile.Result{}, nil
+	}
+
 	if err := r.jenkinsJob.UpdateActionLog(i); err != nil {
 		return reconcile.Result{RequeueAfter: 5 * time.Second}, err
 	}
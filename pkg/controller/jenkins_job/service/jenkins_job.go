@@ -16,18 +16,13 @@ import (
 	"github.com/epam/edp-reconciler/v2/pkg/controller/helper"
 	"github.com/epam/edp-reconciler/v2/pkg/db"
 	"github.com/epam/edp-reconciler/v2/pkg/model"
+	"github.com/epam/edp-reconciler/v2/pkg/model/actionmessage"
 	"github.com/epam/edp-reconciler/v2/pkg/repository"
 	"github.com/epam/edp-reconciler/v2/pkg/util/cluster"
 )
 
 const ErrorStatus = "error"
 
-var actions = map[string]string{
-	"platform_project_creation": "Create Platform Project for Stage %v",
-	"role_binding":              "Create Role Binding for project stage %v",
-	"create_jenkins_pipeline":   "Create Jenkins pipeline for CD Stage %v",
-}
-
 type JenkinsJobService struct {
 	DB     *sql.DB
 	Client client.Client
@@ -37,14 +32,14 @@ var log = ctrl.Log.WithName("jenkins-job-service")
 
 func (s JenkinsJobService) UpdateActionLog(jj *jenkinsApi.JenkinsJob) error {
 	log.V(2).Info("start adding action log for jenkins job", "name", jj.Name)
-	l, err := s.createActionLogModel(*jj)
+	edpN, err := helper.GetEDPName(s.Client, jj.Namespace)
 	if err != nil {
-		return err
+		return errors.Wrap(err, "cannot get edp name")
 	}
 
-	edpN, err := helper.GetEDPName(s.Client, jj.Namespace)
+	l, err := s.createActionLogModel(*jj, *edpN)
 	if err != nil {
-		return errors.Wrap(err, "cannot get edp name")
+		return err
 	}
 
 	stage, err := s.getStageInstanceOwner(*jj)
@@ -68,13 +63,7 @@ func (s JenkinsJobService) UpdateActionLog(jj *jenkinsApi.JenkinsJob) error {
 		return fmt.Errorf("cd pipeline %v is not inserted into table yet", stage.Spec.CdPipeline)
 	}
 
-	alid, err := repository.CreateEventActionLog(tx, *l, *edpN)
-	if err != nil {
-		_ = tx.Rollback()
-		return err
-	}
-
-	if err = repository.CreateCDPipelineActionLog(tx, p.Id, *alid, *edpN); err != nil {
+	if _, err = repository.CreateActionLogOnce(tx, repository.CDPipelineActionLogLink, p.Id, *l, *edpN); err != nil {
 		_ = tx.Rollback()
 		return err
 	}
@@ -87,7 +76,7 @@ func (s JenkinsJobService) UpdateActionLog(jj *jenkinsApi.JenkinsJob) error {
 
 }
 
-func (s JenkinsJobService) createActionLogModel(jj jenkinsApi.JenkinsJob) (*model.ActionLog, error) {
+func (s JenkinsJobService) createActionLogModel(jj jenkinsApi.JenkinsJob, edpName string) (*model.ActionLog, error) {
 	st := jj.Status
 	l := &model.ActionLog{
 		Username:        st.Username,
@@ -105,7 +94,12 @@ func (s JenkinsJobService) createActionLogModel(jj jenkinsApi.JenkinsJob) (*mode
 	if err != nil {
 		return nil, err
 	}
-	l.ActionMessage = fmt.Sprintf(actions[string(st.Action)], stage.Name)
+	l.ActionMessage = actionmessage.Render(edpName, actionmessage.JenkinsJob, actionmessage.Data{
+		Name:   stage.Name,
+		Action: l.Action,
+		Result: l.Result,
+		Object: jj,
+	})
 	return l, nil
 }
 
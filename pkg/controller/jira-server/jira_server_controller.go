@@ -5,6 +5,7 @@ import (
 
 	codebaseApi "github.com/epam/edp-codebase-operator/v2/pkg/apis/edp/v1"
 	"github.com/go-logr/logr"
+	coreV1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -12,6 +13,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/epam/edp-reconciler/v2/pkg/controller/helper"
 	"github.com/epam/edp-reconciler/v2/pkg/db"
@@ -19,6 +21,8 @@ import (
 	jiraserver "github.com/epam/edp-reconciler/v2/pkg/service/jira-server"
 )
 
+const jiraServerReconcileFinalizerName = "jiraserver.reconciler.finalizer.name"
+
 func NewReconcileJiraServer(client client.Client, log logr.Logger) *ReconcileJiraServer {
 	return &ReconcileJiraServer{
 		client: client,
@@ -32,10 +36,18 @@ func NewReconcileJiraServer(client client.Client, log logr.Logger) *ReconcileJir
 type ReconcileJiraServer struct {
 	client     client.Client
 	jiraServer jiraserver.JiraServerService
+	finalizer  helper.ServerFinalizer
 	log        logr.Logger
 }
 
 func (r *ReconcileJiraServer) SetupWithManager(mgr ctrl.Manager) error {
+	r.finalizer = helper.ServerFinalizer{
+		Name:     jiraServerReconcileFinalizerName,
+		Client:   r.client,
+		Recorder: mgr.GetEventRecorderFor("jira-server-reconciler"),
+		Log:      r.log,
+	}
+
 	p := predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
 			oldObject := e.ObjectOld.(*codebaseApi.JiraServer)
@@ -46,12 +58,22 @@ func (r *ReconcileJiraServer) SetupWithManager(mgr ctrl.Manager) error {
 			if oldObject.Status.Available != newObject.Status.Available {
 				return true
 			}
+			if newObject.DeletionTimestamp != nil {
+				return true
+			}
+			if helper.PausedAnnotationChanged(oldObject, newObject) ||
+				helper.ResyncRequested(oldObject, newObject) {
+				return true
+			}
 			return false
 		},
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&codebaseApi.JiraServer{}, builder.WithPredicates(p)).
+		Watches(&source.Kind{Type: &coreV1.ConfigMap{}},
+			helper.EnqueueAllOnResume(r.client, &codebaseApi.JiraServerList{}),
+			builder.WithPredicates(helper.EDPConfigResumePredicate())).
 		Complete(r)
 }
 
@@ -67,11 +89,27 @@ func (r *ReconcileJiraServer) Reconcile(ctx context.Context, request reconcile.R
 		return reconcile.Result{}, err
 	}
 
+	paused, err := helper.IsPaused(r.client, i)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
 	tenant, err := helper.GetEDPName(r.client, i.Namespace)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
+	if res, err := r.finalizer.TryToDelete(ctx, i, paused, func() error {
+		return r.jiraServer.DeleteJiraServer(i.Name, *tenant, helper.DetachOnDelete(i))
+	}); err != nil || res != nil {
+		return *res, err
+	}
+
+	if paused {
+		log.Info("JiraServer sync is paused. Skip reconciling")
+		return reconcile.Result{}, nil
+	}
+
 	if err := r.jiraServer.PutJiraServer(jiramodel.ConvertSpecToJira(*i, *tenant)); err != nil {
 		return reconcile.Result{}, err
 	}
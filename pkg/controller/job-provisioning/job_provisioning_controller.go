@@ -9,6 +9,7 @@ import (
 	jenkinsApi "github.com/epam/edp-jenkins-operator/v2/pkg/apis/v2/v1"
 	"github.com/go-logr/logr"
 	errWrap "github.com/pkg/errors"
+	coreV1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -16,6 +17,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/epam/edp-reconciler/v2/pkg/controller/helper"
 	"github.com/epam/edp-reconciler/v2/pkg/db"
@@ -41,8 +43,15 @@ type ReconcileJobProvision struct {
 func (r *ReconcileJobProvision) SetupWithManager(mgr ctrl.Manager) error {
 	p := predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
-			old := e.ObjectOld.(*jenkinsApi.Jenkins).Status.JobProvisions
-			new := e.ObjectNew.(*jenkinsApi.Jenkins).Status.JobProvisions
+			oldObject := e.ObjectOld.(*jenkinsApi.Jenkins)
+			newObject := e.ObjectNew.(*jenkinsApi.Jenkins)
+			if helper.PausedAnnotationChanged(oldObject, newObject) ||
+				helper.ResyncRequested(oldObject, newObject) {
+				return true
+			}
+
+			old := oldObject.Status.JobProvisions
+			new := newObject.Status.JobProvisions
 
 			sort.Slice(old, func(i, j int) bool {
 				return old[i].Name < old[j].Name
@@ -57,6 +66,9 @@ func (r *ReconcileJobProvision) SetupWithManager(mgr ctrl.Manager) error {
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&jenkinsApi.Jenkins{}, builder.WithPredicates(p)).
+		Watches(&source.Kind{Type: &coreV1.ConfigMap{}},
+			helper.EnqueueAllOnResume(r.client, &jenkinsApi.JenkinsList{}),
+			builder.WithPredicates(helper.EDPConfigResumePredicate())).
 		Complete(r)
 }
 
@@ -72,12 +84,21 @@ func (r *ReconcileJobProvision) Reconcile(ctx context.Context, request reconcile
 		return reconcile.Result{}, err
 	}
 
+	paused, err := helper.IsPaused(r.client, instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if paused {
+		log.Info("Job provisions sync is paused. Skip reconciling")
+		return reconcile.Result{}, nil
+	}
+
 	jp := instance.Status.JobProvisions
 	edpN, err := helper.GetEDPName(r.client, instance.Namespace)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
-	err = r.jobProvision.PutJobProvisions(jp, *edpN)
+	err = r.jobProvision.PutJobProvisions(instance.Name, jp, *edpN)
 	if err != nil {
 		return reconcile.Result{RequeueAfter: time.Second * 120},
 			errWrap.Wrapf(err, "an error has occurred while adding {%v} job provisions into DB", jp)
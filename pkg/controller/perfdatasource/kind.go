@@ -0,0 +1,63 @@
+package perfdatasource
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	perfApi "github.com/epam/edp-perf-operator/v2/pkg/apis/edp/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Kind describes a perf data source custom resource handled by the generic controller.
+// Data source CRs are expected to have spec.type and either Codebase owner reference or spec.codebaseName.
+type Kind struct {
+	GVK schema.GroupVersionKind
+	// Finalizer is set on data source CRs, defaults to <kind>.data.source.reconciler.finalizer.name
+	Finalizer string
+}
+
+var (
+	kindsMu sync.Mutex
+	kinds   []Kind
+)
+
+func init() {
+	Register(Kind{
+		GVK:       perfApi.SchemeGroupVersion.WithKind("PerfDataSourceJenkins"),
+		Finalizer: "jenkins.data.source.reconciler.finalizer.name",
+	})
+	Register(Kind{
+		GVK:       perfApi.SchemeGroupVersion.WithKind("PerfDataSourceSonar"),
+		Finalizer: "sonar.data.source.reconciler.finalizer.name",
+	})
+	Register(Kind{
+		GVK:       perfApi.SchemeGroupVersion.WithKind("PerfDataSourceGitLab"),
+		Finalizer: "gitlab.data.source.reconciler.finalizer.name",
+	})
+}
+
+// Register adds the data source kind to be reconciled. Kinds have to be registered before controllers are set up.
+func Register(k Kind) {
+	kindsMu.Lock()
+	defer kindsMu.Unlock()
+
+	if k.Finalizer == "" {
+		k.Finalizer = fmt.Sprintf("%v.data.source.reconciler.finalizer.name",
+			strings.ToLower(strings.TrimPrefix(k.GVK.Kind, "PerfDataSource")))
+	}
+	for i, registered := range kinds {
+		if registered.GVK == k.GVK {
+			kinds[i] = k
+			return
+		}
+	}
+	kinds = append(kinds, k)
+}
+
+// Kinds returns registered data source kinds
+func Kinds() []Kind {
+	kindsMu.Lock()
+	defer kindsMu.Unlock()
+	return append([]Kind(nil), kinds...)
+}
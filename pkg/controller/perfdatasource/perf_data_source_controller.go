@@ -0,0 +1,238 @@
+package perfdatasource
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	errWrap "github.com/pkg/errors"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/epam/edp-reconciler/v2/pkg/controller/helper"
+	"github.com/epam/edp-reconciler/v2/pkg/db"
+	"github.com/epam/edp-reconciler/v2/pkg/service/perfdatasource"
+	"github.com/epam/edp-reconciler/v2/pkg/util/cluster"
+)
+
+const (
+	codebaseKind = "Codebase"
+	// syncedDataSourceAnnotation keeps codebase and type the data source has been stored with in DB as <codebase>/<type>,
+	// so the stale record could be removed once they're changed
+	syncedDataSourceAnnotation = "reconciler.edp.epam.com/perf-data-source"
+	missingCodebaseDelay       = 30 * time.Second
+)
+
+func NewReconcilePerfDataSource(client client.Client, kind Kind, log logr.Logger) *ReconcilePerfDataSource {
+	return &ReconcilePerfDataSource{
+		client: client,
+		kind:   kind,
+		dsService: perfdatasource.PerfDataSourceService{
+			DB: db.Instance,
+		},
+		log: log.WithName("perf-data-source").WithValues("kind", kind.GVK.Kind),
+	}
+}
+
+// ReconcilePerfDataSource syncs codebase_perf_data_sources records with perf data source CRs of one kind
+type ReconcilePerfDataSource struct {
+	client    client.Client
+	kind      Kind
+	dsService perfdatasource.PerfDataSourceService
+	log       logr.Logger
+}
+
+func (r *ReconcilePerfDataSource) SetupWithManager(mgr ctrl.Manager) error {
+	p := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldObject := e.ObjectOld.(*unstructured.Unstructured)
+			newObject := e.ObjectNew.(*unstructured.Unstructured)
+			if newObject.GetDeletionTimestamp() != nil {
+				return true
+			}
+			if helper.PausedAnnotationChanged(oldObject, newObject) ||
+				helper.ResyncRequested(oldObject, newObject) {
+				return true
+			}
+			return !reflect.DeepEqual(oldObject.Object["spec"], newObject.Object["spec"])
+		},
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(r.kind.GVK.GroupVersion().WithKind(r.kind.GVK.Kind + "List"))
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(strings.ToLower(r.kind.GVK.Kind)).
+		For(r.newObject(), builder.WithPredicates(p)).
+		Watches(&source.Kind{Type: &coreV1.ConfigMap{}},
+			helper.EnqueueAllOnResume(r.client, list),
+			builder.WithPredicates(helper.EDPConfigResumePredicate())).
+		Complete(r)
+}
+
+func (r *ReconcilePerfDataSource) newObject() *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(r.kind.GVK)
+	return u
+}
+
+func (r *ReconcilePerfDataSource) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log := r.log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	log.Info("Reconciling perf data source")
+
+	ds := r.newObject()
+	if err := r.client.Get(ctx, request.NamespacedName, ds); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	schema, err := helper.GetEDPName(r.client, ds.GetNamespace())
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	paused, err := helper.IsPaused(r.client, ds)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if res, err := r.tryToDeleteDataSource(ctx, ds, *schema, paused); err != nil || res != nil {
+		return *res, err
+	}
+
+	if paused {
+		log.Info("perf data source sync is paused. Skip reconciling")
+		return reconcile.Result{}, nil
+	}
+
+	codebase, dsType := codebaseName(ds), dataSourceType(ds)
+	if codebase == "" || dsType == "" {
+		log.Info("perf data source doesn't specify codebase or type. Skip reconciling")
+		return reconcile.Result{}, nil
+	}
+
+	if err := r.removeStaleDataSource(ds, codebase, dsType, *schema); err != nil {
+		return reconcile.Result{RequeueAfter: 2 * time.Second}, err
+	}
+
+	if err := r.dsService.PutCodebaseDataSource(codebase, dsType, *schema); err != nil {
+		if errWrap.Cause(err) == perfdatasource.ErrCodebaseNotFound {
+			log.Info("codebase of perf data source has not been stored yet. Retrying later", "codebase", codebase)
+			return reconcile.Result{RequeueAfter: missingCodebaseDelay}, nil
+		}
+		return reconcile.Result{RequeueAfter: 2 * time.Second}, err
+	}
+
+	if err := r.setSyncedDataSource(ctx, ds, codebase, dsType); err != nil {
+		return reconcile.Result{RequeueAfter: 2 * time.Second}, errWrap.Wrap(err, "couldn't set synced data source annotation")
+	}
+
+	log.Info("perf data source reconciling has been finished successfully")
+	return reconcile.Result{}, nil
+}
+
+// removeStaleDataSource removes the record the data source has been stored with if its codebase or type is changed
+func (r *ReconcilePerfDataSource) removeStaleDataSource(ds *unstructured.Unstructured, codebase, dsType, schema string) error {
+	syncedCodebase, syncedType, ok := syncedDataSource(ds)
+	if !ok || syncedValue(syncedCodebase, syncedType) == syncedValue(codebase, dsType) {
+		return nil
+	}
+	return r.dsService.RemoveCodebaseDataSource(syncedCodebase, syncedType, schema)
+}
+
+// syncedDataSource returns codebase and type the data source has been stored with
+func syncedDataSource(ds *unstructured.Unstructured) (string, string, bool) {
+	synced, ok := ds.GetAnnotations()[syncedDataSourceAnnotation]
+	if !ok {
+		return "", "", false
+	}
+
+	parts := strings.SplitN(synced, "/", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+func (r *ReconcilePerfDataSource) setSyncedDataSource(ctx context.Context, ds *unstructured.Unstructured, codebase, dsType string) error {
+	value := syncedValue(codebase, dsType)
+	if ds.GetAnnotations()[syncedDataSourceAnnotation] == value {
+		return nil
+	}
+
+	annotations := ds.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[syncedDataSourceAnnotation] = value
+	ds.SetAnnotations(annotations)
+	return r.client.Update(ctx, ds)
+}
+
+func (r *ReconcilePerfDataSource) tryToDeleteDataSource(ctx context.Context, ds *unstructured.Unstructured, schema string, paused bool) (*reconcile.Result, error) {
+	if ds.GetDeletionTimestamp().IsZero() {
+		if !helper.ContainsString(ds.GetFinalizers(), r.kind.Finalizer) {
+			ds.SetFinalizers(append(ds.GetFinalizers(), r.kind.Finalizer))
+			if err := r.client.Update(ctx, ds); err != nil {
+				return &reconcile.Result{}, err
+			}
+		}
+		return nil, nil
+	}
+
+	if !helper.ContainsString(ds.GetFinalizers(), r.kind.Finalizer) {
+		return &reconcile.Result{}, nil
+	}
+
+	// the record is stored with the synced codebase and type, the spec could have been changed since then
+	codebase, dsType, ok := syncedDataSource(ds)
+	if !ok {
+		codebase, dsType = codebaseName(ds), dataSourceType(ds)
+	}
+	switch {
+	case paused:
+		r.log.Info("perf data source sync is paused. skip deleting db record", "data source", ds.GetName())
+	case codebase == "" || dsType == "":
+		r.log.Info("perf data source doesn't specify codebase or type. skip deleting db record", "data source", ds.GetName())
+	default:
+		if err := r.dsService.RemoveCodebaseDataSource(codebase, dsType, schema); err != nil {
+			return &reconcile.Result{}, err
+		}
+	}
+
+	ds.SetFinalizers(helper.RemoveString(ds.GetFinalizers(), r.kind.Finalizer))
+	if err := r.client.Update(ctx, ds); err != nil {
+		return &reconcile.Result{}, err
+	}
+	return &reconcile.Result{}, nil
+}
+
+// codebaseName returns name of the codebase the data source belongs to, owner reference takes precedence over spec
+func codebaseName(ds *unstructured.Unstructured) string {
+	if ow := cluster.GetOwnerReference(codebaseKind, ds.GetOwnerReferences()); ow != nil {
+		return ow.Name
+	}
+	name, _, _ := unstructured.NestedString(ds.Object, "spec", "codebaseName")
+	return name
+}
+
+func dataSourceType(ds *unstructured.Unstructured) string {
+	t, _, _ := unstructured.NestedString(ds.Object, "spec", "type")
+	return t
+}
+
+func syncedValue(codebase, dsType string) string {
+	return codebase + "/" + strings.ToUpper(dsType)
+}
@@ -6,41 +6,43 @@ import (
 	"time"
 
 	cdPipeApi "github.com/epam/edp-cd-pipeline-operator/v2/pkg/apis/edp/v1"
+	codebaseApi "github.com/epam/edp-codebase-operator/v2/pkg/apis/edp/v1"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	coreV1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/epam/edp-reconciler/v2/pkg/controller/helper"
 	"github.com/epam/edp-reconciler/v2/pkg/db"
 	"github.com/epam/edp-reconciler/v2/pkg/model/stage"
-	"github.com/epam/edp-reconciler/v2/pkg/platform"
 	stageService "github.com/epam/edp-reconciler/v2/pkg/service/stage"
 )
 
-const stageReconcileFinalizerName = "stage.reconciler.finalizer.name"
-
-func NewReconcileStage(client client.Client, scheme *runtime.Scheme, log logr.Logger) (*ReconcileStage, error) {
-	cs, err := platform.CreateOpenshiftClients()
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to create openshift clients")
-	}
+const (
+	stageReconcileFinalizerName = "stage.reconciler.finalizer.name"
+	unresolvedQualityGatesDelay = 30 * time.Second
+)
 
+func NewReconcileStage(client client.Client, scheme *runtime.Scheme, log logr.Logger) *ReconcileStage {
 	return &ReconcileStage{
 		client: client,
 		scheme: scheme,
 		service: stageService.StageService{
-			DB:        db.Instance,
-			ClientSet: *cs,
+			DB:     db.Instance,
+			Client: client,
 		},
 		log: log.WithName("cd-stage"),
-	}, nil
+	}
 }
 
 type ReconcileStage struct {
@@ -65,15 +67,67 @@ func (r *ReconcileStage) SetupWithManager(mgr ctrl.Manager) error {
 			if newObject.DeletionTimestamp != nil {
 				return true
 			}
+			if helper.PausedAnnotationChanged(oldObject, newObject) ||
+				helper.ResyncRequested(oldObject, newObject) {
+				return true
+			}
 			return false
 		},
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&cdPipeApi.Stage{}, builder.WithPredicates(p)).
+		Watches(&source.Kind{Type: &coreV1.ConfigMap{}},
+			helper.EnqueueAllOnResume(r.client, &cdPipeApi.StageList{}),
+			builder.WithPredicates(helper.EDPConfigResumePredicate())).
+		Watches(&source.Kind{Type: &codebaseApi.CodebaseBranch{}},
+			handler.EnqueueRequestsFromMapFunc(r.stagesReferencingBranch),
+			builder.WithPredicates(predicate.Funcs{
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					return false
+				},
+				DeleteFunc: func(e event.DeleteEvent) bool {
+					return false
+				},
+			})).
 		Complete(r)
 }
 
+// stagesReferencingBranch enqueues stages which quality gates reference created codebase branch,
+// so their autotests quality gates could be resolved without waiting for periodic requeue
+func (r *ReconcileStage) stagesReferencingBranch(o client.Object) []reconcile.Request {
+	cb, ok := o.(*codebaseApi.CodebaseBranch)
+	if !ok {
+		return nil
+	}
+
+	stages := &cdPipeApi.StageList{}
+	if err := r.client.List(context.TODO(), stages, client.InNamespace(cb.Namespace)); err != nil {
+		r.log.Error(err, "unable to list stages", "namespace", cb.Namespace)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, s := range stages.Items {
+		if referencesBranch(s.Spec.QualityGates, cb.Spec.CodebaseName, cb.Spec.BranchName) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: s.Namespace, Name: s.Name},
+			})
+		}
+	}
+	return requests
+}
+
+func referencesBranch(gates []cdPipeApi.QualityGate, codebaseName, branchName string) bool {
+	for _, g := range gates {
+		if g.AutotestName != nil && g.BranchName != nil &&
+			*g.AutotestName == codebaseName && *g.BranchName == branchName {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *ReconcileStage) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
 	log := r.log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	log.V(2).Info("Reconciling Stage")
@@ -90,23 +144,37 @@ func (r *ReconcileStage) Reconcile(ctx context.Context, request reconcile.Reques
 		return reconcile.Result{RequeueAfter: 2 * time.Second}, errors.Wrap(err, "cannot get edp name")
 	}
 
-	if res, err := r.tryToDeleteCDStage(ctx, i, *edpN); err != nil || res != nil {
+	paused, err := helper.IsPaused(r.client, i)
+	if err != nil {
+		return reconcile.Result{RequeueAfter: 2 * time.Second}, errors.Wrap(err, "cannot check whether sync is paused")
+	}
+
+	if res, err := r.tryToDeleteCDStage(ctx, i, *edpN, paused); err != nil || res != nil {
 		return *res, err
 	}
 
+	if paused {
+		log.Info("Stage sync is paused. Skip reconciling")
+		return reconcile.Result{}, nil
+	}
+
 	st, err := stage.ConvertToStage(*i, *edpN)
 	if err != nil {
 		return reconcile.Result{RequeueAfter: 2 * time.Second}, errors.Wrap(err, "couldn't convert to stage dto")
 	}
 
 	if err = r.service.PutStage(*st); err != nil {
+		if errors.Cause(err) == stageService.ErrUnresolvedQualityGates {
+			log.Info("Stage has been saved without some quality gates. Retrying later", "reason", err.Error())
+			return reconcile.Result{RequeueAfter: unresolvedQualityGatesDelay}, nil
+		}
 		return reconcile.Result{RequeueAfter: 2 * time.Second}, errors.Wrap(err, "couldn't put stage")
 	}
 	log.V(2).Info("Reconciling has been finished successfully")
 	return reconcile.Result{}, nil
 }
 
-func (r ReconcileStage) tryToDeleteCDStage(ctx context.Context, i *cdPipeApi.Stage, schema string) (*reconcile.Result, error) {
+func (r ReconcileStage) tryToDeleteCDStage(ctx context.Context, i *cdPipeApi.Stage, schema string, paused bool) (*reconcile.Result, error) {
 	if i.GetDeletionTimestamp().IsZero() {
 		if !helper.ContainsString(i.ObjectMeta.Finalizers, stageReconcileFinalizerName) {
 			i.ObjectMeta.Finalizers = append(i.ObjectMeta.Finalizers, stageReconcileFinalizerName)
@@ -117,7 +185,9 @@ func (r ReconcileStage) tryToDeleteCDStage(ctx context.Context, i *cdPipeApi.Sta
 		return nil, nil
 	}
 
-	if err := r.service.DeleteCDStage(i.Spec.CdPipeline, i.Spec.Name, schema); err != nil {
+	if paused {
+		r.log.Info("stage sync is paused. skip deleting db record", "name", i.Name)
+	} else if err := r.service.DeleteCDStage(i.Spec.CdPipeline, i.Spec.Name, schema); err != nil {
 		return &reconcile.Result{RequeueAfter: 2 * time.Second}, err
 	}
 
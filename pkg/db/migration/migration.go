@@ -0,0 +1,183 @@
+package migration
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	createMigrationTable = "create table if not exists \"%v\".reconciler_migration " +
+		"(version varchar(255) primary key, applied_at timestamp with time zone not null default now());"
+	lockSchema      = "select pg_advisory_xact_lock(hashtext($1));"
+	selectMigration = "select count(*) from \"%v\".reconciler_migration where version = $1;"
+	insertMigration = "insert into \"%v\".reconciler_migration(version) values ($1);"
+	selectMigrated  = "select table_schema from information_schema.tables " +
+		"where table_name = 'reconciler_migration' order by table_schema;"
+	selectVersions    = "select version from \"%v\".reconciler_migration;"
+	migrationsDir     = "sql"
+	schemaPlaceholder = "%[1]v"
+)
+
+// files contains migrations of tenant schemas. Every file is applied once per schema
+// in lexical order, schema name is substituted instead of %[1]v placeholder.
+//
+//go:embed sql/*.sql
+var files embed.FS
+
+var (
+	log      = ctrl.Log.WithName("db-migration")
+	migrated sync.Map
+)
+
+// Ensure applies not yet applied migrations to the tenant schema.
+// Schemas migrated by the current process are remembered, so it's cheap to call it before every write.
+func Ensure(db *sql.DB, schema string) error {
+	if _, ok := migrated.Load(schema); ok {
+		return nil
+	}
+
+	if err := migrate(db, schema); err != nil {
+		return errors.Wrapf(err, "couldn't migrate %v schema", schema)
+	}
+	migrated.Store(schema, true)
+	return nil
+}
+
+func migrate(db *sql.DB, schema string) error {
+	names, err := migrationNames()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := applyMigrations(txn, schema, names); err != nil {
+		_ = txn.Rollback()
+		return err
+	}
+	return txn.Commit()
+}
+
+func applyMigrations(txn *sql.Tx, schema string, names []string) error {
+	if _, err := txn.Exec(lockSchema, schema); err != nil {
+		return err
+	}
+
+	if _, err := txn.Exec(fmt.Sprintf(createMigrationTable, schema)); err != nil {
+		return errors.Wrap(err, "couldn't create migration table")
+	}
+
+	for _, name := range names {
+		var count int
+		if err := txn.QueryRow(fmt.Sprintf(selectMigration, schema), name).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		body, err := files.ReadFile(path.Join(migrationsDir, name))
+		if err != nil {
+			return err
+		}
+
+		if _, err := txn.Exec(strings.ReplaceAll(string(body), schemaPlaceholder, schema)); err != nil {
+			return errors.Wrapf(err, "couldn't apply %v migration", name)
+		}
+
+		if _, err := txn.Exec(fmt.Sprintf(insertMigration, schema), name); err != nil {
+			return err
+		}
+		log.Info("migration has been applied", "schema", schema, "migration", name)
+	}
+	return nil
+}
+
+// Schemas returns tenant schemas which have all migrations applied. Schemas the reconciler has never written to,
+// e.g. ones of other applications, are omitted, so background jobs never touch them and could rely on every
+// migrated table being in place.
+func Schemas(db *sql.DB) ([]string, error) {
+	names, err := migrationNames()
+	if err != nil {
+		return nil, err
+	}
+
+	txn, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = txn.Rollback() }()
+
+	candidates, err := queryStrings(txn, selectMigrated)
+	if err != nil {
+		return nil, err
+	}
+
+	var schemas []string
+	for _, schema := range candidates {
+		versions, err := queryStrings(txn, fmt.Sprintf(selectVersions, schema))
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't get migrations of %v schema", schema)
+		}
+		if applied(versions, names) {
+			schemas = append(schemas, schema)
+		}
+	}
+	return schemas, nil
+}
+
+func applied(versions, names []string) bool {
+	set := map[string]bool{}
+	for _, v := range versions {
+		set[v] = true
+	}
+	for _, n := range names {
+		if !set[n] {
+			return false
+		}
+	}
+	return true
+}
+
+func queryStrings(txn *sql.Tx, query string) ([]string, error) {
+	rows, err := txn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+func migrationNames() ([]string, error) {
+	entries, err := files.ReadDir(migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,77 @@
+package migration
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyMigrations_OnlyNotAppliedMigrationsShouldBeExecuted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	names, err := migrationNames()
+	assert.NoError(t, err)
+	assert.Contains(t, names, "0001_codebase_branch_action_log.sql")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("select pg_advisory_xact_lock")).WithArgs("tenant").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`create table if not exists "tenant".reconciler_migration`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`select count(*) from "tenant".reconciler_migration`)).WithArgs("0001_applied.sql").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`select count(*) from "tenant".reconciler_migration`)).
+		WithArgs("0001_codebase_branch_action_log.sql").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(regexp.QuoteMeta(`create table if not exists "tenant".codebase_branch_action_log`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`insert into "tenant".reconciler_migration`)).
+		WithArgs("0001_codebase_branch_action_log.sql").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = applyMigrations(tx, "tenant", []string{"0001_applied.sql", "0001_codebase_branch_action_log.sql"})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSchemas_NotMigratedSchemasShouldBeOmitted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	names, err := migrationNames()
+	assert.NoError(t, err)
+	current := sqlmock.NewRows([]string{"version"})
+	for _, n := range names {
+		current.AddRow(n)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("select table_schema from information_schema.tables")).
+		WillReturnRows(sqlmock.NewRows([]string{"table_schema"}).AddRow("outdated").AddRow("tenant"))
+	mock.ExpectQuery(regexp.QuoteMeta(`select version from "outdated".reconciler_migration`)).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(names[0]))
+	mock.ExpectQuery(regexp.QuoteMeta(`select version from "tenant".reconciler_migration`)).
+		WillReturnRows(current)
+	mock.ExpectRollback()
+
+	schemas, err := Schemas(db)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tenant"}, schemas)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,6 @@
+create table if not exists "%[1]v".codebase_branch_action_log
+(
+    codebase_branch_id integer not null references "%[1]v".codebase_branch (id) on delete cascade,
+    action_log_id      integer not null references "%[1]v".action_log (id) on delete cascade,
+    primary key (codebase_branch_id, action_log_id)
+);
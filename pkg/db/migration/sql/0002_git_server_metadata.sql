@@ -0,0 +1,12 @@
+alter table "%[1]v".git_server
+    add column if not exists git_user                    text,
+    add column if not exists ssh_port                    integer,
+    add column if not exists https_port                  integer,
+    add column if not exists create_code_review_pipeline boolean not null default false;
+
+create table if not exists "%[1]v".git_server_action_log
+(
+    git_server_id integer not null references "%[1]v".git_server (id) on delete cascade,
+    action_log_id integer not null references "%[1]v".action_log (id) on delete cascade,
+    primary key (git_server_id, action_log_id)
+);
@@ -0,0 +1,7 @@
+alter table "%[1]v".jenkins_slave
+    add column if not exists jenkins_name text,
+    add column if not exists removed      boolean not null default false;
+
+alter table "%[1]v".job_provisioning
+    add column if not exists jenkins_name text,
+    add column if not exists removed      boolean not null default false;
@@ -0,0 +1,10 @@
+alter table "%[1]v".codebase_perf_data_sources
+    add column if not exists spec_managed boolean not null default false,
+    add column if not exists cr_managed   boolean not null default false;
+
+-- links stored before their origin has been tracked could come from either the codebase spec or a data source CR,
+-- so they're kept on behalf of both. Codebase sync releases the spec origin of links which aren't in the spec,
+-- deletion of the data source CR releases the CR one.
+update "%[1]v".codebase_perf_data_sources
+set spec_managed = true,
+    cr_managed   = true;
@@ -0,0 +1,26 @@
+create table if not exists "%[1]v".change_event
+(
+    id         bigserial primary key,
+    kind       text                     not null,
+    name       text                     not null,
+    op         text                     not null,
+    before     jsonb,
+    after      jsonb,
+    created_at timestamp with time zone not null default now()
+);
+
+create table if not exists "%[1]v".change_feed_cursor
+(
+    subscriber    text primary key,
+    owner         text                     not null,
+    last_event_id bigint                   not null,
+    updated_at    timestamp with time zone not null default now()
+);
+
+-- single row table with id of the latest pruned event, subscribers resuming from an earlier cursor have missed events
+create table if not exists "%[1]v".change_event_retention
+(
+    id             boolean primary key default true check (id),
+    last_pruned_id bigint                   not null,
+    updated_at     timestamp with time zone not null default now()
+);
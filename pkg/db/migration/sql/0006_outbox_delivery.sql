@@ -0,0 +1,25 @@
+create table if not exists "%[1]v".outbox_cursor
+(
+    sink          text primary key,
+    last_event_id bigint                   not null,
+    updated_at    timestamp with time zone not null default now()
+);
+
+create table if not exists "%[1]v".outbox_delivery
+(
+    id              bigserial primary key,
+    sink            text                     not null,
+    event_id        bigint                   not null,
+    kind            text                     not null,
+    name            text                     not null,
+    payload         jsonb                    not null,
+    attempts        integer                  not null default 0,
+    next_attempt_at timestamp with time zone not null default now(),
+    last_error      text,
+    failed_at       timestamp with time zone,
+    created_at      timestamp with time zone not null default now(),
+    unique (sink, event_id)
+);
+
+create index if not exists outbox_delivery_entity_idx
+    on "%[1]v".outbox_delivery (sink, kind, name, id);
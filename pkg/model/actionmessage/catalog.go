@@ -0,0 +1,220 @@
+// Package actionmessage renders human readable action messages of action logs.
+// Messages are Go templates keyed by entity kind and action, built-in messages could be
+// overridden and localized from a ConfigMap.
+package actionmessage
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// Entity kinds action messages are defined for
+const (
+	Codebase       = "codebase"
+	CodebaseBranch = "codebase_branch"
+	CDPipeline     = "cd_pipeline"
+	CDStage        = "cd_stage"
+	JenkinsJob     = "jenkins_job"
+	GitServer      = "git_server"
+)
+
+// fallbackAction is the key of the message used for actions without their own message
+const fallbackAction = "default"
+
+var log = ctrl.Log.WithName("action-message")
+
+// Data is passed to message templates
+type Data struct {
+	Name   string
+	Action string
+	Result string
+	// Codebase is set for codebase branches only
+	Codebase string
+	// Object is the custom resource the event belongs to, e.g. {{.Object.Spec.Lang}}
+	Object interface{}
+}
+
+// Messages contains message templates by entity kind and action
+type Messages map[string]map[string]string
+
+var defaults = Messages{
+	Codebase: {
+		"codebase_registration":          "Codebase {{.Name}} registration",
+		"accept_codebase_registration":   "Accept codebase {{.Name}} registration",
+		"gerrit_repository_provisioning": "Gerrit repository for codebase {{.Name}} provisioning",
+		"jenkins_configuration":          "CI Jenkins pipelines codebase {{.Name}} provisioning",
+		"perf_registration":              "Registration codebase {{.Name}} in Perf",
+		"setup_deployment_templates":     "Setup deployment templates for codebase {{.Name}}",
+		"put_s2i":                        "Put s2i for {{.Name}} codebase",
+		"put_jenkins_folder":             "Put JenkinsFolder CR for {{.Name}} codebase",
+		"clean_data":                     "Clean temporary data for {{.Name}} codebase",
+		"import_project":                 "Start importing project {{.Name}}",
+		"put_version_file":               "Put VERSION file for Go {{.Name}} app",
+		"put_gitlab_ci_file":             "Put GitlabCI file for {{.Name}} codebase",
+		fallbackAction:                   "Action {{.Action}} for codebase {{.Name}}",
+	},
+	CodebaseBranch: {
+		"jenkins_configuration":               "CI Jenkins pipelines for codebase branch {{.Name}} provisioning for codebase {{.Codebase}}",
+		"codebase_branch_registration":        "Branch {{.Name}} for codebase {{.Codebase}} registration",
+		"accept_codebase_branch_registration": "Accept branch {{.Name}} for codebase {{.Codebase}} registration",
+		"put_branch_for_gitlab_ci_codebase":   "Create {{.Name}} branch for {{.Codebase}} codebase in Git ",
+		"trigger_release_job":                 "Trigger release job. Branch - {{.Name}}, Codebase - {{.Codebase}}",
+		"put_codebase_image_stream":           "Put Codebase ImageStream. Branch - {{.Name}}, Codebase - {{.Codebase}}",
+		"perf_data_source_cr_update":          "Update PerfDataSource CR. Branch - {{.Name}}, Codebase - {{.Codebase}}",
+		fallbackAction:                        "Action {{.Action}} for branch {{.Name}} of codebase {{.Codebase}}",
+	},
+	CDPipeline: {
+		"accept_cd_pipeline_registration": "Accept CD Pipeline {{.Name}} registration",
+		"jenkins_configuration":           "CI Jenkins pipelines {{.Name}} provisioning",
+		"setup_initial_structure":         "Initial structure for CD Pipeline {{.Name}} is created",
+		"cd_pipeline_registration":        "CD Pipeline {{.Name}} registration",
+		"create_jenkins_directory":        "Create directory in Jenkins for CD Pipeline {{.Name}}",
+		fallbackAction:                    "Action {{.Action}} for CD Pipeline {{.Name}}",
+	},
+	CDStage: {
+		"accept_cd_stage_registration":      "Accept CD Stage {{.Name}} registration",
+		"fetching_user_settings_config_map": "Fetch User Settings from config map during CD Stage {{.Name}} provision",
+		"platform_project_creation":         "Create Openshift Project for Stage {{.Name}}",
+		"jenkins_configuration":             "CI Jenkins pipelines {{.Name}} provisioning",
+		"setup_deployment_templates":        "Setup deployment templates for cd_stage {{.Name}}",
+		"create_jenkins_pipeline":           "Create Jenkins pipeline for CD Stage {{.Name}}",
+		fallbackAction:                      "Action {{.Action}} for CD Stage {{.Name}}",
+	},
+	GitServer: {
+		fallbackAction: "Action {{.Action}} for Git Server {{.Name}}",
+	},
+	JenkinsJob: {
+		"platform_project_creation": "Create Platform Project for Stage {{.Name}}",
+		"role_binding":              "Create Role Binding for project stage {{.Name}}",
+		"create_jenkins_pipeline":   "Create Jenkins pipeline for CD Stage {{.Name}}",
+		fallbackAction:              "Action {{.Action}} for CD Stage {{.Name}}",
+	},
+}
+
+type templates map[string]map[string]*template.Template
+
+// Catalog contains compiled templates of the tenant messages in the locale selected by the tenant
+type Catalog struct {
+	templates templates
+}
+
+// NewCatalog compiles messages overriding the built-in ones. Messages of the locale, if any,
+// override the resulting default messages.
+func NewCatalog(messages Messages, localized map[string]Messages, locale string) (*Catalog, error) {
+	base := merge(defaults, messages)
+	for l, m := range localized {
+		if _, err := compile(merge(base, m)); err != nil {
+			return nil, errors.Wrapf(err, "invalid %v messages", l)
+		}
+	}
+	if m, ok := localized[locale]; ok {
+		base = merge(base, m)
+	}
+
+	t, err := compile(base)
+	if err != nil {
+		return nil, err
+	}
+	return &Catalog{templates: t}, nil
+}
+
+// ParseMessages reads messages from YAML of kind to action to template mapping
+func ParseMessages(data string) (Messages, error) {
+	m := Messages{}
+	if err := yaml.Unmarshal([]byte(data), &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func merge(base, overrides Messages) Messages {
+	res := Messages{}
+	for _, m := range []Messages{base, overrides} {
+		for kind, actions := range m {
+			if res[kind] == nil {
+				res[kind] = map[string]string{}
+			}
+			for action, text := range actions {
+				res[kind][action] = text
+			}
+		}
+	}
+	return res
+}
+
+func compile(m Messages) (templates, error) {
+	res := templates{}
+	for kind, actions := range m {
+		res[kind] = map[string]*template.Template{}
+		for action, text := range actions {
+			t, err := template.New(kind + "." + action).Option("missingkey=error").Parse(text)
+			if err != nil {
+				return nil, err
+			}
+			res[kind][action] = t
+		}
+	}
+	return res, nil
+}
+
+// Render returns message of the entity action. Kind fallback message is used for
+// unknown actions and for messages which couldn't be rendered.
+func (c *Catalog) Render(kind string, d Data) string {
+	if d.Action == "" {
+		return ""
+	}
+
+	for _, action := range []string{d.Action, fallbackAction} {
+		t, ok := c.templates[kind][action]
+		if !ok {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, d); err != nil {
+			log.Error(err, "couldn't render action message", "kind", kind, "action", action)
+			continue
+		}
+		return buf.String()
+	}
+	return strings.TrimSpace(fmt.Sprintf("%v %v", d.Action, d.Name))
+}
+
+var (
+	mu       sync.RWMutex
+	builtin  = mustDefaultCatalog()
+	catalogs = map[string]*Catalog{}
+)
+
+func mustDefaultCatalog() *Catalog {
+	c, err := NewCatalog(nil, nil, "")
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Set replaces the catalog of the tenant (edp name)
+func Set(tenant string, c *Catalog) {
+	mu.Lock()
+	defer mu.Unlock()
+	catalogs[tenant] = c
+}
+
+// Render renders action message using the tenant catalog, tenants without one get the built-in messages
+func Render(tenant, kind string, d Data) string {
+	mu.RLock()
+	c, ok := catalogs[tenant]
+	mu.RUnlock()
+	if !ok {
+		c = builtin
+	}
+	return c.Render(kind, d)
+}
@@ -0,0 +1,86 @@
+package actionmessage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog_Render(t *testing.T) {
+	c, err := NewCatalog(Messages{
+		Codebase: {"codebase_registration": "Codebase {{.Name}} ({{.Object}}) registered"},
+	}, map[string]Messages{
+		"uk": {Codebase: {"clean_data": "Очищення даних {{.Name}}"}},
+	}, "")
+	assert.NoError(t, err)
+
+	d := Data{Name: "app", Action: "codebase_registration", Object: "java"}
+	assert.Equal(t, "Codebase app (java) registered", c.Render(Codebase, d))
+
+	d.Action = "clean_data"
+	assert.Equal(t, "Clean temporary data for app codebase", c.Render(Codebase, d))
+
+	d.Action = "unknown_action"
+	assert.Equal(t, "Action unknown_action for codebase app", c.Render(Codebase, d))
+
+	d.Action = ""
+	assert.Empty(t, c.Render(Codebase, d))
+}
+
+func TestCatalog_Render_BranchDefaults(t *testing.T) {
+	c, err := NewCatalog(nil, nil, "")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Branch master for codebase app registration", c.Render(CodebaseBranch, Data{
+		Name:     "master",
+		Codebase: "app",
+		Action:   "codebase_branch_registration",
+	}))
+}
+
+func TestCatalog_Render_BrokenTemplateFallsBack(t *testing.T) {
+	c, err := NewCatalog(Messages{
+		CDPipeline: {"cd_pipeline_registration": "{{.Object.Spec.Name}}"},
+	}, nil, "")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Action cd_pipeline_registration for CD Pipeline pipe", c.Render(CDPipeline, Data{
+		Name:   "pipe",
+		Action: "cd_pipeline_registration",
+		Object: struct{}{},
+	}))
+}
+
+func TestNewCatalog_InvalidTemplate(t *testing.T) {
+	_, err := NewCatalog(Messages{Codebase: {"clean_data": "{{.Name"}}, nil, "")
+	assert.Error(t, err)
+}
+
+func TestCatalog_Render_Locale(t *testing.T) {
+	c, err := NewCatalog(nil, map[string]Messages{
+		"uk": {Codebase: {"clean_data": "Очищення даних {{.Name}}"}},
+	}, "uk")
+	assert.NoError(t, err)
+
+	d := Data{Name: "app", Action: "clean_data"}
+	assert.Equal(t, "Очищення даних app", c.Render(Codebase, d))
+	d.Action = "put_s2i"
+	assert.Equal(t, "Put s2i for app codebase", c.Render(Codebase, d))
+}
+
+func TestRender_TenantCatalog(t *testing.T) {
+	c, err := NewCatalog(Messages{Codebase: {"clean_data": "Tenant clean {{.Name}}"}}, nil, "")
+	assert.NoError(t, err)
+	Set("tenant-a", c)
+	defer Set("tenant-a", builtin)
+
+	d := Data{Name: "app", Action: "clean_data"}
+	assert.Equal(t, "Tenant clean app", Render("tenant-a", Codebase, d))
+	assert.Equal(t, "Clean temporary data for app codebase", Render("tenant-b", Codebase, d))
+}
+
+func TestParseMessages(t *testing.T) {
+	m, err := ParseMessages("codebase:\n  clean_data: Clean {{.Name}}\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "Clean {{.Name}}", m[Codebase]["clean_data"])
+}
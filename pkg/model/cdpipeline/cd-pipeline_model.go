@@ -22,6 +22,7 @@ import (
 	cdPipeApi "github.com/epam/edp-cd-pipeline-operator/v2/pkg/apis/edp/v1"
 
 	"github.com/epam/edp-reconciler/v2/pkg/model"
+	"github.com/epam/edp-reconciler/v2/pkg/model/actionmessage"
 )
 
 type CDPipeline struct {
@@ -36,20 +37,12 @@ type CDPipeline struct {
 	DeploymentType        string
 }
 
-var cdPipelineActionMessageMap = map[string]string{
-	"accept_cd_pipeline_registration": "Accept CD Pipeline %v registration",
-	"jenkins_configuration":           "CI Jenkins pipelines %v provisioning",
-	"setup_initial_structure":         "Initial structure for CD Pipeline %v is created",
-	"cd_pipeline_registration":        "CD Pipeline %v registration",
-	"create_jenkins_directory":        "Create directory in Jenkins for CD Pipeline %v",
-}
-
 // ConvertToCDPipeline returns converted to DTO CDPipeline object from K8S.
 // An error occurs if method received nil instead of k8s object
 func ConvertToCDPipeline(k8sObject cdPipeApi.CDPipeline, edpName string) (*CDPipeline, error) {
 	spec := k8sObject.Spec
 
-	actionLog := convertCDPipelineActionLog(k8sObject.Name, k8sObject.Status)
+	actionLog := convertCDPipelineActionLog(k8sObject, edpName)
 
 	cdPipeline := CDPipeline{
 		Name:                  k8sObject.Spec.Name,
@@ -65,7 +58,8 @@ func ConvertToCDPipeline(k8sObject cdPipeApi.CDPipeline, edpName string) (*CDPip
 	return &cdPipeline, nil
 }
 
-func convertCDPipelineActionLog(cdPipelineName string, status cdPipeApi.CDPipelineStatus) *model.ActionLog {
+func convertCDPipelineActionLog(p cdPipeApi.CDPipeline, edpName string) *model.ActionLog {
+	status := p.Status
 
 	al := &model.ActionLog{
 		Event:           model.FormatStatus(status.Status),
@@ -81,6 +75,11 @@ func convertCDPipelineActionLog(cdPipelineName string, status cdPipeApi.CDPipeli
 		return al
 	}
 
-	al.ActionMessage = fmt.Sprintf(cdPipelineActionMessageMap[fmt.Sprint(status.Action)], cdPipelineName)
+	al.ActionMessage = actionmessage.Render(edpName, actionmessage.CDPipeline, actionmessage.Data{
+		Name:   p.Name,
+		Action: al.Action,
+		Result: al.Result,
+		Object: p,
+	})
 	return al
 }
@@ -100,7 +100,7 @@ func TestConvertMethodToCDPipeline(t *testing.T) {
 		t.Fatal(fmt.Sprintf("result is incorrect %v", result))
 	}
 
-	actionMessage := fmt.Sprintf(cdPipelineActionMessageMap[cdPipelineAction], name)
+	actionMessage := fmt.Sprintf("Initial structure for CD Pipeline %v is created", name)
 	if cdPipeline.ActionLog.ActionMessage != actionMessage {
 		t.Fatal(fmt.Sprintf("action message is incorrect %v", actionMessage))
 	}
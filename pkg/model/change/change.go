@@ -0,0 +1,51 @@
+// Package change describes modifications of entities stored by the reconciler
+package change
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Kind string
+
+const (
+	Codebase       Kind = "codebase"
+	CodebaseBranch Kind = "codebase_branch"
+	CDPipeline     Kind = "cd_pipeline"
+	CDStage        Kind = "cd_stage"
+)
+
+type Op string
+
+const (
+	Create Op = "create"
+	Update Op = "update"
+	Delete Op = "delete"
+)
+
+// Event is a modification of the entity. Id grows monotonically within the tenant and serves as a cursor of the feed.
+// Branch and stage names are prefixed with the codebase or pipeline name, e.g. app/master.
+// Before and After contain read models of the entity, Before is empty on creation and After on deletion.
+type Event struct {
+	Id        int64           `json:"id"`
+	Tenant    string          `json:"tenant"`
+	Kind      Kind            `json:"kind"`
+	Name      string          `json:"name"`
+	Op        Op              `json:"op"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// Notification is compact payload of the change published with pg_notify, consumers read the entity by its id
+type Notification struct {
+	Kind Kind   `json:"kind"`
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+	Op   Op     `json:"op"`
+}
+
+// Channel returns name of Postgres channel changes of the tenant are notified on
+func Channel(tenant string) string {
+	return "reconciler_" + tenant
+}
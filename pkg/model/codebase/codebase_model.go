@@ -17,12 +17,12 @@
 package codebase
 
 import (
-	"fmt"
 	"strings"
 
 	codebaseApi "github.com/epam/edp-codebase-operator/v2/pkg/apis/edp/v1"
 
 	"github.com/epam/edp-reconciler/v2/pkg/model"
+	"github.com/epam/edp-reconciler/v2/pkg/model/actionmessage"
 )
 
 const (
@@ -73,25 +73,10 @@ type Perf struct {
 	DataSources []string `json:"dataSources"`
 }
 
-var codebaseActionMessageMap = map[string]string{
-	"codebase_registration":          "Codebase %v registration",
-	"accept_codebase_registration":   "Accept codebase %v registration",
-	"gerrit_repository_provisioning": "Gerrit repository for codebase %v provisioning",
-	"jenkins_configuration":          "CI Jenkins pipelines codebase %v provisioning",
-	"perf_registration":              "Registration codebase %v in Perf",
-	"setup_deployment_templates":     "Setup deployment templates for codebase %v",
-	"put_s2i":                        "Put s2i for %v codebase",
-	"put_jenkins_folder":             "Put JenkinsFolder CR for %v codebase",
-	"clean_data":                     "Clean temporary data for %v codebase",
-	"import_project":                 "Start importing project %v",
-	"put_version_file":               "Put VERSION file for Go %v app",
-	"put_gitlab_ci_file":             "Put GitlabCI file for %v codebase",
-}
-
 func Convert(k8sObject codebaseApi.Codebase, edpName string) (*Codebase, error) {
 	s := k8sObject.Spec
 
-	status := convertActionLog(k8sObject.Name, k8sObject.Status)
+	status := convertActionLog(k8sObject, edpName)
 
 	c := Codebase{
 		Tenant:               edpName,
@@ -152,7 +137,8 @@ func Convert(k8sObject codebaseApi.Codebase, edpName string) (*Codebase, error)
 	return &c, nil
 }
 
-func convertActionLog(name string, status codebaseApi.CodebaseStatus) *model.ActionLog {
+func convertActionLog(c codebaseApi.Codebase, edpName string) *model.ActionLog {
+	status := c.Status
 
 	al := &model.ActionLog{
 		Event:           model.FormatStatus(status.Status),
@@ -168,6 +154,11 @@ func convertActionLog(name string, status codebaseApi.CodebaseStatus) *model.Act
 		return al
 	}
 
-	al.ActionMessage = fmt.Sprintf(codebaseActionMessageMap[string(status.Action)], name)
+	al.ActionMessage = actionmessage.Render(edpName, actionmessage.Codebase, actionmessage.Data{
+		Name:   c.Name,
+		Action: al.Action,
+		Result: al.Result,
+		Object: c,
+	})
 	return al
 }
@@ -17,11 +17,10 @@
 package codebasebranch
 
 import (
-	"fmt"
-
 	codeBaseApi "github.com/epam/edp-codebase-operator/v2/pkg/apis/edp/v1"
 
 	"github.com/epam/edp-reconciler/v2/pkg/model"
+	"github.com/epam/edp-reconciler/v2/pkg/model/actionmessage"
 )
 
 type CodebaseBranch struct {
@@ -35,23 +34,16 @@ type CodebaseBranch struct {
 	Release          bool
 	Status           string
 	ActionLog        model.ActionLog
-}
-
-var codebaseBranchActionMessageMap = map[string]string{
-	"jenkins_configuration":               "CI Jenkins pipelines for codebase branch %v provisioning for codebase %v",
-	"codebase_branch_registration":        "Branch %v for codebase %v registration",
-	"accept_codebase_branch_registration": "Accept branch %v for codebase %v registration",
-	"put_branch_for_gitlab_ci_codebase":   "Create %v branch for %v codebase in Git ",
-	"trigger_release_job":                 "Trigger release job. Branch - %v, Codebase - %v",
-	"put_codebase_image_stream":           "Put Codebase ImageStream. Branch - %v, Codebase - %v",
-	"perf_data_source_cr_update":          "Update PerfDataSource CR. Branch - %v, Codebase - %v",
+	// PreviousAppName is the codebase the branch has been synced under last time.
+	// It differs from AppName once the branch has been moved to another codebase.
+	PreviousAppName string
 }
 
 func ConvertToCodebaseBranch(k8sObject codeBaseApi.CodebaseBranch, edpName string) (*CodebaseBranch, error) {
 
 	spec := k8sObject.Spec
 
-	actionLog := convertCodebaseBranchActionLog(spec.BranchName, spec.CodebaseName, k8sObject.Status)
+	actionLog := convertCodebaseBranchActionLog(k8sObject, edpName)
 
 	branch := CodebaseBranch{
 		Name:             spec.BranchName,
@@ -69,7 +61,8 @@ func ConvertToCodebaseBranch(k8sObject codeBaseApi.CodebaseBranch, edpName strin
 	return &branch, nil
 }
 
-func convertCodebaseBranchActionLog(brName, cbName string, status codeBaseApi.CodebaseBranchStatus) *model.ActionLog {
+func convertCodebaseBranchActionLog(cb codeBaseApi.CodebaseBranch, edpName string) *model.ActionLog {
+	status := cb.Status
 
 	al := &model.ActionLog{
 		Event:           model.FormatStatus(status.Status),
@@ -85,6 +78,12 @@ func convertCodebaseBranchActionLog(brName, cbName string, status codeBaseApi.Co
 		return al
 	}
 
-	al.ActionMessage = fmt.Sprintf(codebaseBranchActionMessageMap[string(status.Action)], brName, cbName)
+	al.ActionMessage = actionmessage.Render(edpName, actionmessage.CodebaseBranch, actionmessage.Data{
+		Name:     cb.Spec.BranchName,
+		Codebase: cb.Spec.CodebaseName,
+		Action:   al.Action,
+		Result:   al.Result,
+		Object:   cb,
+	})
 	return al
 }
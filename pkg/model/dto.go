@@ -5,6 +5,12 @@ type CodebaseBranchDTO struct {
 	BranchName   string
 }
 
+type CodebaseBranchReadDTO struct {
+	Id             int
+	CodebaseId     int
+	OutputStreamId *int
+}
+
 type CDPipelineDTO struct {
 	Id             int
 	Name           string
@@ -18,7 +24,42 @@ type CodebaseDockerStreamReadDTO struct {
 	CodebaseName           string
 }
 
+type StageCodebaseDockerStreamDTO struct {
+	InputStreamId  int
+	OutputStreamId int
+	CodebaseId     int
+	CodebaseName   string
+}
+
+type StageDTO struct {
+	Id                int
+	Name              string
+	Description       string
+	TriggerType       string
+	Order             int
+	Status            string
+	CodebaseBranchId  *int
+	JobProvisioningId *int
+}
+
 type CodebaseBranchIdDTO struct {
 	CodebaseId int
 	BranchId   int
 }
+
+type JenkinsSlaveDTO struct {
+	Id      int
+	Name    string
+	Removed bool
+	// Unclaimed is set for slaves stored before the Jenkins name has been tracked
+	Unclaimed bool
+}
+
+type JobProvisionDTO struct {
+	Id      int
+	Name    string
+	Scope   string
+	Removed bool
+	// Unclaimed is set for job provisioners stored before the Jenkins name has been tracked
+	Unclaimed bool
+}
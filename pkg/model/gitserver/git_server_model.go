@@ -21,6 +21,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/epam/edp-reconciler/v2/pkg/model"
+	"github.com/epam/edp-reconciler/v2/pkg/model/actionmessage"
 )
 
 var log = ctrl.Log.WithName("git-server-model")
@@ -42,7 +43,7 @@ func ConvertToGitServer(k8sObj codeBaseApi.GitServer, edpName string) (*GitServe
 
 	spec := k8sObj.Spec
 
-	actionLog := convertGitServerActionLog(k8sObj.Status)
+	actionLog := convertGitServerActionLog(k8sObj, edpName)
 
 	gitServer := GitServer{
 		GitHost:                  spec.GitHost,
@@ -59,8 +60,9 @@ func ConvertToGitServer(k8sObj codeBaseApi.GitServer, edpName string) (*GitServe
 	return &gitServer, nil
 }
 
-func convertGitServerActionLog(status codeBaseApi.GitServerStatus) *model.ActionLog {
-	return &model.ActionLog{
+func convertGitServerActionLog(gs codeBaseApi.GitServer, edpName string) *model.ActionLog {
+	status := gs.Status
+	al := &model.ActionLog{
 		Event:           model.FormatStatus(status.Status),
 		DetailedMessage: status.DetailedMessage,
 		Username:        status.Username,
@@ -68,4 +70,17 @@ func convertGitServerActionLog(status codeBaseApi.GitServerStatus) *model.Action
 		Action:          status.Action,
 		Result:          status.Result,
 	}
+
+	if status.Result == "error" {
+		al.ActionMessage = status.DetailedMessage
+		return al
+	}
+
+	al.ActionMessage = actionmessage.Render(edpName, actionmessage.GitServer, actionmessage.Data{
+		Name:   gs.Name,
+		Action: al.Action,
+		Result: al.Result,
+		Object: gs,
+	})
+	return al
 }
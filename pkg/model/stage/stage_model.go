@@ -23,22 +23,24 @@ import (
 	cpPipeApi "github.com/epam/edp-cd-pipeline-operator/v2/pkg/apis/edp/v1"
 
 	"github.com/epam/edp-reconciler/v2/pkg/model"
+	"github.com/epam/edp-reconciler/v2/pkg/model/actionmessage"
 )
 
 type Stage struct {
-	Id              int
-	Name            string
-	Tenant          string
-	Namespace       string
-	CdPipelineName  string
-	Description     string
-	TriggerType     string
-	Order           int
-	ActionLog       model.ActionLog
-	Status          string
-	QualityGates    []QualityGate
-	Source          Source
-	JobProvisioning string
+	Id                int
+	Name              string
+	Tenant            string
+	Namespace         string
+	CdPipelineName    string
+	Description       string
+	TriggerType       string
+	Order             int
+	ActionLog         model.ActionLog
+	Status            string
+	QualityGates      []QualityGate
+	Source            Source
+	JobProvisioning   string
+	JobProvisioningId *int
 }
 
 type Source struct {
@@ -46,6 +48,14 @@ type Source struct {
 	Library Library
 }
 
+// LibraryBranchId returns id of the library branch or nil if the stage uses default source
+func (s Source) LibraryBranchId() *int {
+	if s.Type == "default" {
+		return nil
+	}
+	return s.Library.BranchId
+}
+
 type Library struct {
 	Id       *int
 	BranchId *int
@@ -54,26 +64,18 @@ type Library struct {
 }
 
 type QualityGate struct {
+	Id              int
 	QualityGate     string
 	JenkinsStepName string
 	AutotestName    *string
 	BranchName      *string
 }
 
-var cdStageActionMessageMap = map[string]string{
-	"accept_cd_stage_registration":      "Accept CD Stage %v registration",
-	"fetching_user_settings_config_map": "Fetch User Settings from config map during CD Stage %v provision",
-	"platform_project_creation":         "Create Openshift Project for Stage %v",
-	"jenkins_configuration":             "CI Jenkins pipelines %v provisioning",
-	"setup_deployment_templates":        "Setup deployment templates for cd_stage %v",
-	"create_jenkins_pipeline":           "Create Jenkins pipeline for CD Stage %v",
-}
-
 // ConvertToStage returns converted to DTO Stage object from K8S and provided edp name
 // An error occurs if method received nil instead of k8s object
 func ConvertToStage(k8sObject cpPipeApi.Stage, edpName string) (*Stage, error) {
 	spec := k8sObject.Spec
-	actionLog := convertStageActionLog(k8sObject.Name, k8sObject.Status)
+	actionLog := convertStageActionLog(k8sObject, edpName)
 	stage := Stage{
 		Name:           spec.Name,
 		Tenant:         edpName,
@@ -117,7 +119,8 @@ func convertQualityGatesFromRequest(gates []cpPipeApi.QualityGate) []QualityGate
 	return result
 }
 
-func convertStageActionLog(cdStageName string, status cpPipeApi.StageStatus) *model.ActionLog {
+func convertStageActionLog(s cpPipeApi.Stage, edpName string) *model.ActionLog {
+	status := s.Status
 
 	al := &model.ActionLog{
 		Event:           model.FormatStatus(status.Status),
@@ -133,6 +136,11 @@ func convertStageActionLog(cdStageName string, status cpPipeApi.StageStatus) *mo
 		return al
 	}
 
-	al.ActionMessage = fmt.Sprintf(cdStageActionMessageMap[fmt.Sprint(status.Action)], cdStageName)
+	al.ActionMessage = actionmessage.Render(edpName, actionmessage.CDStage, actionmessage.Data{
+		Name:   s.Name,
+		Action: al.Action,
+		Result: al.Result,
+		Object: s,
+	})
 	return al
 }
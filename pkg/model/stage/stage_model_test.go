@@ -151,7 +151,7 @@ func TestConvertMethodToCDStage(t *testing.T) {
 		t.Fatal(fmt.Sprintf("result is incorrect %v", result))
 	}
 
-	actionMessage := fmt.Sprintf(cdStageActionMessageMap[stageAction], name)
+	actionMessage := fmt.Sprintf("Accept CD Stage %v registration", name)
 	if cdStage.ActionLog.ActionMessage != actionMessage {
 		t.Fatal(fmt.Sprintf("action message is incorrect %v", actionMessage))
 	}
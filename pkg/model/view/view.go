@@ -0,0 +1,99 @@
+// Package view contains read models of the tenant data exposed by the reconciler API
+package view
+
+import "time"
+
+// Page limits rows returned by list queries
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+type Codebase struct {
+	Id              int     `json:"-"`
+	Name            string  `json:"name"`
+	Type            string  `json:"type"`
+	Language        *string `json:"language,omitempty"`
+	Framework       *string `json:"framework,omitempty"`
+	BuildTool       *string `json:"buildTool,omitempty"`
+	Strategy        *string `json:"strategy,omitempty"`
+	RepositoryUrl   *string `json:"repositoryUrl,omitempty"`
+	Status          *string `json:"status,omitempty"`
+	Description     *string `json:"description,omitempty"`
+	VersioningType  *string `json:"versioningType,omitempty"`
+	DefaultBranch   *string `json:"defaultBranch,omitempty"`
+	CiTool          *string `json:"ciTool,omitempty"`
+	GitServer       *string `json:"gitServer,omitempty"`
+	JenkinsSlave    *string `json:"jenkinsSlave,omitempty"`
+	JobProvisioning *string `json:"jobProvisioning,omitempty"`
+	JiraServer      *string `json:"jiraServer,omitempty"`
+	PerfServer      *string `json:"perfServer,omitempty"`
+}
+
+type CodebaseBranch struct {
+	Id               int     `json:"-"`
+	Name             string  `json:"name"`
+	FromCommit       *string `json:"fromCommit,omitempty"`
+	Status           *string `json:"status,omitempty"`
+	Version          *string `json:"version,omitempty"`
+	BuildNumber      *string `json:"buildNumber,omitempty"`
+	LastSuccessBuild *string `json:"lastSuccessBuild,omitempty"`
+	Release          bool    `json:"release"`
+	DockerStream     *string `json:"dockerStream,omitempty"`
+}
+
+type CDPipeline struct {
+	Id             int      `json:"-"`
+	Name           string   `json:"name"`
+	DeploymentType *string  `json:"deploymentType,omitempty"`
+	Status         *string  `json:"status,omitempty"`
+	InputStreams   []string `json:"inputDockerStreams"`
+}
+
+type Stage struct {
+	Id              int           `json:"-"`
+	Name            string        `json:"name"`
+	Description     *string       `json:"description,omitempty"`
+	TriggerType     *string       `json:"triggerType,omitempty"`
+	Order           int           `json:"order"`
+	Status          *string       `json:"status,omitempty"`
+	JobProvisioning *string       `json:"jobProvisioning,omitempty"`
+	Library         *string       `json:"library,omitempty"`
+	LibraryBranch   *string       `json:"libraryBranch,omitempty"`
+	QualityGates    []QualityGate `json:"qualityGates"`
+}
+
+type QualityGate struct {
+	StageId         int     `json:"-"`
+	QualityGate     string  `json:"qualityGate"`
+	JenkinsStepName string  `json:"jenkinsStepName"`
+	Autotest        *string `json:"autotest,omitempty"`
+	Branch          *string `json:"branch,omitempty"`
+}
+
+// StageDockerStream is an edge of the docker stream lineage: the stage promotes the codebase image
+// from the input stream to the output one.
+type StageDockerStream struct {
+	Stage        string  `json:"stage"`
+	StageOrder   int     `json:"stageOrder"`
+	Codebase     *string `json:"codebase,omitempty"`
+	InputStream  *string `json:"inputStream,omitempty"`
+	OutputStream *string `json:"outputStream,omitempty"`
+}
+
+// InputDockerStream is the docker stream of the codebase branch the CD pipeline promotes images from
+type InputDockerStream struct {
+	Stream   string  `json:"stream"`
+	Codebase *string `json:"codebase,omitempty"`
+	Branch   *string `json:"branch,omitempty"`
+}
+
+type ActionLog struct {
+	Id              int       `json:"id"`
+	DetailedMessage string    `json:"detailedMessage"`
+	Username        string    `json:"username"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+	Action          string    `json:"action"`
+	ActionMessage   string    `json:"actionMessage"`
+	Result          string    `json:"result"`
+}
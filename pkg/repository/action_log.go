@@ -9,25 +9,8 @@ import (
 const (
 	InsertActionLog = "insert into \"%v\".action_log(detailed_message, username, updated_at, action, action_message, result) " +
 		"VALUES($1, $2, $3, $4, $5, $6) returning id;"
-
-	InsertCodebaseActionLog = "insert into \"%v\".codebase_action_log(codebase_id, action_log_id) " +
-		"values($1, $2);"
 )
 
-func CreateCodebaseAction(txn *sql.Tx, codebaseId int, codebaseActionId int, schemaName string) error {
-	stmt, err := txn.Prepare(fmt.Sprintf(InsertCodebaseActionLog, schemaName))
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-
-	_, err = stmt.Exec(codebaseId, codebaseActionId)
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
 func CreateActionLog(txn *sql.Tx, actionLog model.ActionLog, schemaName string) (*int, error) {
 	stmt, err := txn.Prepare(fmt.Sprintf(InsertActionLog, schemaName))
 	if err != nil {
@@ -41,3 +24,53 @@ func CreateActionLog(txn *sql.Tx, actionLog model.ActionLog, schemaName string)
 
 	return &id, err
 }
+
+// ActionLogLink describes a table which links action logs to an entity
+type ActionLogLink struct {
+	Table  string
+	Column string
+}
+
+var (
+	CodebaseActionLogLink       = ActionLogLink{Table: "codebase_action_log", Column: "codebase_id"}
+	CodebaseBranchActionLogLink = ActionLogLink{Table: "codebase_branch_action_log", Column: "codebase_branch_id"}
+	CDPipelineActionLogLink     = ActionLogLink{Table: "cd_pipeline_action_log", Column: "cd_pipeline_id"}
+	GitServerActionLogLink      = ActionLogLink{Table: "git_server_action_log", Column: "git_server_id"}
+)
+
+const (
+	lockActionLogEntity   = "select pg_advisory_xact_lock(hashtext($1));"
+	selectLinkedActionLog = "select al.id from \"%[1]v\".action_log al " +
+		"join \"%[1]v\".%[2]v l on al.id = l.action_log_id " +
+		"where l.%[3]v = $1 and al.action = $2 and al.result = $3 and al.updated_at = $4 limit 1;"
+	insertActionLogLink = "insert into \"%[1]v\".%[2]v(%[3]v, action_log_id) values ($1, $2);"
+)
+
+// CreateActionLogOnce inserts the action log and links it to the entity unless an event with the same
+// action, result and update time is already linked to it, so replays of the same status don't duplicate history.
+// It returns id of the inserted or already existing action log.
+func CreateActionLogOnce(txn *sql.Tx, link ActionLogLink, entityId int, actionLog model.ActionLog, schemaName string) (*int, error) {
+	if _, err := txn.Exec(lockActionLogEntity, fmt.Sprintf("%v.%v.%v", schemaName, link.Table, entityId)); err != nil {
+		return nil, err
+	}
+
+	var existingId int
+	err := txn.QueryRow(fmt.Sprintf(selectLinkedActionLog, schemaName, link.Table, link.Column),
+		entityId, actionLog.Action, actionLog.Result, actionLog.UpdatedAt).Scan(&existingId)
+	if err == nil {
+		return &existingId, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	id, err := CreateActionLog(txn, actionLog, schemaName)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := txn.Exec(fmt.Sprintf(insertActionLogLink, schemaName, link.Table, link.Column), entityId, *id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
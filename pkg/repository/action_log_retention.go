@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model"
+)
+
+const (
+	// selectPrunableActionLogs returns action logs linked to an entity which are either older than $1
+	// or don't fit into the latest $2 events of the entity. Null $1 and zero $2 disable the corresponding limit.
+	selectPrunableActionLogs = "select t.id, t.entity_id, t.detailed_message, t.username, t.updated_at, t.action, " +
+		"t.action_message, t.result from (" +
+		"select al.id, al.detailed_message, al.username, al.updated_at, al.action, al.action_message, al.result, " +
+		"l.%[3]v as entity_id, " +
+		"row_number() over (partition by l.%[3]v order by al.updated_at desc, al.id desc) as rn " +
+		"from \"%[1]v\".action_log al join \"%[1]v\".%[2]v l on al.id = l.action_log_id) t " +
+		"where ($1::timestamptz is not null and t.updated_at < $1) or ($2 > 0 and t.rn > $2) " +
+		"order by t.id limit $3;"
+	deleteActionLogLinks = "delete from \"%v\".%v where action_log_id = any($1);"
+	deleteActionLogs     = "delete from \"%v\".action_log where id = any($1) returning id;"
+)
+
+// PrunableActionLog is an action log selected for pruning together with id of the entity it belongs to
+type PrunableActionLog struct {
+	EntityId int
+	model.ActionLog
+}
+
+// GetPrunableActionLogs returns up to limit action logs of the link's entities which are older than olderThan
+// or exceed maxRows latest events per entity. Zero olderThan or maxRows disables the corresponding rule.
+func GetPrunableActionLogs(txn *sql.Tx, link ActionLogLink, olderThan time.Time, maxRows, limit int,
+	schemaName string) ([]PrunableActionLog, error) {
+	var before sql.NullTime
+	if !olderThan.IsZero() {
+		before = sql.NullTime{Time: olderThan, Valid: true}
+	}
+
+	rows, err := txn.Query(fmt.Sprintf(selectPrunableActionLogs, schemaName, link.Table, link.Column),
+		before, maxRows, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []PrunableActionLog
+	for rows.Next() {
+		var l PrunableActionLog
+		if err := rows.Scan(&l.Id, &l.EntityId, &l.DetailedMessage, &l.Username, &l.UpdatedAt, &l.Action,
+			&l.ActionMessage, &l.Result); err != nil {
+			return nil, err
+		}
+		logs = append(logs, l)
+	}
+	return logs, rows.Err()
+}
+
+// DeleteActionLogs removes action logs together with their rows in the link table and returns ids of the removed ones
+func DeleteActionLogs(txn *sql.Tx, link ActionLogLink, ids []int, schemaName string) ([]int, error) {
+	arr := make(pq.Int64Array, 0, len(ids))
+	for _, id := range ids {
+		arr = append(arr, int64(id))
+	}
+
+	if _, err := txn.Exec(fmt.Sprintf(deleteActionLogLinks, schemaName, link.Table), arr); err != nil {
+		return nil, err
+	}
+	rows, err := txn.Query(fmt.Sprintf(deleteActionLogs, schemaName), arr)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deleted []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, id)
+	}
+	return deleted, rows.Err()
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model"
+)
+
+func TestCreateActionLogOnce_SkipsAlreadyLinkedEvent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	log := model.ActionLog{
+		UpdatedAt: time.Now(),
+		Result:    "success",
+		Action:    "codebase_registration",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`select pg_advisory_xact_lock`).WithArgs("schema.codebase_action_log.3").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`join "schema".codebase_action_log l`).
+		WithArgs(3, log.Action, log.Result, log.UpdatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	id, err := CreateActionLogOnce(tx, CodebaseActionLogLink, 3, log, "schema")
+	assert.NoError(t, err)
+	assert.Equal(t, 11, *id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateActionLogOnce_InsertsNewEvent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	log := model.ActionLog{
+		UpdatedAt: time.Now(),
+		Result:    "success",
+		Action:    "accept_cd_pipeline_registration",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`select pg_advisory_xact_lock`).WithArgs("schema.cd_pipeline_action_log.5").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`join "schema".cd_pipeline_action_log l`).
+		WithArgs(5, log.Action, log.Result, log.UpdatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectPrepare(`insert into "schema".action_log`).ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+	mock.ExpectExec(`insert into "schema".cd_pipeline_action_log\(cd_pipeline_id, action_log_id\)`).
+		WithArgs(5, 12).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	id, err := CreateActionLogOnce(tx, CDPipelineActionLogLink, 5, log, "schema")
+	assert.NoError(t, err)
+	assert.Equal(t, 12, *id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
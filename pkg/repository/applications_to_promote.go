@@ -8,6 +8,11 @@ import (
 const (
 	InsertApplicationsToPromote = "insert into \"%v\".applications_to_promote(cd_pipeline_id, codebase_id) values ($1, $2);"
 	DeleteApplicationsToPromote = "delete from \"%v\".applications_to_promote where cd_pipeline_id = $1 ;"
+	selectApplicationsToPromote = "select c.name " +
+		"	from \"%[1]v\".applications_to_promote atp " +
+		"join \"%[1]v\".cd_pipeline cp on atp.cd_pipeline_id = cp.id " +
+		"join \"%[1]v\".codebase c on atp.codebase_id = c.id " +
+		"where cp.name = $1 order by c.name ;"
 )
 
 func CreateApplicationsToPromote(txn *sql.Tx, cdPipelineId int, codebaseId int, schemaName string) error {
@@ -37,3 +42,22 @@ func RemoveApplicationsToPromote(txn *sql.Tx, cdPipelineId int, schemaName strin
 	}
 	return nil
 }
+
+// GetApplicationsToPromote returns names of the codebases promoted between stages of the CD pipeline
+func GetApplicationsToPromote(txn *sql.Tx, pipeline, schema string) ([]string, error) {
+	rows, err := txn.Query(fmt.Sprintf(selectApplicationsToPromote, schema), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		result = append(result, name)
+	}
+	return result, rows.Err()
+}
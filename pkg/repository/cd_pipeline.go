@@ -10,7 +10,7 @@ import (
 const (
 	insertCDPipeline             = "insert into \"%v\".cd_pipeline(name, deployment_type, status) VALUES ($1, $2, $3) returning id, name, deployment_type, status;"
 	selectCDPipeline             = "select * from \"%v\".cd_pipeline cdp where cdp.name = $1 ;"
-	updateCDPipelineStatusQuery  = "update \"%v\".cd_pipeline set status = $1 where id = $2 ;"
+	updateCDPipelineQuery        = "update \"%v\".cd_pipeline set deployment_type = $1, status = $2 where id = $3 ;"
 	insertCDPipelineDockerStream = "insert into \"%v\".cd_pipeline_docker_stream(cd_pipeline_id, codebase_docker_stream_id) VALUES ($1, $2);"
 	deleteAllDockerStreams       = "delete from \"%v\".cd_pipeline_docker_stream cpds  where cpds.cd_pipeline_id = $1 ;"
 	deleteCDPipeline             = "delete from \"%v\".cd_pipeline where name = $1 ;"
@@ -51,14 +51,14 @@ func GetCDPipeline(txn *sql.Tx, cdPipelineName string, schemaName string) (*mode
 	return &cdPipeline, nil
 }
 
-func UpdateCDPipelineStatus(txn *sql.Tx, pipelineId int, cdPipelineStatus string, schemaName string) error {
-	stmt, err := txn.Prepare(fmt.Sprintf(updateCDPipelineStatusQuery, schemaName))
+func UpdateCDPipeline(txn *sql.Tx, pipelineId int, deploymentType, status, schemaName string) error {
+	stmt, err := txn.Prepare(fmt.Sprintf(updateCDPipelineQuery, schemaName))
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(cdPipelineStatus, pipelineId)
+	_, err = stmt.Exec(deploymentType, status, pipelineId)
 	return err
 }
 
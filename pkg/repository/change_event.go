@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+)
+
+const (
+	lockChangeEvents  = "select pg_advisory_xact_lock(hashtext($1));"
+	notifyChange      = "select pg_notify($1, $2);"
+	insertChangeEvent = "insert into \"%v\".change_event(kind, name, op, before, after) " +
+		"values ($1, $2, $3, $4, $5) returning id, created_at;"
+	selectChangeEvents = "select id, kind, name, op, before, after, created_at from \"%v\".change_event " +
+		"where id > $1 order by id limit $2;"
+	selectLatestChangeEventId = "select coalesce(max(id), 0) from \"%v\".change_event;"
+	// deleteChangeEvents keeps events following the lowest cursor of the configured outbox sinks,
+	// so none of the events is lost before it's queued for delivery, and moves the last pruned id
+	deleteChangeEvents = "with deleted as (delete from \"%[1]v\".change_event where id in (select id " +
+		"from \"%[1]v\".change_event where created_at < $1 and id <= coalesce((select min(last_event_id) " +
+		"from \"%[1]v\".outbox_cursor where sink = any($3)), 9223372036854775807) order by id limit $2) returning id), " +
+		"pruned as (insert into \"%[1]v\".change_event_retention(last_pruned_id) select max(id) from deleted " +
+		"having count(*) > 0 on conflict (id) do update set last_pruned_id = greatest(" +
+		"\"%[1]v\".change_event_retention.last_pruned_id, excluded.last_pruned_id), updated_at = now()) " +
+		"select count(*) from deleted;"
+	selectLastPrunedChangeEventId = "select coalesce(max(last_pruned_id), 0) from \"%v\".change_event_retention;"
+	selectChangeFeedCursor        = "select last_event_id from \"%v\".change_feed_cursor where subscriber = $1 and owner = $2;"
+	upsertChangeFeedCursor        = "insert into \"%[1]v\".change_feed_cursor(subscriber, owner, last_event_id) values ($1, $2, $3) " +
+		"on conflict (subscriber) do update set last_event_id = excluded.last_event_id, updated_at = now() " +
+		"where \"%[1]v\".change_feed_cursor.owner = excluded.owner;"
+)
+
+// ErrChangeFeedCursorTaken is returned on storing cursor of the subscriber which belongs to another owner
+var ErrChangeFeedCursorTaken = errors.New("change feed subscriber belongs to another owner")
+
+// CreateChangeEvent appends the event to the tenant change feed and sets its id.
+// Appends are serialized within the tenant until commit, so events become visible in order of their ids
+// and readers using the last seen id as a cursor never skip an event.
+func CreateChangeEvent(txn *sql.Tx, e *change.Event, schema string) error {
+	if _, err := txn.Exec(lockChangeEvents, schema+".change_event"); err != nil {
+		return err
+	}
+
+	stmt, err := txn.Prepare(fmt.Sprintf(insertChangeEvent, schema))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	return stmt.QueryRow(e.Kind, e.Name, e.Op, nullJSON(e.Before), nullJSON(e.After)).Scan(&e.Id, &e.CreatedAt)
+}
+
+// NotifyChange sends the payload to listeners of the channel once the transaction commits
+func NotifyChange(txn *sql.Tx, channel string, payload []byte) error {
+	_, err := txn.Exec(notifyChange, channel, string(payload))
+	return err
+}
+
+// GetChangeEvents returns events of the tenant change feed following the cursor
+func GetChangeEvents(txn *sql.Tx, after int64, limit int, schema string) ([]change.Event, error) {
+	rows, err := txn.Query(fmt.Sprintf(selectChangeEvents, schema), after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []change.Event
+	for rows.Next() {
+		e := change.Event{Tenant: schema}
+		var before, after []byte
+		if err := rows.Scan(&e.Id, &e.Kind, &e.Name, &e.Op, &before, &after, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Before, e.After = before, after
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
+
+// GetLatestChangeEventId returns id of the latest event of the tenant change feed, zero if the feed is empty
+func GetLatestChangeEventId(txn *sql.Tx, schema string) (int64, error) {
+	var id int64
+	err := txn.QueryRow(fmt.Sprintf(selectLatestChangeEventId, schema)).Scan(&id)
+	return id, err
+}
+
+// GetChangeFeedCursor returns id of the last event acknowledged by the subscriber of the owner, nil if it hasn't
+// acknowledged any
+func GetChangeFeedCursor(txn *sql.Tx, subscriber, owner, schema string) (*int64, error) {
+	var id int64
+	if err := txn.QueryRow(fmt.Sprintf(selectChangeFeedCursor, schema), subscriber, owner).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &id, nil
+}
+
+// PutChangeFeedCursor stores cursor of the subscriber, the subscriber is taken by the owner storing it first
+func PutChangeFeedCursor(txn *sql.Tx, subscriber, owner string, lastEventId int64, schema string) error {
+	res, err := txn.Exec(fmt.Sprintf(upsertChangeFeedCursor, schema), subscriber, owner, lastEventId)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.Wrapf(ErrChangeFeedCursorTaken, "subscriber %v", subscriber)
+	}
+	return nil
+}
+
+// DeleteChangeEvents deletes up to limit events created before olderThan and returns their number.
+// Events which haven't been queued for any of the sinks yet are kept, cursors of other sinks are ignored.
+func DeleteChangeEvents(txn *sql.Tx, olderThan time.Time, limit int, sinks []string, schema string) (int, error) {
+	var n int
+	err := txn.QueryRow(fmt.Sprintf(deleteChangeEvents, schema), olderThan, limit, pq.StringArray(sinks)).Scan(&n)
+	return n, err
+}
+
+// GetLastPrunedChangeEventId returns id of the latest pruned event of the tenant change feed, zero if none is pruned
+func GetLastPrunedChangeEventId(txn *sql.Tx, schema string) (int64, error) {
+	var id int64
+	err := txn.QueryRow(fmt.Sprintf(selectLastPrunedChangeEventId, schema)).Scan(&id)
+	return id, err
+}
+
+func nullJSON(v []byte) interface{} {
+	if len(v) == 0 {
+		return nil
+	}
+	return string(v)
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPutChangeFeedCursor_SubscriberOfAnotherOwner(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`insert into "edp".change_feed_cursor`).WithArgs("cli", "alice", 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`insert into "edp".change_feed_cursor`).WithArgs("cli", "bob", 7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	assert.NoError(t, PutChangeFeedCursor(tx, "cli", "alice", 5, "edp"))
+	err = PutChangeFeedCursor(tx, "cli", "bob", 7, "edp")
+	assert.Equal(t, ErrChangeFeedCursorTaken, errors.Cause(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
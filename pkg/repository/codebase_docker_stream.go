@@ -16,14 +16,6 @@ const (
 		"left join \"%[1]v\".cd_pipeline_docker_stream cpds on cds.id = cpds.codebase_docker_stream_id " +
 		"left join \"%[1]v\".cd_pipeline cp on cpds.cd_pipeline_id = cp.id " +
 		"where cp.name = $1;"
-	GetDockerStreamsByPipelineNameAndStageOrderQuery = "select cds.id, c.id codebase_id, c.name " +
-		"	from \"%[1]v\".codebase_docker_stream cds " +
-		"left join \"%[1]v\".codebase_branch cb on cds.codebase_branch_id = cb.id " +
-		"left join \"%[1]v\".codebase c on cb.codebase_id = c.id " +
-		"left join \"%[1]v\".stage_codebase_docker_stream scds on cds.id = scds.output_codebase_docker_stream_id " +
-		"left join \"%[1]v\".cd_stage cs on scds.cd_stage_id = cs.id " +
-		"left join \"%[1]v\".cd_pipeline pipe on cs.cd_pipeline_id = pipe.id " +
-		"where pipe.name = $1 and cs.\"order\" = $2;"
 	CreateStageCodebaseDockerStreamQuery = "insert into \"%v\".stage_codebase_docker_stream " +
 		"values($1, $2, $3);"
 	RemoveStageCodebaseDockerStream = "delete " +
@@ -39,6 +31,21 @@ const (
 	SelectCodebaseDockerStreamId       = "select id from \"%[1]v\".codebase_docker_stream cds where cds.oc_image_stream_name=$1 ;"
 	UpdateCodebaseDockerStreamBranchId = "update \"%v\".codebase_docker_stream set codebase_branch_id = $1 where id = $2 ;"
 	SelectCodebaseDockerStreamBranchId = "select cds.codebase_branch_id from \"%v\".codebase_docker_stream cds where cds.id = $1;"
+	SelectCodebaseNameByDockerStream   = "select c.name " +
+		"	from \"%[1]v\".codebase_docker_stream cds " +
+		"left join \"%[1]v\".codebase_branch cb on cds.codebase_branch_id = cb.id " +
+		"left join \"%[1]v\".codebase c on cb.codebase_id = c.id " +
+		"where cds.oc_image_stream_name = $1 ;"
+	SelectStageCodebaseDockerStreams = "select scds.input_codebase_docker_stream_id, scds.output_codebase_docker_stream_id, c.id, c.name " +
+		"	from \"%[1]v\".stage_codebase_docker_stream scds " +
+		"left join \"%[1]v\".codebase_docker_stream cds on scds.output_codebase_docker_stream_id = cds.id " +
+		"left join \"%[1]v\".codebase_branch cb on cds.codebase_branch_id = cb.id " +
+		"left join \"%[1]v\".codebase c on cb.codebase_id = c.id " +
+		"where scds.cd_stage_id = $1 ;"
+	ReplaceStageCodebaseDockerStreamInput = "update \"%v\".stage_codebase_docker_stream set input_codebase_docker_stream_id = $1 " +
+		"where input_codebase_docker_stream_id = $2 ;"
+	UpdateStageCodebaseDockerStreamInput = "update \"%v\".stage_codebase_docker_stream set input_codebase_docker_stream_id = $1 " +
+		"where cd_stage_id = $2 and output_codebase_docker_stream_id = $3 ;"
 )
 
 func CreateCodebaseDockerStream(txn *sql.Tx, schemaName string, branchId *int, ocImageStreamName string) (id *int, err error) {
@@ -73,35 +80,64 @@ func GetDockerStreamsByPipelineName(txn *sql.Tx, schemaName string, cdPipelineNa
 	return getDockerStreamsFromRows(rows)
 }
 
-func GetDockerStreamsByPipelineNameAndStageOrder(txn *sql.Tx, schemaName string, cdPipelineName string, order int) ([]model.CodebaseDockerStreamReadDTO, error) {
-	query := fmt.Sprintf(GetDockerStreamsByPipelineNameAndStageOrderQuery, schemaName)
+func CreateStageCodebaseDockerStream(txn *sql.Tx, schemaName string, stageId int, inputStreamId int, outputStreamId int) error {
+	query := fmt.Sprintf(CreateStageCodebaseDockerStreamQuery, schemaName)
 	stmt, err := txn.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(stageId, inputStreamId, outputStreamId)
+
+	return err
+}
+
+// GetStageCodebaseDockerStreams returns input and output streams linked to the stage
+func GetStageCodebaseDockerStreams(txn *sql.Tx, schemaName string, stageId int) ([]model.StageCodebaseDockerStreamDTO, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(SelectStageCodebaseDockerStreams, schemaName))
 	if err != nil {
 		return nil, err
 	}
-	//TODO: lint. should check returned error before deferring stmt.Close() (staticcheck)
 	defer stmt.Close()
 
-	rows, err := stmt.Query(cdPipelineName, order)
-	//TODO: lint. should check returned error before deferring rows.Close() (staticcheck)
-	//nolint:staticcheck
-	defer rows.Close()
+	rows, err := stmt.Query(stageId)
 	if err != nil {
 		return nil, err
 	}
-	return getDockerStreamsFromRows(rows)
+	defer rows.Close()
+
+	var result []model.StageCodebaseDockerStreamDTO
+	for rows.Next() {
+		dto := model.StageCodebaseDockerStreamDTO{}
+		if err := rows.Scan(&dto.InputStreamId, &dto.OutputStreamId, &dto.CodebaseId, &dto.CodebaseName); err != nil {
+			return nil, err
+		}
+		result = append(result, dto)
+	}
+	return result, rows.Err()
 }
 
-func CreateStageCodebaseDockerStream(txn *sql.Tx, schemaName string, stageId int, inputStreamId int, outputStreamId int) error {
-	query := fmt.Sprintf(CreateStageCodebaseDockerStreamQuery, schemaName)
-	stmt, err := txn.Prepare(query)
+func UpdateStageCodebaseDockerStreamInputId(txn *sql.Tx, schemaName string, stageId int, outputStreamId int, inputStreamId int) error {
+	stmt, err := txn.Prepare(fmt.Sprintf(UpdateStageCodebaseDockerStreamInput, schemaName))
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(stageId, inputStreamId, outputStreamId)
+	_, err = stmt.Exec(inputStreamId, stageId, outputStreamId)
+	return err
+}
 
+// ReplaceStageCodebaseDockerStreamInputId points all stages which consume the old input stream to the new one
+func ReplaceStageCodebaseDockerStreamInputId(txn *sql.Tx, schemaName string, oldInputStreamId int, newInputStreamId int) error {
+	stmt, err := txn.Prepare(fmt.Sprintf(ReplaceStageCodebaseDockerStreamInput, schemaName))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(newInputStreamId, oldInputStreamId)
 	return err
 }
 
@@ -195,6 +231,23 @@ func GetCodebaseDockerStreamId(txn *sql.Tx, dockerStream, schemaName string) (*i
 	return &id, nil
 }
 
+func GetCodebaseNameByDockerStream(txn *sql.Tx, dockerStream, schemaName string) (*string, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(SelectCodebaseNameByDockerStream, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var name string
+	err = stmt.QueryRow(dockerStream).Scan(&name)
+	if err != nil {
+		_, err = checkNoRows(err)
+		return nil, err
+	}
+
+	return &name, nil
+}
+
 func UpdateBranchIdCodebaseDockerStream(txn *sql.Tx, dockerStreamId int, branchId int, schemaName string) error {
 	stmt, err := txn.Prepare(fmt.Sprintf(UpdateCodebaseDockerStreamBranchId, schemaName))
 	if err != nil {
@@ -3,6 +3,8 @@ package codebasebranch
 import (
 	"database/sql"
 	"fmt"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model"
 )
 
 const (
@@ -10,9 +12,12 @@ const (
 		" left join \"%v\".codebase c on cb.codebase_id = c.id where cb.name=$1 and c.name=$2;"
 	InsertCodebaseBranch = "insert into \"%v\".codebase_branch(name, codebase_id, from_commit, output_codebase_docker_stream_id, status, version, build_number, last_success_build, release)" +
 		" values ($1, $2, $3, $4, $5, $6, $7, $8, $9) returning id;"
-	UpdateCodebaseBranchStatus = "update \"%v\".codebase_branch set status = $1 where id = $2;"
-	UpdateCodebaseBranchValues = "update \"%v\".codebase_branch set version = $1, build_number = $2, last_success_build = $3 where id = $4;"
-	deleteCodebaseBranch       = "delete from \"%[1]v\".codebase_branch where \"%[1]v\".codebase_branch.id=(select cb.id from" +
+	UpdateCodebaseBranchValues = "update \"%v\".codebase_branch set from_commit = $1, status = $2, version = $3, build_number = $4, " +
+		"last_success_build = $5, release = $6 where id = $7;"
+	SelectCodebaseBranchById = "select cb.id, cb.codebase_id, cb.output_codebase_docker_stream_id " +
+		"from \"%v\".codebase_branch cb where cb.id = $1;"
+	UpdateCodebaseBranchCodebaseQuery = "update \"%v\".codebase_branch set codebase_id = $1, output_codebase_docker_stream_id = $2 where id = $3;"
+	deleteCodebaseBranch              = "delete from \"%[1]v\".codebase_branch where \"%[1]v\".codebase_branch.id=(select cb.id from" +
 		" \"%[1]v\".codebase_branch cb left join \"%[1]v\".codebase c on cb.codebase_id = c.id where c.name = $1 and cb.name = $2);"
 )
 
@@ -52,25 +57,44 @@ func CreateCodebaseBranch(txn *sql.Tx, name string, beId int, fromCommit string,
 	return &id, nil
 }
 
-func UpdateStatusByCodebaseBranchId(txn *sql.Tx, branchId int, status string, schemaName string) error {
-	stmt, err := txn.Prepare(fmt.Sprintf(UpdateCodebaseBranchStatus, schemaName))
+func UpdateCodebaseBranch(txn *sql.Tx, branchId int, fromCommit string, status string, version *string, build *string,
+	lastSuccess *string, release bool, schemaName string) error {
+	stmt, err := txn.Prepare(fmt.Sprintf(UpdateCodebaseBranchValues, schemaName))
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(status, branchId)
+	_, err = stmt.Exec(fromCommit, status, version, build, lastSuccess, release, branchId)
 	return err
 }
 
-func UpdateCodebaseBranch(txn *sql.Tx, branchId int, version *string, build *string, lastSuccess *string, schemaName string) error {
-	stmt, err := txn.Prepare(fmt.Sprintf(UpdateCodebaseBranchValues, schemaName))
+func GetCodebaseBranchById(txn *sql.Tx, branchId int, schemaName string) (*model.CodebaseBranchReadDTO, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(SelectCodebaseBranchById, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var dto model.CodebaseBranchReadDTO
+	err = stmt.QueryRow(branchId).Scan(&dto.Id, &dto.CodebaseId, &dto.OutputStreamId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &dto, nil
+}
+
+func UpdateCodebaseBranchCodebase(txn *sql.Tx, branchId int, codebaseId int, streamId *int, schemaName string) error {
+	stmt, err := txn.Prepare(fmt.Sprintf(UpdateCodebaseBranchCodebaseQuery, schemaName))
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(version, build, lastSuccess, branchId)
+	_, err = stmt.Exec(codebaseId, streamId, branchId)
 	return err
 }
 
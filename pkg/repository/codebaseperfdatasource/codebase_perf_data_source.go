@@ -3,40 +3,85 @@ package codebaseperfdatasource
 import (
 	"database/sql"
 	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Origin is the codebase_perf_data_sources column telling the link is required by a codebase spec or a data source CR.
+// Link is kept as long as it's required by either of them.
+type Origin string
+
+const (
+	SpecOrigin Origin = "spec_managed"
+	CROrigin   Origin = "cr_managed"
 )
 
 const (
-	insertPerfDataSource         = "insert into \"%v\".codebase_perf_data_sources(codebase_id, data_source_id) values ($1, $2);"
-	codebasePerfDataSourceExists = "select exists(select 1 from \"%v\".codebase_perf_data_sources where codebase_id=$1 and data_source_id=$2);"
+	insertPerfDataSource = "insert into \"%v\".codebase_perf_data_sources(codebase_id, data_source_id, spec_managed, cr_managed) " +
+		"values ($1, $2, $3, $4);"
+	markPerfDataSource           = "update \"%v\".codebase_perf_data_sources set %v = true where codebase_id=$1 and data_source_id=$2;"
+	selectPerfDataSources        = "select data_source_id from \"%v\".codebase_perf_data_sources where codebase_id=$1 and %v;"
+	unmarkPerfDataSources        = "update \"%v\".codebase_perf_data_sources set %v = false where codebase_id=$1 and data_source_id = any($2);"
+	deleteOrphanPerfDataSources  = "delete from \"%v\".codebase_perf_data_sources where codebase_id=$1 and not spec_managed and not cr_managed;"
 	deleteCodebasePerfDataSource = "delete from \"%v\".codebase_perf_data_sources where codebase_id=$1;"
 )
 
-func InsertCodebasePerfDataSource(txn *sql.Tx, codebaseId, dsId int, tenant string) error {
-	stmt, err := txn.Prepare(fmt.Sprintf(insertPerfDataSource, tenant))
+// PutCodebasePerfDataSource links the data source to the codebase on behalf of the origin
+func PutCodebasePerfDataSource(txn *sql.Tx, codebaseId, dsId int, origin Origin, schema string) error {
+	res, err := txn.Exec(fmt.Sprintf(markPerfDataSource, schema, origin), codebaseId, dsId)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+
+	stmt, err := txn.Prepare(fmt.Sprintf(insertPerfDataSource, schema))
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(codebaseId, dsId)
+	_, err = stmt.Exec(codebaseId, dsId, origin == SpecOrigin, origin == CROrigin)
 	return err
 }
 
-func CodebasePerfDataSourceExists(txn *sql.Tx, codebaseId, dsId int, tenant string) (bool, error) {
-	stmt, err := txn.Prepare(fmt.Sprintf(codebasePerfDataSourceExists, tenant))
+// GetCodebasePerfDataSourceIds returns ids of data sources linked to the codebase on behalf of the origin
+func GetCodebasePerfDataSourceIds(txn *sql.Tx, codebaseId int, origin Origin, schema string) ([]int, error) {
+	rows, err := txn.Query(fmt.Sprintf(selectPerfDataSources, schema, origin), codebaseId)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	defer stmt.Close()
+	defer rows.Close()
 
-	var exists bool
-	if err = stmt.QueryRow(codebaseId, dsId).Scan(&exists); err != nil {
-		if err == sql.ErrNoRows {
-			return false, nil
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
 		}
-		return false, err
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ReleaseCodebasePerfDataSources drops links of the origin to the data sources.
+// Links which are not required by any origin anymore are removed.
+func ReleaseCodebasePerfDataSources(txn *sql.Tx, codebaseId int, dsIds []int, origin Origin, schema string) error {
+	if len(dsIds) == 0 {
+		return nil
+	}
+
+	ids := make(pq.Int64Array, 0, len(dsIds))
+	for _, id := range dsIds {
+		ids = append(ids, int64(id))
+	}
+	if _, err := txn.Exec(fmt.Sprintf(unmarkPerfDataSources, schema, origin), codebaseId, ids); err != nil {
+		return err
 	}
-	return exists, err
+
+	_, err := txn.Exec(fmt.Sprintf(deleteOrphanPerfDataSources, schema), codebaseId)
+	return err
 }
 
 func DeleteCodebasePerfDataSourceRecord(txn *sql.Tx, codebaseId int, schema string) error {
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+)
+
+// selectEntityNames lists names of the entities the way they are recorded in the change feed,
+// branch and stage names are prefixed with the codebase or pipeline name
+var selectEntityNames = map[change.Kind]string{
+	change.Codebase: "select name from \"%[1]v\".codebase order by name ;",
+	change.CodebaseBranch: "select c.name || '/' || cb.name as name from \"%[1]v\".codebase_branch cb " +
+		"join \"%[1]v\".codebase c on cb.codebase_id = c.id order by name ;",
+	change.CDPipeline: "select name from \"%[1]v\".cd_pipeline order by name ;",
+	change.CDStage: "select cp.name || '/' || cs.name as name from \"%[1]v\".cd_stage cs " +
+		"join \"%[1]v\".cd_pipeline cp on cs.cd_pipeline_id = cp.id order by name ;",
+}
+
+// GetEntityNames returns names of all entities of the kind stored in the tenant schema
+func GetEntityNames(txn *sql.Tx, kind change.Kind, schema string) ([]string, error) {
+	query, ok := selectEntityNames[kind]
+	if !ok {
+		return nil, fmt.Errorf("unsupported entity kind %v", kind)
+	}
+
+	rows, err := txn.Query(fmt.Sprintf(query, schema))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		result = append(result, name)
+	}
+	return result, rows.Err()
+}
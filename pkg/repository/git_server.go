@@ -3,34 +3,40 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/gitserver"
 )
 
 const (
-	InsertGitServerSql = "insert into \"%v\".git_server(name, hostname, available) values ($1, $2, $3) returning id;"
-	UpdateGitServerSql = "update \"%v\".git_server set available = $1 where id = $2;"
+	InsertGitServerSql = "insert into \"%v\".git_server(name, hostname, available, git_user, ssh_port, https_port, " +
+		"create_code_review_pipeline) values ($1, $2, $3, $4, $5, $6, $7) returning id;"
+	UpdateGitServerSql = "update \"%v\".git_server set hostname = $1, available = $2, git_user = $3, ssh_port = $4, " +
+		"https_port = $5, create_code_review_pipeline = $6 where id = $7;"
 	SelectGitServerSql = "select id from \"%v\".git_server where name = $1;"
 )
 
-func CreateGitServer(txn *sql.Tx, name string, hostname string, available bool, tenant string) (*int, error) {
-	stmt, err := txn.Prepare(fmt.Sprintf(InsertGitServerSql, tenant))
+func CreateGitServer(txn *sql.Tx, gitServer gitserver.GitServer, available bool) (*int, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(InsertGitServerSql, gitServer.Tenant))
 	if err != nil {
 		return nil, err
 	}
 	defer stmt.Close()
 
 	var id int
-	err = stmt.QueryRow(name, hostname, available).Scan(&id)
+	err = stmt.QueryRow(gitServer.Name, gitServer.GitHost, available, gitServer.GitUser, gitServer.SshPort,
+		gitServer.HttpsPort, gitServer.CreateCodeReviewPipeline).Scan(&id)
 	return &id, err
 }
 
-func UpdateGitServer(txn *sql.Tx, id *int, available bool, tenant string) error {
-	stmt, err := txn.Prepare(fmt.Sprintf(UpdateGitServerSql, tenant))
+func UpdateGitServer(txn *sql.Tx, id int, gitServer gitserver.GitServer, available bool) error {
+	stmt, err := txn.Prepare(fmt.Sprintf(UpdateGitServerSql, gitServer.Tenant))
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(available, id)
+	_, err = stmt.Exec(gitServer.GitHost, available, gitServer.GitUser, gitServer.SshPort, gitServer.HttpsPort,
+		gitServer.CreateCodeReviewPipeline, id)
 	return err
 }
 
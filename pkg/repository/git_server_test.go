@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/gitserver"
+)
+
+func TestUpdateGitServer_UpdatesConnectionMetadata(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	gs := gitserver.GitServer{
+		Name:                     "gerrit",
+		GitHost:                  "gerrit.example.com",
+		GitUser:                  "edp-ci",
+		SshPort:                  29418,
+		HttpsPort:                443,
+		CreateCodeReviewPipeline: true,
+		Tenant:                   "schema",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`update "schema".git_server set hostname`).ExpectExec().
+		WithArgs("gerrit.example.com", true, "edp-ci", int32(29418), int32(443), true, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	assert.NoError(t, UpdateGitServer(tx, 3, gs, true))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
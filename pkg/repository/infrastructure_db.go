@@ -2,12 +2,21 @@ package repository
 
 import (
 	"database/sql"
+	"regexp"
+
+	"github.com/pkg/errors"
 )
 
 const (
 	CheckSchema = "select exists(select 1 from pg_namespace where nspname = $1);"
 )
 
+// ErrTenantNotFound is returned when the tenant schema doesn't exist
+var ErrTenantNotFound = errors.New("tenant not found")
+
+// tenantName matches names which could be safely substituted into queries as a schema
+var tenantName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
 func DoesSchemaExist(txn *sql.Tx, schema string) (bool, error) {
 	var exists bool
 	err := txn.QueryRow(CheckSchema, schema).Scan(&exists)
@@ -16,3 +25,18 @@ func DoesSchemaExist(txn *sql.Tx, schema string) (bool, error) {
 	}
 	return exists, nil
 }
+
+// CheckTenant checks the tenant name coming from clients could be used as a schema and the schema exists
+func CheckTenant(txn *sql.Tx, tenant string) error {
+	if !tenantName.MatchString(tenant) {
+		return errors.Wrapf(ErrTenantNotFound, "tenant %v", tenant)
+	}
+	exists, err := DoesSchemaExist(txn, tenant)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.Wrapf(ErrTenantNotFound, "tenant %v", tenant)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// selectBranchDockerStreamViolations returns application branches whose output docker stream is missing,
+	// doesn't point back to the branch or isn't the only stream of the branch besides stage outputs
+	selectBranchDockerStreamViolations = "select t.codebase, t.branch, t.branch_id, t.stream_id, t.stream_branch_id, t.streams from (" +
+		"select c.name as codebase, cb.name as branch, cb.id as branch_id, cds.id as stream_id, " +
+		"cds.codebase_branch_id as stream_branch_id, " +
+		"(select count(*) from \"%[1]v\".codebase_docker_stream o where o.codebase_branch_id = cb.id and not exists " +
+		"(select 1 from \"%[1]v\".stage_codebase_docker_stream s where s.output_codebase_docker_stream_id = o.id)) as streams " +
+		"	from \"%[1]v\".codebase_branch cb " +
+		"join \"%[1]v\".codebase c on cb.codebase_id = c.id " +
+		"left join \"%[1]v\".codebase_docker_stream cds on cb.output_codebase_docker_stream_id = cds.id " +
+		"where c.type = 'application') t " +
+		"where t.stream_id is null or t.stream_branch_id is distinct from t.branch_id or t.streams <> 1 " +
+		"order by t.codebase, t.branch ;"
+	// selectPromotedApplicationViolations returns applications to promote which aren't among the pipeline input streams
+	selectPromotedApplicationViolations = "select cp.name, c.name " +
+		"	from \"%[1]v\".applications_to_promote atp " +
+		"join \"%[1]v\".cd_pipeline cp on atp.cd_pipeline_id = cp.id " +
+		"join \"%[1]v\".codebase c on atp.codebase_id = c.id " +
+		"where not exists (select 1 from \"%[1]v\".cd_pipeline_docker_stream cpds " +
+		"join \"%[1]v\".codebase_docker_stream cds on cpds.codebase_docker_stream_id = cds.id " +
+		"join \"%[1]v\".codebase_branch cb on cds.codebase_branch_id = cb.id " +
+		"where cpds.cd_pipeline_id = cp.id and cb.codebase_id = c.id) " +
+		"order by cp.name, c.name ;"
+	// selectStageOrderViolations returns pipelines whose stage orders aren't contiguous from 0
+	selectStageOrderViolations = "select cp.name, array_agg(cs.\"order\" order by cs.\"order\") " +
+		"	from \"%[1]v\".cd_stage cs " +
+		"join \"%[1]v\".cd_pipeline cp on cs.cd_pipeline_id = cp.id " +
+		"group by cp.name " +
+		"having min(cs.\"order\") <> 0 or max(cs.\"order\") <> count(*) - 1 or count(distinct cs.\"order\") <> count(*) " +
+		"order by cp.name ;"
+)
+
+// BranchDockerStream describes the output docker stream of the application branch
+type BranchDockerStream struct {
+	Codebase       string
+	Branch         string
+	BranchId       int
+	StreamId       *int
+	StreamBranchId *int
+	// Streams is a number of docker streams pointing to the branch which aren't stage outputs
+	Streams int
+}
+
+// PipelineCodebase is the codebase referenced by the CD pipeline
+type PipelineCodebase struct {
+	Pipeline string
+	Codebase string
+}
+
+// PipelineStageOrders lists orders of all stages of the CD pipeline
+type PipelineStageOrders struct {
+	Pipeline string
+	Orders   []int
+}
+
+// GetBranchDockerStreamViolations returns application branches which don't have exactly one docker stream
+// referenced by output_codebase_docker_stream_id and pointing back to the branch
+func GetBranchDockerStreamViolations(txn *sql.Tx, schema string) ([]BranchDockerStream, error) {
+	rows, err := txn.Query(fmt.Sprintf(selectBranchDockerStreamViolations, schema))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []BranchDockerStream
+	for rows.Next() {
+		b := BranchDockerStream{}
+		if err := rows.Scan(&b.Codebase, &b.Branch, &b.BranchId, &b.StreamId, &b.StreamBranchId, &b.Streams); err != nil {
+			return nil, err
+		}
+		result = append(result, b)
+	}
+	return result, rows.Err()
+}
+
+// GetPromotedApplicationViolations returns applications to promote which have no input docker stream in their pipeline
+func GetPromotedApplicationViolations(txn *sql.Tx, schema string) ([]PipelineCodebase, error) {
+	rows, err := txn.Query(fmt.Sprintf(selectPromotedApplicationViolations, schema))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []PipelineCodebase
+	for rows.Next() {
+		p := PipelineCodebase{}
+		if err := rows.Scan(&p.Pipeline, &p.Codebase); err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+	}
+	return result, rows.Err()
+}
+
+// GetStageOrderViolations returns pipelines whose stage orders don't form a sequence 0, 1, ..., n-1
+func GetStageOrderViolations(txn *sql.Tx, schema string) ([]PipelineStageOrders, error) {
+	rows, err := txn.Query(fmt.Sprintf(selectStageOrderViolations, schema))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []PipelineStageOrders
+	for rows.Next() {
+		var orders pq.Int64Array
+		p := PipelineStageOrders{}
+		if err := rows.Scan(&p.Pipeline, &orders); err != nil {
+			return nil, err
+		}
+		for _, o := range orders {
+			p.Orders = append(p.Orders, int(o))
+		}
+		result = append(result, p)
+	}
+	return result, rows.Err()
+}
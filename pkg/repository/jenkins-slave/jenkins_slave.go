@@ -3,11 +3,20 @@ package jenkins_slave
 import (
 	"database/sql"
 	"fmt"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model"
 )
 
 const (
 	SelectJenkinsSlaveSql = "select id from \"%v\".jenkins_slave where name = $1;"
-	InsertJenkinsSlaveSql = "insert into \"%v\".jenkins_slave(name) values ($1)"
+	InsertJenkinsSlaveSql = "insert into \"%v\".jenkins_slave(name, jenkins_name) values ($1, $2)"
+	// SelectJenkinsSlavesSql returns slaves of the Jenkins together with unclaimed ones stored before
+	// the Jenkins name has been tracked, which could belong to any Jenkins
+	SelectJenkinsSlavesSql = "select id, name, removed, jenkins_name is null from \"%v\".jenkins_slave " +
+		"where jenkins_name = $1 or jenkins_name is null order by name;"
+	UpdateJenkinsSlaveSql      = "update \"%v\".jenkins_slave set jenkins_name = $1, removed = $2 where id = $3;"
+	DeleteJenkinsSlaveSql      = "delete from \"%v\".jenkins_slave where id = $1;"
+	SelectJenkinsSlaveUsageSql = "select exists(select 1 from \"%v\".codebase where jenkins_slave_id = $1);"
 )
 
 func SelectJenkinsSlave(txn *sql.Tx, name, tenant string) (*int, error) {
@@ -28,14 +37,73 @@ func SelectJenkinsSlave(txn *sql.Tx, name, tenant string) (*int, error) {
 	return &id, err
 }
 
-func CreateJenkinsSlave(txn *sql.Tx, name string, tenant string) error {
+func CreateJenkinsSlave(txn *sql.Tx, name, jenkinsName, tenant string) error {
 	stmt, err := txn.Prepare(fmt.Sprintf(InsertJenkinsSlaveSql, tenant))
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(name)
+	_, err = stmt.Exec(name, jenkinsName)
+
+	return err
+}
+
+func GetJenkinsSlaves(txn *sql.Tx, jenkinsName, tenant string) ([]model.JenkinsSlaveDTO, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(SelectJenkinsSlavesSql, tenant))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(jenkinsName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var slaves []model.JenkinsSlaveDTO
+	for rows.Next() {
+		var s model.JenkinsSlaveDTO
+		if err := rows.Scan(&s.Id, &s.Name, &s.Removed, &s.Unclaimed); err != nil {
+			return nil, err
+		}
+		slaves = append(slaves, s)
+	}
+	return slaves, rows.Err()
+}
+
+func UpdateJenkinsSlave(txn *sql.Tx, id int, jenkinsName string, removed bool, tenant string) error {
+	stmt, err := txn.Prepare(fmt.Sprintf(UpdateJenkinsSlaveSql, tenant))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(jenkinsName, removed, id)
+	return err
+}
+
+func DeleteJenkinsSlave(txn *sql.Tx, id int, tenant string) error {
+	stmt, err := txn.Prepare(fmt.Sprintf(DeleteJenkinsSlaveSql, tenant))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
 
+	_, err = stmt.Exec(id)
 	return err
 }
+
+// IsJenkinsSlaveUsed checks whether any codebase is built by the slave
+func IsJenkinsSlaveUsed(txn *sql.Tx, id int, tenant string) (bool, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(SelectJenkinsSlaveUsageSql, tenant))
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var used bool
+	err = stmt.QueryRow(id).Scan(&used)
+	return used, err
+}
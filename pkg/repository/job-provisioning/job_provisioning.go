@@ -3,11 +3,21 @@ package job_provisioning
 import (
 	"database/sql"
 	"fmt"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model"
 )
 
 const (
 	SelectJobProvisioningSql = "select id from \"%v\".job_provisioning where name = $1 and scope = $2;"
-	InsertJobProvisioningSql = "insert into \"%v\".job_provisioning(name, scope) values ($1, $2)"
+	InsertJobProvisioningSql = "insert into \"%v\".job_provisioning(name, scope, jenkins_name) values ($1, $2, $3)"
+	// SelectJobProvisionsSql returns job provisioners of the Jenkins together with unclaimed ones stored before
+	// the Jenkins name has been tracked, which could belong to any Jenkins
+	SelectJobProvisionsSql = "select id, name, scope, removed, jenkins_name is null from \"%v\".job_provisioning " +
+		"where jenkins_name = $1 or jenkins_name is null order by scope, name;"
+	UpdateJobProvisionSql      = "update \"%v\".job_provisioning set jenkins_name = $1, removed = $2 where id = $3;"
+	DeleteJobProvisionSql      = "delete from \"%v\".job_provisioning where id = $1;"
+	SelectJobProvisionUsageSql = "select exists(select 1 from \"%[1]v\".codebase where job_provisioning_id = $1) " +
+		"or exists(select 1 from \"%[1]v\".cd_stage where job_provisioning_id = $1);"
 )
 
 func SelectJobProvision(txn *sql.Tx, name string, scope string, tenant string) (*int, error) {
@@ -28,14 +38,73 @@ func SelectJobProvision(txn *sql.Tx, name string, scope string, tenant string) (
 	return &id, err
 }
 
-func CreateJobProvision(txn *sql.Tx, name string, scope string, tenant string) error {
+func CreateJobProvision(txn *sql.Tx, name, scope, jenkinsName, tenant string) error {
 	stmt, err := txn.Prepare(fmt.Sprintf(InsertJobProvisioningSql, tenant))
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(name, scope)
+	_, err = stmt.Exec(name, scope, jenkinsName)
+
+	return err
+}
+
+func GetJobProvisions(txn *sql.Tx, jenkinsName, tenant string) ([]model.JobProvisionDTO, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(SelectJobProvisionsSql, tenant))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(jenkinsName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var provisions []model.JobProvisionDTO
+	for rows.Next() {
+		var p model.JobProvisionDTO
+		if err := rows.Scan(&p.Id, &p.Name, &p.Scope, &p.Removed, &p.Unclaimed); err != nil {
+			return nil, err
+		}
+		provisions = append(provisions, p)
+	}
+	return provisions, rows.Err()
+}
+
+func UpdateJobProvision(txn *sql.Tx, id int, jenkinsName string, removed bool, tenant string) error {
+	stmt, err := txn.Prepare(fmt.Sprintf(UpdateJobProvisionSql, tenant))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(jenkinsName, removed, id)
+	return err
+}
+
+func DeleteJobProvision(txn *sql.Tx, id int, tenant string) error {
+	stmt, err := txn.Prepare(fmt.Sprintf(DeleteJobProvisionSql, tenant))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
 
+	_, err = stmt.Exec(id)
 	return err
 }
+
+// IsJobProvisionUsed checks whether any codebase or cd stage is provisioned by the job provisioner
+func IsJobProvisionUsed(txn *sql.Tx, id int, tenant string) (bool, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(SelectJobProvisionUsageSql, tenant))
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var used bool
+	err = stmt.QueryRow(id).Scan(&used)
+	return used, err
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	insertOutboxDelivery = "insert into \"%v\".outbox_delivery(sink, event_id, kind, name, payload) " +
+		"values ($1, $2, $3, $4, $5) on conflict (sink, event_id) do nothing;"
+	// selectDueOutboxDeliveries returns deliveries which are the oldest pending ones of their entity for the sink,
+	// so later changes of the entity wait until the earlier ones are delivered or parked
+	selectDueOutboxDeliveries = "select d.id, d.sink, d.event_id, d.payload, d.attempts " +
+		"	from \"%[1]v\".outbox_delivery d " +
+		"where d.sink = any($1) and d.failed_at is null and d.next_attempt_at <= now() " +
+		"and not exists (select 1 from \"%[1]v\".outbox_delivery p " +
+		"where p.sink = d.sink and p.kind = d.kind and p.name = d.name and p.id < d.id and p.failed_at is null) " +
+		"order by d.id limit $2;"
+	selectOutboxCursor = "select last_event_id from \"%v\".outbox_cursor where sink = $1;"
+	upsertOutboxCursor = "insert into \"%v\".outbox_cursor(sink, last_event_id) values ($1, $2) " +
+		"on conflict (sink) do update set last_event_id = excluded.last_event_id, updated_at = now();"
+	deleteOutboxDelivery = "delete from \"%v\".outbox_delivery where id = $1;"
+	// updateFailedOutboxDelivery records failed attempt, the delivery is parked if $4 is true
+	updateFailedOutboxDelivery = "update \"%v\".outbox_delivery set attempts = attempts + 1, last_error = $2, " +
+		"next_attempt_at = $3, failed_at = case when $4 then now() end where id = $1;"
+)
+
+// OutboxDelivery is a change event queued for delivery to the sink
+type OutboxDelivery struct {
+	Id       int64
+	Sink     string
+	EventId  int64
+	Payload  []byte
+	Attempts int
+}
+
+// GetOutboxCursor returns id of the last change event queued for the sink, nil if the sink has no cursor yet
+func GetOutboxCursor(txn *sql.Tx, sink, schema string) (*int64, error) {
+	var id int64
+	if err := txn.QueryRow(fmt.Sprintf(selectOutboxCursor, schema), sink).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &id, nil
+}
+
+// PutOutboxCursor stores id of the last change event queued for the sink
+func PutOutboxCursor(txn *sql.Tx, sink string, lastEventId int64, schema string) error {
+	_, err := txn.Exec(fmt.Sprintf(upsertOutboxCursor, schema), sink, lastEventId)
+	return err
+}
+
+// CreateOutboxDelivery queues the event for delivery, events already queued for the sink are skipped
+func CreateOutboxDelivery(txn *sql.Tx, sink string, eventId int64, kind, name string, payload []byte,
+	schema string) error {
+	_, err := txn.Exec(fmt.Sprintf(insertOutboxDelivery, schema), sink, eventId, kind, name, string(payload))
+	return err
+}
+
+// GetDueOutboxDeliveries returns deliveries to the sinks which should be attempted now in order they have been queued
+func GetDueOutboxDeliveries(txn *sql.Tx, sinks []string, limit int, schema string) ([]OutboxDelivery, error) {
+	rows, err := txn.Query(fmt.Sprintf(selectDueOutboxDeliveries, schema), pq.StringArray(sinks), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []OutboxDelivery
+	for rows.Next() {
+		d := OutboxDelivery{}
+		if err := rows.Scan(&d.Id, &d.Sink, &d.EventId, &d.Payload, &d.Attempts); err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
+
+func DeleteOutboxDelivery(txn *sql.Tx, id int64, schema string) error {
+	_, err := txn.Exec(fmt.Sprintf(deleteOutboxDelivery, schema), id)
+	return err
+}
+
+// FailOutboxDelivery records failed attempt of the delivery. The delivery is retried at nextAttempt
+// unless park is set, parked deliveries are kept for inspection and don't block later changes of the entity.
+func FailOutboxDelivery(txn *sql.Tx, id int64, lastError string, nextAttempt time.Time, park bool,
+	schema string) error {
+	_, err := txn.Exec(fmt.Sprintf(updateFailedOutboxDelivery, schema), id, lastError, nextAttempt, park)
+	return err
+}
@@ -3,16 +3,12 @@ package perfdatasource
 import (
 	"database/sql"
 	"fmt"
-	"strings"
 )
 
 const (
-	perfDataSourceExists         = "select exists(select 1 from \"%v\".perf_data_sources where type=$1);"
-	insertPerfDataSource         = "insert into \"%v\".perf_data_sources(type) values ($1) returning id;"
-	selectPerfDataSource         = "select id from \"%v\".perf_data_sources where type = $1;"
-	deleteCodebasePerfDataSource = "delete from \"%[1]v\".codebase_perf_data_sources cpds where cpds.data_source_id =" +
-		" (select pds.id from \"%[1]v\".perf_data_sources pds where pds.type = $1) " +
-		"and cpds.codebase_id= (select c.id from \"%[1]v\".codebase c where c.name= $2);"
+	perfDataSourceExists = "select exists(select 1 from \"%v\".perf_data_sources where type=$1);"
+	insertPerfDataSource = "insert into \"%v\".perf_data_sources(type) values ($1) returning id;"
+	selectPerfDataSource = "select id from \"%v\".perf_data_sources where type = $1;"
 )
 
 func PerfDataSourceExists(txn *sql.Tx, dsType, tenant string) (bool, error) {
@@ -57,9 +53,17 @@ func GetDataSourceId(txn *sql.Tx, dsType, tenant string) (*int, error) {
 	return &id, err
 }
 
-func RemoveCodebaseDataSource(txn *sql.Tx, codebase, dataSource, schema string) error {
-	if _, err := txn.Exec(fmt.Sprintf(deleteCodebasePerfDataSource, schema), strings.ToUpper(dataSource), codebase); err != nil {
-		return err
+// PutPerfDataSource returns id of the data source of the type creating the record if it doesn't exist
+func PutPerfDataSource(txn *sql.Tx, dsType, tenant string) (*int, error) {
+	exists, err := PerfDataSourceExists(txn, dsType, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		if err := InsertPerfDataSource(txn, dsType, tenant); err != nil {
+			return nil, err
+		}
 	}
-	return nil
+	return GetDataSourceId(txn, dsType, tenant)
 }
@@ -0,0 +1,348 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/view"
+)
+
+const (
+	codebaseView = "select c.id, c.name, c.type, c.language, c.framework, c.build_tool, c.strategy, " +
+		"c.repository_url, c.status, c.description, c.versioning_type, c.default_branch, c.ci_tool, " +
+		"gs.name, js.name, jp.name, jira.name, ps.name " +
+		"	from \"%[1]v\".codebase c " +
+		"left join \"%[1]v\".git_server gs on c.git_server_id = gs.id " +
+		"left join \"%[1]v\".jenkins_slave js on c.jenkins_slave_id = js.id " +
+		"left join \"%[1]v\".job_provisioning jp on c.job_provisioning_id = jp.id " +
+		"left join \"%[1]v\".jira_server jira on c.jira_server_id = jira.id " +
+		"left join \"%[1]v\".perf_server ps on c.perf_server_id = ps.id "
+	selectCodebases    = codebaseView + "order by c.name limit $1 offset $2 ;"
+	selectCodebaseView = codebaseView + "where c.name = $1 ;"
+	codebaseBranchView = "select cb.id, cb.name, cb.from_commit, cb.status, cb.version, cb.build_number, " +
+		"cb.last_success_build, cb.release, cds.oc_image_stream_name " +
+		"	from \"%[1]v\".codebase_branch cb " +
+		"join \"%[1]v\".codebase c on cb.codebase_id = c.id " +
+		"left join \"%[1]v\".codebase_docker_stream cds on cb.output_codebase_docker_stream_id = cds.id "
+	selectCodebaseBranches   = codebaseBranchView + "where c.name = $1 order by cb.name limit $2 offset $3 ;"
+	selectCodebaseBranchView = codebaseBranchView + "where c.name = $1 and cb.name = $2 ;"
+	cdPipelineView           = "select cp.id, cp.name, cp.deployment_type, cp.status, " +
+		"coalesce(array_agg(cds.oc_image_stream_name order by cds.oc_image_stream_name) " +
+		"filter (where cds.id is not null), '{}') " +
+		"	from \"%[1]v\".cd_pipeline cp " +
+		"left join \"%[1]v\".cd_pipeline_docker_stream cpds on cp.id = cpds.cd_pipeline_id " +
+		"left join \"%[1]v\".codebase_docker_stream cds on cpds.codebase_docker_stream_id = cds.id "
+	selectCDPipelines    = cdPipelineView + "group by cp.id order by cp.name limit $1 offset $2 ;"
+	selectCDPipelineView = cdPipelineView + "where cp.name = $1 group by cp.id ;"
+	stageView            = "select cs.id, cs.name, cs.description, cs.trigger_type, cs.\"order\", cs.status, " +
+		"jp.name, lib.name, lb.name " +
+		"	from \"%[1]v\".cd_stage cs " +
+		"join \"%[1]v\".cd_pipeline cp on cs.cd_pipeline_id = cp.id " +
+		"left join \"%[1]v\".job_provisioning jp on cs.job_provisioning_id = jp.id " +
+		"left join \"%[1]v\".codebase_branch lb on cs.codebase_branch_id = lb.id " +
+		"left join \"%[1]v\".codebase lib on lb.codebase_id = lib.id "
+	selectCDPipelineStages = stageView + "where cp.name = $1 order by cs.\"order\", cs.id limit $2 offset $3 ;"
+	selectStageView        = stageView + "where cp.name = $1 and cs.name = $2 ;"
+	qualityGateView        = "select qgs.cd_stage_id, qgs.quality_gate, qgs.step_name, c.name, cb.name " +
+		"	from \"%[1]v\".quality_gate_stage qgs " +
+		"join \"%[1]v\".cd_stage cs on qgs.cd_stage_id = cs.id " +
+		"join \"%[1]v\".cd_pipeline cp on cs.cd_pipeline_id = cp.id " +
+		"left join \"%[1]v\".codebase c on qgs.codebase_id = c.id " +
+		"left join \"%[1]v\".codebase_branch cb on qgs.codebase_branch_id = cb.id "
+	selectStagesQualityGates = qualityGateView + "where cs.id = any($1) order by qgs.id ;"
+	selectStageQualityGates  = qualityGateView + "where cp.name = $1 and cs.name = $2 order by qgs.id ;"
+	stageDockerStreamView    = "select cs.name, cs.\"order\", c.name, ins.oc_image_stream_name, outs.oc_image_stream_name " +
+		"	from \"%[1]v\".stage_codebase_docker_stream scds " +
+		"join \"%[1]v\".cd_stage cs on scds.cd_stage_id = cs.id " +
+		"join \"%[1]v\".cd_pipeline cp on cs.cd_pipeline_id = cp.id " +
+		"left join \"%[1]v\".codebase_docker_stream ins on scds.input_codebase_docker_stream_id = ins.id " +
+		"left join \"%[1]v\".codebase_docker_stream outs on scds.output_codebase_docker_stream_id = outs.id " +
+		"left join \"%[1]v\".codebase_branch cb on outs.codebase_branch_id = cb.id " +
+		"left join \"%[1]v\".codebase c on cb.codebase_id = c.id " +
+		"where cp.name = $1 "
+	selectCDPipelineDockerStreams     = stageDockerStreamView + "order by cs.\"order\", cs.id, c.name, scds.id ;"
+	selectCDPipelineDockerStreamsPage = stageDockerStreamView + "order by cs.\"order\", cs.id, c.name, scds.id limit $2 offset $3 ;"
+	selectCDPipelineInputStreams      = "select cds.oc_image_stream_name, c.name, cb.name " +
+		"	from \"%[1]v\".cd_pipeline_docker_stream cpds " +
+		"join \"%[1]v\".cd_pipeline cp on cpds.cd_pipeline_id = cp.id " +
+		"join \"%[1]v\".codebase_docker_stream cds on cpds.codebase_docker_stream_id = cds.id " +
+		"left join \"%[1]v\".codebase_branch cb on cds.codebase_branch_id = cb.id " +
+		"left join \"%[1]v\".codebase c on cb.codebase_id = c.id " +
+		"where cp.name = $1 " +
+		"order by cds.oc_image_stream_name ;"
+	selectActionLogs = "select al.id, al.detailed_message, al.username, al.updated_at, al.action, al.action_message, al.result " +
+		"	from \"%[1]v\".action_log al " +
+		"join \"%[1]v\".%[2]v l on al.id = l.action_log_id " +
+		"where l.%[3]v = $1 " +
+		"order by al.updated_at desc, al.id desc limit $2 offset $3 ;"
+)
+
+// ListCodebases returns page of codebases ordered by name
+func ListCodebases(txn *sql.Tx, page view.Page, schema string) ([]view.Codebase, error) {
+	rows, err := txn.Query(fmt.Sprintf(selectCodebases, schema), page.Limit, page.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []view.Codebase{}
+	for rows.Next() {
+		c, err := scanCodebaseView(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *c)
+	}
+	return result, rows.Err()
+}
+
+// GetCodebaseView returns read model of the codebase, nil if it doesn't exist
+func GetCodebaseView(txn *sql.Tx, name, schema string) (*view.Codebase, error) {
+	c, err := scanCodebaseView(txn.QueryRow(fmt.Sprintf(selectCodebaseView, schema), name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+func scanCodebaseView(row scanner) (*view.Codebase, error) {
+	c := view.Codebase{}
+	if err := row.Scan(&c.Id, &c.Name, &c.Type, &c.Language, &c.Framework, &c.BuildTool, &c.Strategy,
+		&c.RepositoryUrl, &c.Status, &c.Description, &c.VersioningType, &c.DefaultBranch, &c.CiTool,
+		&c.GitServer, &c.JenkinsSlave, &c.JobProvisioning, &c.JiraServer, &c.PerfServer); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListCodebaseBranches returns page of the codebase branches ordered by name
+func ListCodebaseBranches(txn *sql.Tx, codebase string, page view.Page, schema string) ([]view.CodebaseBranch, error) {
+	rows, err := txn.Query(fmt.Sprintf(selectCodebaseBranches, schema), codebase, page.Limit, page.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []view.CodebaseBranch{}
+	for rows.Next() {
+		b, err := scanCodebaseBranchView(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *b)
+	}
+	return result, rows.Err()
+}
+
+// GetCodebaseBranchView returns read model of the codebase branch, nil if it doesn't exist
+func GetCodebaseBranchView(txn *sql.Tx, codebase, branch, schema string) (*view.CodebaseBranch, error) {
+	b, err := scanCodebaseBranchView(txn.QueryRow(fmt.Sprintf(selectCodebaseBranchView, schema), codebase, branch))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return b, err
+}
+
+func scanCodebaseBranchView(row scanner) (*view.CodebaseBranch, error) {
+	b := view.CodebaseBranch{}
+	if err := row.Scan(&b.Id, &b.Name, &b.FromCommit, &b.Status, &b.Version, &b.BuildNumber,
+		&b.LastSuccessBuild, &b.Release, &b.DockerStream); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// ListCDPipelines returns page of CD pipelines ordered by name
+func ListCDPipelines(txn *sql.Tx, page view.Page, schema string) ([]view.CDPipeline, error) {
+	rows, err := txn.Query(fmt.Sprintf(selectCDPipelines, schema), page.Limit, page.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []view.CDPipeline{}
+	for rows.Next() {
+		p, err := scanCDPipelineView(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *p)
+	}
+	return result, rows.Err()
+}
+
+// GetCDPipelineView returns read model of the CD pipeline, nil if it doesn't exist
+func GetCDPipelineView(txn *sql.Tx, name, schema string) (*view.CDPipeline, error) {
+	p, err := scanCDPipelineView(txn.QueryRow(fmt.Sprintf(selectCDPipelineView, schema), name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+func scanCDPipelineView(row scanner) (*view.CDPipeline, error) {
+	p := view.CDPipeline{}
+	var streams pq.StringArray
+	if err := row.Scan(&p.Id, &p.Name, &p.DeploymentType, &p.Status, &streams); err != nil {
+		return nil, err
+	}
+	p.InputStreams = streams
+	return &p, nil
+}
+
+// ListCDPipelineStages returns a page of the CD pipeline stages in order of promotion along with their quality gates
+func ListCDPipelineStages(txn *sql.Tx, pipeline string, page view.Page, schema string) ([]view.Stage, error) {
+	rows, err := txn.Query(fmt.Sprintf(selectCDPipelineStages, schema), pipeline, page.Limit, page.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stages := []view.Stage{}
+	var ids []int64
+	for rows.Next() {
+		s, err := scanStageView(rows)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, *s)
+		ids = append(ids, int64(s.Id))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(stages) == 0 {
+		return stages, nil
+	}
+
+	gates, err := getQualityGates(txn, selectStagesQualityGates, schema, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range gates {
+		for i := range stages {
+			if stages[i].Id == g.StageId {
+				stages[i].QualityGates = append(stages[i].QualityGates, g)
+			}
+		}
+	}
+	return stages, nil
+}
+
+// GetStageView returns read model of the CD pipeline stage, nil if it doesn't exist
+func GetStageView(txn *sql.Tx, pipeline, stage, schema string) (*view.Stage, error) {
+	s, err := scanStageView(txn.QueryRow(fmt.Sprintf(selectStageView, schema), pipeline, stage))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	gates, err := getQualityGates(txn, selectStageQualityGates, schema, pipeline, stage)
+	if err != nil {
+		return nil, err
+	}
+	s.QualityGates = append(s.QualityGates, gates...)
+	return s, nil
+}
+
+func scanStageView(row scanner) (*view.Stage, error) {
+	s := view.Stage{QualityGates: []view.QualityGate{}}
+	if err := row.Scan(&s.Id, &s.Name, &s.Description, &s.TriggerType, &s.Order, &s.Status,
+		&s.JobProvisioning, &s.Library, &s.LibraryBranch); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func getQualityGates(txn *sql.Tx, query, schema string, args ...interface{}) ([]view.QualityGate, error) {
+	rows, err := txn.Query(fmt.Sprintf(query, schema), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []view.QualityGate
+	for rows.Next() {
+		g := view.QualityGate{}
+		if err := rows.Scan(&g.StageId, &g.QualityGate, &g.JenkinsStepName, &g.Autotest, &g.Branch); err != nil {
+			return nil, err
+		}
+		result = append(result, g)
+	}
+	return result, rows.Err()
+}
+
+// GetCDPipelineDockerStreams returns docker streams each stage of the CD pipeline promotes images between
+func GetCDPipelineDockerStreams(txn *sql.Tx, pipeline, schema string) ([]view.StageDockerStream, error) {
+	return getStageDockerStreams(txn, selectCDPipelineDockerStreams, schema, pipeline)
+}
+
+// ListCDPipelineDockerStreams returns a page of the docker streams returned by GetCDPipelineDockerStreams
+func ListCDPipelineDockerStreams(txn *sql.Tx, pipeline string, page view.Page, schema string) ([]view.StageDockerStream, error) {
+	return getStageDockerStreams(txn, selectCDPipelineDockerStreamsPage, schema, pipeline, page.Limit, page.Offset)
+}
+
+func getStageDockerStreams(txn *sql.Tx, query, schema string, args ...interface{}) ([]view.StageDockerStream, error) {
+	rows, err := txn.Query(fmt.Sprintf(query, schema), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []view.StageDockerStream{}
+	for rows.Next() {
+		s := view.StageDockerStream{}
+		if err := rows.Scan(&s.Stage, &s.StageOrder, &s.Codebase, &s.InputStream, &s.OutputStream); err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+// GetCDPipelineInputStreams returns docker streams of codebase branches the CD pipeline takes as input
+func GetCDPipelineInputStreams(txn *sql.Tx, pipeline, schema string) ([]view.InputDockerStream, error) {
+	rows, err := txn.Query(fmt.Sprintf(selectCDPipelineInputStreams, schema), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []view.InputDockerStream{}
+	for rows.Next() {
+		s := view.InputDockerStream{}
+		if err := rows.Scan(&s.Stream, &s.Codebase, &s.Branch); err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+// ListActionLogs returns page of the entity event history starting from the latest event
+func ListActionLogs(txn *sql.Tx, link ActionLogLink, entityId int, page view.Page, schema string) ([]view.ActionLog, error) {
+	rows, err := txn.Query(fmt.Sprintf(selectActionLogs, schema, link.Table, link.Column), entityId, page.Limit, page.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []view.ActionLog{}
+	for rows.Next() {
+		al := view.ActionLog{}
+		if err := rows.Scan(&al.Id, &al.DetailedMessage, &al.Username, &al.UpdatedAt, &al.Action,
+			&al.ActionMessage, &al.Result); err != nil {
+			return nil, err
+		}
+		result = append(result, al)
+	}
+	return result, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
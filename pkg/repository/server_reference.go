@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ServerReference is the server table together with the column of codebase table which references it
+type ServerReference struct {
+	Table  string
+	Column string
+}
+
+var (
+	GitServerReference  = ServerReference{Table: "git_server", Column: "git_server_id"}
+	JiraServerReference = ServerReference{Table: "jira_server", Column: "jira_server_id"}
+	PerfServerReference = ServerReference{Table: "perf_server", Column: "perf_server_id"}
+)
+
+// ErrServerInUse is returned on deletion of server which is still referenced by codebases
+var ErrServerInUse = errors.New("server is used by codebases")
+
+const (
+	selectServerByName      = "select id from \"%v\".%v where name = $1;"
+	deleteServer            = "delete from \"%v\".%v where id = $1;"
+	selectCodebasesByServer = "select name from \"%v\".codebase where %v = $1 order by name;"
+	detachCodebasesByServer = "update \"%v\".codebase set %v = null where %v = $1;"
+)
+
+// DeleteServer removes the server record if it exists. Codebases referencing the server are either detached from it
+// or, if detach is false, deletion is refused with ErrServerInUse.
+func DeleteServer(txn *sql.Tx, ref ServerReference, name string, detach bool, schemaName string) error {
+	id, err := selectServer(txn, ref, name, schemaName)
+	if err != nil {
+		return errors.Wrapf(err, "an error has occurred while fetching %v %v", ref.Table, name)
+	}
+	if id == nil {
+		return nil
+	}
+
+	codebases, err := getCodebasesReferencingServer(txn, ref, *id, schemaName)
+	if err != nil {
+		return errors.Wrapf(err, "an error has occurred while fetching codebases of %v %v", ref.Table, name)
+	}
+	if len(codebases) > 0 {
+		if !detach {
+			return errors.Wrapf(ErrServerInUse, "%v %v is used by codebases %v", ref.Table, name, codebases)
+		}
+		if err := execServerUpdate(txn, fmt.Sprintf(detachCodebasesByServer, schemaName, ref.Column, ref.Column), *id); err != nil {
+			return errors.Wrapf(err, "an error has occurred while detaching codebases from %v %v", ref.Table, name)
+		}
+	}
+
+	if err := execServerUpdate(txn, fmt.Sprintf(deleteServer, schemaName, ref.Table), *id); err != nil {
+		return errors.Wrapf(err, "an error has occurred while deleting %v %v", ref.Table, name)
+	}
+	return nil
+}
+
+func selectServer(txn *sql.Tx, ref ServerReference, name, schemaName string) (*int, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(selectServerByName, schemaName, ref.Table))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var id int
+	err = stmt.QueryRow(name).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// getCodebasesReferencingServer returns names of codebases which reference the server
+func getCodebasesReferencingServer(txn *sql.Tx, ref ServerReference, serverId int, schemaName string) ([]string, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(selectCodebasesByServer, schemaName, ref.Column))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(serverId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var n string
+		if err := rows.Scan(&n); err != nil {
+			return nil, err
+		}
+		names = append(names, n)
+	}
+	return names, rows.Err()
+}
+
+func execServerUpdate(txn *sql.Tx, query string, args ...interface{}) error {
+	stmt, err := txn.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(args...)
+	return err
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteServer(t *testing.T) {
+	tests := []struct {
+		name      string
+		ref       ServerReference
+		id        *int
+		codebases []string
+		detach    bool
+		// deleted is whether codebases are detached if any and the server record is deleted
+		deleted bool
+		err     error
+	}{
+		{name: "missing server", ref: GitServerReference},
+		{name: "unused git server", ref: GitServerReference, id: intPtr(2), deleted: true},
+		{name: "jira server in use", ref: JiraServerReference, id: intPtr(2), codebases: []string{"app"}, err: ErrServerInUse},
+		{name: "perf server detached", ref: PerfServerReference, id: intPtr(2), codebases: []string{"app"}, detach: true, deleted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectBegin()
+			rows := sqlmock.NewRows([]string{"id"})
+			if tt.id != nil {
+				rows.AddRow(*tt.id)
+			}
+			mock.ExpectPrepare(`select id from "schema".` + tt.ref.Table).ExpectQuery().WithArgs("srv").WillReturnRows(rows)
+			if tt.id != nil {
+				names := sqlmock.NewRows([]string{"name"})
+				for _, c := range tt.codebases {
+					names.AddRow(c)
+				}
+				mock.ExpectPrepare(`select name from "schema".codebase where ` + tt.ref.Column).ExpectQuery().
+					WithArgs(*tt.id).WillReturnRows(names)
+			}
+			if tt.deleted && len(tt.codebases) > 0 {
+				mock.ExpectPrepare(`update "schema".codebase set ` + tt.ref.Column + ` = null`).ExpectExec().
+					WithArgs(*tt.id).WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+			if tt.deleted {
+				mock.ExpectPrepare(`delete from "schema".` + tt.ref.Table).ExpectExec().WithArgs(*tt.id).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			tx, err := db.Begin()
+			assert.NoError(t, err)
+
+			err = DeleteServer(tx, tt.ref, "srv", tt.detach, "schema")
+			assert.Equal(t, tt.err, errors.Cause(err))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/epam/edp-reconciler/v2/pkg/model"
 	"github.com/epam/edp-reconciler/v2/pkg/model/stage"
-	jp "github.com/epam/edp-reconciler/v2/pkg/repository/job-provisioning"
 )
 
 const (
@@ -16,14 +15,12 @@ const (
 	SelectStageId = "select st.id as st_id from \"%v\".cd_stage st " +
 		"left join \"%v\".cd_pipeline pl on st.cd_pipeline_id = pl.id " +
 		"where (st.name = $1 and pl.name = $2);"
-	UpdateStageStatusQuery                = "update \"%v\".cd_stage set status = $1 where id = $2;"
-	GetStageIdByPipelineNameAndOrderQuery = "select stage.id from \"%v\".cd_stage stage " +
-		"left join \"%v\".cd_pipeline pipe on stage.cd_pipeline_id = pipe.id " +
-		"where pipe.name = $1 and stage.\"order\" = $2;"
+	UpdateStageStatusQuery      = "update \"%v\".cd_stage set status = $1 where id = $2;"
 	GetStagesIdByCDPipelineName = "select cs.id, cs.name, cs.status, cs.trigger_type, cs.description, cs.\"order\" " +
 		"	from \"%v\".cd_pipeline cp " +
 		"right join \"%v\".cd_stage cs on cp.id = cs.cd_pipeline_id " +
-		"where cp.name = $1 ;"
+		"where cp.name = $1 " +
+		"order by cs.\"order\", cs.id ;"
 	InsertQualityGate = "insert into \"%v\".quality_gate_stage(quality_gate, step_name, cd_stage_id, codebase_id, codebase_branch_id) " +
 		" values ($1, $2, $3, $4, $5) returning id; "
 	SelectCodebaseAndBranchIds = "select c.id codebase_id, cb.id codebase_branch_id " +
@@ -37,14 +34,7 @@ const (
 		"where cs.cd_pipeline_id = cp.id " +
 		"and cp.name = $1 " +
 		"  and cs.name = $2 ;"
-	deleteCodebaseDockerStream   = "delete from \"%v\".codebase_docker_stream where id = $1 ;"
-	selectCodebaseDockerStreamId = "select cds.id " +
-		"	from \"%[1]v\".codebase_docker_stream cds " +
-		"left join \"%[1]v\".stage_codebase_docker_stream scds on cds.id = scds.output_codebase_docker_stream_id " +
-		"left join \"%[1]v\".cd_stage cs on scds.cd_stage_id = cs.id " +
-		"left join \"%[1]v\".cd_pipeline cp on cs.cd_pipeline_id = cp.id " +
-		"where cp.name = $1 " +
-		"  and cs.name = $2 ;"
+	deleteCodebaseDockerStream    = "delete from \"%v\".codebase_docker_stream where id = $1 ;"
 	deleteCodebaseDockerStreamIds = "delete " +
 		"	from \"%[1]v\".codebase_docker_stream cds " +
 		"where cds.id in (select cds.id " +
@@ -54,7 +44,28 @@ const (
 		"left join \"%[1]v\".cd_pipeline cp on cs.cd_pipeline_id = cp.id " +
 		"where cp.name = $1 );"
 	updateStageTriggerType = "update \"%v\".cd_stage set trigger_type = $1 where id = $2;"
-	scope                  = "cd"
+	selectStage            = "select cs.id, cs.name, cs.description, cs.trigger_type, cs.\"order\", cs.status, " +
+		"cs.codebase_branch_id, cs.job_provisioning_id " +
+		"	from \"%[1]v\".cd_stage cs " +
+		"left join \"%[1]v\".cd_pipeline cp on cs.cd_pipeline_id = cp.id " +
+		"where cs.name = $1 and cp.name = $2 ;"
+	updateStage = "update \"%v\".cd_stage set description = $1, trigger_type = $2, status = $3, " +
+		"codebase_branch_id = $4, job_provisioning_id = $5, \"order\" = $6 where id = $7 ;"
+	selectPreviousStageId = "select cs.id " +
+		"	from \"%[1]v\".cd_stage cs " +
+		"left join \"%[1]v\".cd_pipeline cp on cs.cd_pipeline_id = cp.id " +
+		"where cp.name = $1 " +
+		"  and (cs.\"order\" < $2 or (cs.\"order\" = $2 and cs.id < $3)) " +
+		"order by cs.\"order\" desc, cs.id desc " +
+		"limit 1 ;"
+	selectQualityGates = "select qgs.id, qgs.quality_gate, qgs.step_name, c.name, cb.name " +
+		"	from \"%[1]v\".quality_gate_stage qgs " +
+		"left join \"%[1]v\".codebase c on qgs.codebase_id = c.id " +
+		"left join \"%[1]v\".codebase_branch cb on qgs.codebase_branch_id = cb.id " +
+		"where qgs.cd_stage_id = $1 order by qgs.id ;"
+	deleteQualityGates        = "delete from \"%v\".quality_gate_stage where cd_stage_id = $1 ;"
+	deleteQualityGate         = "delete from \"%v\".quality_gate_stage where id = $1 ;"
+	updateQualityGateStepName = "update \"%v\".quality_gate_stage set step_name = $1 where id = $2 ;"
 )
 
 func CreateStage(txn *sql.Tx, stage stage.Stage, cdPipelineId int) (id *int, err error) {
@@ -63,26 +74,62 @@ func CreateStage(txn *sql.Tx, stage stage.Stage, cdPipelineId int) (id *int, err
 		return nil, err
 	}
 	defer stmt.Close()
-	var jpID *int
-	jpID, err = jp.SelectJobProvision(txn, stage.JobProvisioning, scope, stage.Tenant)
-	if err != nil {
-		return nil, err
-	}
 
 	err = stmt.QueryRow(stage.Name, cdPipelineId, stage.Description,
 		stage.TriggerType, stage.Order, stage.Status,
-		getLibraryBranchIdOrNil(stage.Source), *jpID).Scan(&id)
+		stage.Source.LibraryBranchId(), stage.JobProvisioningId).Scan(&id)
 	if err != nil {
 		return nil, err
 	}
 	return id, nil
 }
 
-func getLibraryBranchIdOrNil(source stage.Source) *int {
-	if source.Type == "default" {
-		return nil
+func GetStage(txn *sql.Tx, schemaName string, name string, cdPipelineName string) (*model.StageDTO, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(selectStage, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var dto model.StageDTO
+	err = stmt.QueryRow(name, cdPipelineName).Scan(&dto.Id, &dto.Name, &dto.Description, &dto.TriggerType,
+		&dto.Order, &dto.Status, &dto.CodebaseBranchId, &dto.JobProvisioningId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &dto, nil
+}
+
+func UpdateStage(txn *sql.Tx, stage stage.Stage) error {
+	stmt, err := txn.Prepare(fmt.Sprintf(updateStage, stage.Tenant))
+	if err != nil {
+		return err
 	}
-	return source.Library.BranchId
+	defer stmt.Close()
+
+	_, err = stmt.Exec(stage.Description, stage.TriggerType, stage.Status,
+		stage.Source.LibraryBranchId(), stage.JobProvisioningId, stage.Order, stage.Id)
+	return err
+}
+
+// GetPreviousStageId returns id of the stage which precedes the stage with provided order and id
+// in the pipeline chain. Stages with the same order are chained by id. Pass zero id for not created stage.
+func GetPreviousStageId(txn *sql.Tx, schemaName string, cdPipelineName string, order int, id int) (*int, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(selectPreviousStageId, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var prevId int
+	err = stmt.QueryRow(cdPipelineName, order, id).Scan(&prevId)
+	if err != nil {
+		return checkNoRows(err)
+	}
+	return &prevId, nil
 }
 
 func GetStageId(txn *sql.Tx, schemaName string, name string, cdPipelineName string) (id *int, err error) {
@@ -108,21 +155,6 @@ func UpdateStageStatus(txn *sql.Tx, schemaName string, id int, status string) er
 	return err
 }
 
-func GetStageIdByPipelineNameAndOrder(txn *sql.Tx, schemaName string, cdPipelineName string, order int) (id *int, err error) {
-	stmt, err := txn.Prepare(fmt.Sprintf(GetStageIdByPipelineNameAndOrderQuery, schemaName, schemaName))
-
-	if err != nil {
-		return
-	}
-	defer stmt.Close()
-
-	err = stmt.QueryRow(cdPipelineName, order).Scan(&id)
-	if err != nil {
-		return checkNoRows(err)
-	}
-	return
-}
-
 func checkNoRows(err error) (*int, error) {
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -183,6 +215,51 @@ func CreateQualityGate(txn *sql.Tx, qualityGateType string, jenkinsStepName stri
 	return id, nil
 }
 
+func GetQualityGates(txn *sql.Tx, cdStageId int, schemaName string) ([]stage.QualityGate, error) {
+	stmt, err := txn.Prepare(fmt.Sprintf(selectQualityGates, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(cdStageId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []stage.QualityGate
+	for rows.Next() {
+		gate := stage.QualityGate{}
+		if err := rows.Scan(&gate.Id, &gate.QualityGate, &gate.JenkinsStepName, &gate.AutotestName, &gate.BranchName); err != nil {
+			return nil, err
+		}
+		result = append(result, gate)
+	}
+	return result, rows.Err()
+}
+
+func DeleteQualityGates(txn *sql.Tx, cdStageId int, schemaName string) error {
+	if _, err := txn.Exec(fmt.Sprintf(deleteQualityGates, schemaName), cdStageId); err != nil {
+		return err
+	}
+	return nil
+}
+
+func DeleteQualityGate(txn *sql.Tx, id int, schemaName string) error {
+	if _, err := txn.Exec(fmt.Sprintf(deleteQualityGate, schemaName), id); err != nil {
+		return err
+	}
+	return nil
+}
+
+func UpdateQualityGateStepName(txn *sql.Tx, id int, stepName string, schemaName string) error {
+	if _, err := txn.Exec(fmt.Sprintf(updateQualityGateStepName, schemaName), stepName, id); err != nil {
+		return err
+	}
+	return nil
+}
+
 func GetCodebaseAndBranchIds(txn *sql.Tx, autotestName, branchName, schemaName string) (*model.CodebaseBranchIdDTO, error) {
 	stmt, err := txn.Prepare(fmt.Sprintf(SelectCodebaseAndBranchIds, schemaName, schemaName))
 
@@ -210,19 +287,6 @@ func DeleteCDStage(txn *sql.Tx, pipeName, stageName, schema string) error {
 	return nil
 }
 
-func SelectCodebaseDockerStreamId(txn *sql.Tx, pipeName, stageName, schema string) (id *int, err error) {
-	stmt, err := txn.Prepare(fmt.Sprintf(selectCodebaseDockerStreamId, schema))
-	if err != nil {
-		return
-	}
-	defer stmt.Close()
-
-	if err = stmt.QueryRow(pipeName, stageName).Scan(&id); err != nil {
-		return checkNoRows(err)
-	}
-	return
-}
-
 func DeleteCodebaseDockerStream(txn *sql.Tx, id int, schema string) error {
 	if _, err := txn.Exec(fmt.Sprintf(deleteCodebaseDockerStream, schema), id); err != nil {
 		return err
@@ -0,0 +1,80 @@
+package actionlog
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/epam/edp-reconciler/v2/pkg/repository"
+)
+
+type archivedActionLog struct {
+	Schema          string    `json:"schema"`
+	Entity          string    `json:"entity"`
+	EntityId        int       `json:"entityId"`
+	Id              int       `json:"id"`
+	Action          string    `json:"action"`
+	Result          string    `json:"result"`
+	Username        string    `json:"username"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+	ActionMessage   string    `json:"actionMessage"`
+	DetailedMessage string    `json:"detailedMessage"`
+}
+
+// archive appends action logs of one schema and entity table to a gzipped JSON-lines file.
+// Every batch is flushed to disk before it's written, so rows are deleted only once they're archived.
+type archive struct {
+	schema string
+	link   repository.ActionLogLink
+	file   *os.File
+	gz     *gzip.Writer
+}
+
+func newArchive(dir, schema string, link repository.ActionLogLink, now time.Time) (*archive, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%v_%v_%v.jsonl.gz", schema, link.Table, now.UTC().Format("20060102T150405Z"))
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &archive{schema: schema, link: link, file: f, gz: gzip.NewWriter(f)}, nil
+}
+
+func (a *archive) write(logs []repository.PrunableActionLog) error {
+	enc := json.NewEncoder(a.gz)
+	for _, l := range logs {
+		if err := enc.Encode(archivedActionLog{
+			Schema:          a.schema,
+			Entity:          a.link.Table,
+			EntityId:        l.EntityId,
+			Id:              l.Id,
+			Action:          l.Action,
+			Result:          l.Result,
+			Username:        l.Username,
+			UpdatedAt:       l.UpdatedAt,
+			ActionMessage:   l.ActionMessage,
+			DetailedMessage: l.DetailedMessage,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := a.gz.Flush(); err != nil {
+		return err
+	}
+	return a.file.Sync()
+}
+
+func (a *archive) close() error {
+	if err := a.gz.Close(); err != nil {
+		_ = a.file.Close()
+		return err
+	}
+	return a.file.Close()
+}
@@ -0,0 +1,32 @@
+package actionlog
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	prunedActionLogs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reconciler_action_log_pruned_total",
+		Help: "Number of action logs deleted by the retention policy",
+	}, []string{"schema", "entity"})
+
+	archivedActionLogs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reconciler_action_log_archived_total",
+		Help: "Number of pruned action logs written to the archive",
+	}, []string{"schema", "entity"})
+
+	prunedChangeEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reconciler_change_event_pruned_total",
+		Help: "Number of change feed events deleted by the retention policy",
+	}, []string{"schema"})
+
+	pruneFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reconciler_action_log_prune_failures_total",
+		Help: "Number of failed prunes of the tenant schema",
+	}, []string{"schema"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(prunedActionLogs, archivedActionLogs, prunedChangeEvents, pruneFailures)
+}
@@ -0,0 +1,99 @@
+package actionlog
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	maxAgeEnv        = "ACTION_LOG_RETENTION_MAX_AGE"
+	maxRowsEnv       = "ACTION_LOG_RETENTION_MAX_ROWS"
+	pruneIntervalEnv = "ACTION_LOG_PRUNE_INTERVAL"
+	pruneBatchEnv    = "ACTION_LOG_PRUNE_BATCH_SIZE"
+	archiveDirEnv    = "ACTION_LOG_ARCHIVE_DIR"
+	// changeEventMaxAgeEnv limits age of the tenant change feed events
+	changeEventMaxAgeEnv = "CHANGE_EVENT_RETENTION_MAX_AGE"
+
+	defaultPruneInterval = time.Hour
+	defaultPruneBatch    = 1000
+)
+
+// RetentionPolicy describes which action logs are kept in DB.
+// Zero MaxAge or MaxRowsPerEntity disables the corresponding rule.
+type RetentionPolicy struct {
+	MaxAge           time.Duration
+	MaxRowsPerEntity int
+	Interval         time.Duration
+	BatchSize        int
+	// ArchiveDir is a directory pruned rows are written to as gzipped JSON lines once their deletion is committed.
+	// Rows are deleted without archiving if it's empty.
+	ArchiveDir string
+	// ChangeEventMaxAge is the age change feed events are pruned at. Events the outbox hasn't queued yet are kept.
+	ChangeEventMaxAge time.Duration
+}
+
+// Enabled returns true if at least one retention rule is set
+func (p RetentionPolicy) Enabled() bool {
+	return p.MaxAge > 0 || p.MaxRowsPerEntity > 0 || p.ChangeEventMaxAge > 0
+}
+
+// PolicyFromEnv reads retention policy from ACTION_LOG_* and CHANGE_EVENT_* env variables
+func PolicyFromEnv() (*RetentionPolicy, error) {
+	p := &RetentionPolicy{
+		Interval:   defaultPruneInterval,
+		BatchSize:  defaultPruneBatch,
+		ArchiveDir: os.Getenv(archiveDirEnv),
+	}
+
+	var err error
+	if p.MaxAge, err = durationEnv(maxAgeEnv, p.MaxAge); err != nil {
+		return nil, err
+	}
+	if p.ChangeEventMaxAge, err = durationEnv(changeEventMaxAgeEnv, p.ChangeEventMaxAge); err != nil {
+		return nil, err
+	}
+	if p.Interval, err = durationEnv(pruneIntervalEnv, p.Interval); err != nil {
+		return nil, err
+	}
+	if p.MaxRowsPerEntity, err = intEnv(maxRowsEnv, p.MaxRowsPerEntity); err != nil {
+		return nil, err
+	}
+	if p.BatchSize, err = intEnv(pruneBatchEnv, p.BatchSize); err != nil {
+		return nil, err
+	}
+
+	if p.MaxAge < 0 || p.MaxRowsPerEntity < 0 || p.ChangeEventMaxAge < 0 {
+		return nil, errors.New("action log retention limits must not be negative")
+	}
+	if p.Interval <= 0 || p.BatchSize <= 0 {
+		return nil, errors.New("action log prune interval and batch size must be positive")
+	}
+	return p, nil
+}
+
+func durationEnv(key string, def time.Duration) (time.Duration, error) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, errors.Wrapf(err, "couldn't parse %v env variable", key)
+	}
+	return d, nil
+}
+
+func intEnv(key string, def int) (int, error) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, errors.Wrapf(err, "couldn't parse %v env variable", key)
+	}
+	return i, nil
+}
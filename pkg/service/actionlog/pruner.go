@@ -0,0 +1,239 @@
+package actionlog
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/epam/edp-reconciler/v2/pkg/db/migration"
+	"github.com/epam/edp-reconciler/v2/pkg/repository"
+)
+
+var log = ctrl.Log.WithName("action-log-pruner")
+
+// links are tables which link action logs to entities, every action log is pruned per entity it belongs to
+var links = []repository.ActionLogLink{
+	repository.CodebaseActionLogLink,
+	repository.CodebaseBranchActionLogLink,
+	repository.CDPipelineActionLogLink,
+	repository.GitServerActionLogLink,
+}
+
+// Pruner periodically deletes action logs and change feed events which don't match the retention policy
+// in all migrated tenant schemas.
+// It implements manager.Runnable and runs on the leader only.
+type Pruner struct {
+	DB     *sql.DB
+	Policy RetentionPolicy
+	// OutboxSinks are names of the configured outbox sinks, change events they haven't queued yet are kept
+	OutboxSinks []string
+	now         func() time.Time
+}
+
+func NewPruner(db *sql.DB, policy RetentionPolicy) *Pruner {
+	return &Pruner{
+		DB:     db,
+		Policy: policy,
+		now:    time.Now,
+	}
+}
+
+func (p *Pruner) NeedLeaderElection() bool {
+	return true
+}
+
+func (p *Pruner) Start(ctx context.Context) error {
+	log.Info("starting action log pruner", "max age", p.Policy.MaxAge.String(),
+		"max rows per entity", p.Policy.MaxRowsPerEntity, "change event max age", p.Policy.ChangeEventMaxAge.String(),
+		"interval", p.Policy.Interval.String())
+
+	ticker := time.NewTicker(p.Policy.Interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Prune(ctx); err != nil {
+			log.Error(err, "couldn't prune action logs")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Prune removes action logs and change events exceeding the retention policy from all schemas.
+// Failure of one schema doesn't stop the others, errors of all failed schemas are returned together.
+func (p *Pruner) Prune(ctx context.Context) error {
+	schemas, err := migration.Schemas(p.DB)
+	if err != nil {
+		return errors.Wrap(err, "couldn't get tenant schemas")
+	}
+
+	var result error
+	for _, schema := range schemas {
+		if ctx.Err() != nil {
+			return result
+		}
+		if err := p.pruneSchema(ctx, schema); err != nil {
+			log.Error(err, "couldn't prune the schema", "schema", schema)
+			pruneFailures.WithLabelValues(schema).Inc()
+			result = multierr.Append(result, err)
+		}
+	}
+	return result
+}
+
+func (p *Pruner) pruneSchema(ctx context.Context, schema string) error {
+	if p.Policy.ChangeEventMaxAge > 0 {
+		n, err := p.pruneChangeEvents(ctx, schema)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't prune change events in %v schema", schema)
+		}
+		if n > 0 {
+			log.Info("change events have been pruned", "schema", schema, "count", n)
+		}
+	}
+
+	if p.Policy.MaxAge == 0 && p.Policy.MaxRowsPerEntity == 0 {
+		return nil
+	}
+	for _, link := range links {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		n, err := p.pruneLink(ctx, schema, link)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't prune %v in %v schema", link.Table, schema)
+		}
+		if n > 0 {
+			log.Info("action logs have been pruned", "schema", schema, "entity", link.Table, "count", n)
+		}
+	}
+	return nil
+}
+
+// pruneLink deletes prunable action logs of one entity table batch by batch, every batch in its own transaction
+func (p *Pruner) pruneLink(ctx context.Context, schema string, link repository.ActionLogLink) (int, error) {
+	var olderThan time.Time
+	if p.Policy.MaxAge > 0 {
+		olderThan = p.now().Add(-p.Policy.MaxAge)
+	}
+
+	var arc *archive
+	defer func() {
+		if arc != nil {
+			if err := arc.close(); err != nil {
+				log.Error(err, "couldn't close action log archive", "schema", schema, "entity", link.Table)
+			}
+		}
+	}()
+
+	total := 0
+	for ctx.Err() == nil {
+		selected, logs, err := p.pruneBatch(schema, link, olderThan)
+		if err != nil {
+			return total, err
+		}
+
+		total += len(logs)
+		prunedActionLogs.WithLabelValues(schema, link.Table).Add(float64(len(logs)))
+		if p.Policy.ArchiveDir != "" && len(logs) > 0 {
+			if arc == nil {
+				a, err := newArchive(p.Policy.ArchiveDir, schema, link, p.now())
+				if err != nil {
+					return total, errors.Wrap(err, "couldn't create action log archive")
+				}
+				arc = a
+			}
+			if err := arc.write(logs); err != nil {
+				return total, errors.Wrap(err, "couldn't archive action logs")
+			}
+			archivedActionLogs.WithLabelValues(schema, link.Table).Add(float64(len(logs)))
+		}
+		if selected < p.Policy.BatchSize {
+			break
+		}
+	}
+	return total, nil
+}
+
+// pruneBatch deletes one batch of prunable action logs and returns number of the selected ones
+// along with the deleted ones. The deleted logs are returned once the transaction is committed,
+// so they are archived exactly once.
+func (p *Pruner) pruneBatch(schema string, link repository.ActionLogLink,
+	olderThan time.Time) (int, []repository.PrunableActionLog, error) {
+	txn, err := p.DB.Begin()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	logs, err := repository.GetPrunableActionLogs(txn, link, olderThan, p.Policy.MaxRowsPerEntity,
+		p.Policy.BatchSize, schema)
+	if err != nil {
+		_ = txn.Rollback()
+		return 0, nil, err
+	}
+	if len(logs) == 0 {
+		_ = txn.Rollback()
+		return 0, nil, nil
+	}
+
+	ids := make([]int, 0, len(logs))
+	for _, l := range logs {
+		ids = append(ids, l.Id)
+	}
+	deleted, err := repository.DeleteActionLogs(txn, link, ids, schema)
+	if err != nil {
+		_ = txn.Rollback()
+		return 0, nil, err
+	}
+
+	if err := txn.Commit(); err != nil {
+		return 0, nil, err
+	}
+
+	removed := make(map[int]bool, len(deleted))
+	for _, id := range deleted {
+		removed[id] = true
+	}
+	result := make([]repository.PrunableActionLog, 0, len(deleted))
+	for _, l := range logs {
+		if removed[l.Id] {
+			result = append(result, l)
+		}
+	}
+	return len(logs), result, nil
+}
+
+// pruneChangeEvents deletes change events older than the max age batch by batch
+func (p *Pruner) pruneChangeEvents(ctx context.Context, schema string) (int, error) {
+	olderThan := p.now().Add(-p.Policy.ChangeEventMaxAge)
+	total := 0
+	for ctx.Err() == nil {
+		txn, err := p.DB.Begin()
+		if err != nil {
+			return total, err
+		}
+		n, err := repository.DeleteChangeEvents(txn, olderThan, p.Policy.BatchSize, p.OutboxSinks, schema)
+		if err != nil {
+			_ = txn.Rollback()
+			return total, err
+		}
+		if err := txn.Commit(); err != nil {
+			return total, err
+		}
+
+		total += n
+		prunedChangeEvents.WithLabelValues(schema).Add(float64(n))
+		if n < p.Policy.BatchSize {
+			break
+		}
+	}
+	return total, nil
+}
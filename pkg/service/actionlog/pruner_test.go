@@ -0,0 +1,184 @@
+package actionlog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/epam/edp-reconciler/v2/pkg/repository"
+)
+
+func TestPruner_pruneLink_ArchivesAndDeletesInBatches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	p := NewPruner(db, RetentionPolicy{MaxAge: 24 * time.Hour, MaxRowsPerEntity: 10, BatchSize: 2, ArchiveDir: dir})
+	p.now = func() time.Time { return now }
+
+	columns := []string{"id", "entity_id", "detailed_message", "username", "updated_at", "action", "action_message", "result"}
+	old := now.Add(-48 * time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`join "schema".codebase_action_log l`).WithArgs(now.Add(-24*time.Hour), 10, 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, 5, "", "user", old, "codebase_registration", "", "success").
+			AddRow(2, 5, "", "user", old, "setup_deployment_templates", "", "success"))
+	mock.ExpectExec(`delete from "schema".codebase_action_log where action_log_id = any`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(`delete from "schema".action_log where id = any`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`join "schema".codebase_action_log l`).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(3, 6, "", "user", old, "codebase_registration", "", "success"))
+	mock.ExpectExec(`delete from "schema".codebase_action_log`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`delete from "schema".action_log`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectCommit()
+
+	n, err := p.pruneLink(context.Background(), "schema", repository.CodebaseActionLogLink)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	files, err := filepath.Glob(filepath.Join(dir, "schema_codebase_action_log_*.jsonl.gz"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	f, err := os.Open(files[0])
+	assert.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+
+	var ids []int
+	s := bufio.NewScanner(gz)
+	for s.Scan() {
+		var l archivedActionLog
+		assert.NoError(t, json.Unmarshal(s.Bytes(), &l))
+		ids = append(ids, l.Id)
+	}
+	assert.Equal(t, []int{1, 2, 3}, ids)
+}
+
+func TestPruner_pruneLink_DoesNotArchiveUncommittedBatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	p := NewPruner(db, RetentionPolicy{MaxAge: 24 * time.Hour, BatchSize: 2, ArchiveDir: dir})
+	p.now = func() time.Time { return now }
+
+	columns := []string{"id", "entity_id", "detailed_message", "username", "updated_at", "action", "action_message", "result"}
+	mock.ExpectBegin()
+	mock.ExpectQuery(`join "schema".codebase_action_log l`).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, 5, "", "user", now.Add(-48*time.Hour), "codebase_registration", "", "success"))
+	mock.ExpectExec(`delete from "schema".codebase_action_log`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`delete from "schema".action_log`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit().WillReturnError(errors.New("connection reset"))
+
+	n, err := p.pruneLink(context.Background(), "schema", repository.CodebaseActionLogLink)
+	assert.Error(t, err)
+	assert.Equal(t, 0, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl.gz"))
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestPruner_pruneChangeEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	p := NewPruner(db, RetentionPolicy{ChangeEventMaxAge: 24 * time.Hour, BatchSize: 2})
+	p.OutboxSinks = []string{"webhook/bot"}
+	p.now = func() time.Time { return now }
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`delete from "schema".change_event where id in`).
+		WithArgs(now.Add(-24*time.Hour), 2, pq.StringArray{"webhook/bot"}).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`delete from "schema".change_event where id in`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectCommit()
+
+	n, err := p.pruneChangeEvents(context.Background(), "schema")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPruner_Prune_ContinuesWithNextSchemaOnFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	p := NewPruner(db, RetentionPolicy{ChangeEventMaxAge: time.Hour, BatchSize: 10})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select table_schema from information_schema.tables`).
+		WillReturnRows(sqlmock.NewRows([]string{"table_schema"}).AddRow("broken").AddRow("edp"))
+	mock.ExpectQuery(`select version from "broken".reconciler_migration`).WillReturnRows(migratedVersions(t))
+	mock.ExpectQuery(`select version from "edp".reconciler_migration`).WillReturnRows(migratedVersions(t))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`delete from "broken".change_event`).WillReturnError(errors.New("locked"))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`delete from "edp".change_event`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectCommit()
+
+	err = p.Prune(context.Background())
+	assert.EqualError(t, err, "couldn't prune change events in broken schema: locked")
+	assert.Equal(t, float64(1), testutil.ToFloat64(pruneFailures.WithLabelValues("broken")))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// migratedVersions returns rows of reconciler_migration of the schema with all migrations applied
+func migratedVersions(t *testing.T) *sqlmock.Rows {
+	entries, err := os.ReadDir("../../db/migration/sql")
+	assert.NoError(t, err)
+
+	rows := sqlmock.NewRows([]string{"version"})
+	for _, e := range entries {
+		rows.AddRow(e.Name())
+	}
+	return rows
+}
+
+func TestPolicyFromEnv(t *testing.T) {
+	t.Setenv(maxAgeEnv, "720h")
+	t.Setenv(maxRowsEnv, "100")
+
+	p, err := PolicyFromEnv()
+	assert.NoError(t, err)
+	assert.True(t, p.Enabled())
+	assert.Equal(t, 720*time.Hour, p.MaxAge)
+	assert.Equal(t, 100, p.MaxRowsPerEntity)
+	assert.Equal(t, defaultPruneBatch, p.BatchSize)
+
+	t.Setenv(maxRowsEnv, "-1")
+	_, err = PolicyFromEnv()
+	assert.Error(t, err)
+}
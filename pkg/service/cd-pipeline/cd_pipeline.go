@@ -3,55 +3,104 @@ package cd_pipeline
 import (
 	"database/sql"
 	"fmt"
+	"github.com/epam/edp-reconciler/v2/pkg/db/migration"
 	"github.com/epam/edp-reconciler/v2/pkg/model"
 	"github.com/epam/edp-reconciler/v2/pkg/model/cdpipeline"
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
 	"github.com/epam/edp-reconciler/v2/pkg/model/stage"
-	"github.com/epam/edp-reconciler/v2/pkg/platform"
 	"github.com/epam/edp-reconciler/v2/pkg/repository"
 	sr "github.com/epam/edp-reconciler/v2/pkg/repository/stage"
+	"github.com/epam/edp-reconciler/v2/pkg/service/changefeed"
 	stageService "github.com/epam/edp-reconciler/v2/pkg/service/stage"
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sort"
 )
 
 var log = ctrl.Log.WithName("cd_pipeline_service")
 
+// ErrDockerStreamsNotFound is returned by PutCDPipeline when some of input docker streams are not in DB yet,
+// e.g. the branches of promoted codebases haven't been synced, so the pipeline has to be put later
+var ErrDockerStreamsNotFound = errors.New("input docker streams have not been found")
+
 type CdPipelineService struct {
-	DB        *sql.DB
-	ClientSet platform.ClientSet
+	DB     *sql.DB
+	Client client.Client
 }
 
 func (s CdPipelineService) PutCDPipeline(cdPipeline cdpipeline.CDPipeline) error {
 	log.V(2).Info("start CD Pipeline creation", "name", cdPipeline.Name)
+	if err := migration.Ensure(s.DB, cdPipeline.Tenant); err != nil {
+		return err
+	}
+
 	txn, err := s.DB.Begin()
 	if err != nil {
 		return errors.New("an error has occurred while opening transaction")
 	}
 	schemaName := cdPipeline.Tenant
 
-	cdPipelineDb, err := s.getCDPipelineOrCreate(txn, cdPipeline, schemaName)
+	err = changefeed.InTx(txn, change.CDPipeline, cdPipeline.Name, schemaName, func() error {
+		missing, err := validateApplicationsToPromote(txn, cdPipeline, schemaName)
+		if err != nil {
+			return errors.Wrapf(err, "cd pipeline %v is invalid", cdPipeline.Name)
+		}
+		if len(missing) > 0 {
+			return errors.Wrapf(ErrDockerStreamsNotFound, "cd pipeline %v takes %v", cdPipeline.Name, missing)
+		}
+
+		cdPipelineDb, err := s.getCDPipelineOrCreate(txn, cdPipeline, schemaName)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't get/create cd pipeline %v", cdPipeline.Name)
+		}
+		log.Info("CD Pipeline has been retrieved", "id", cdPipelineDb.Id)
+
+		if err := updateCDPipeline(txn, *cdPipelineDb, cdPipeline, schemaName); err != nil {
+			return errors.Wrapf(err, "an error has occurred while updating %v CD Pipeline", cdPipelineDb.Name)
+		}
+
+		if err := updateActionLog(txn, cdPipeline, cdPipelineDb.Id, schemaName); err != nil {
+			return errors.Wrapf(err, "an error has occurred while updating CD Pipeline %v Action Event Log", cdPipeline.Name)
+		}
+		return nil
+	})
 	if err != nil {
-		err = txn.Rollback()
-		return errors.Wrapf(err, "couldn't get/create cd pipeline %v", cdPipeline.Name)
+		return err
 	}
-	log.Info("CD Pipeline has been retrieved", "id", cdPipelineDb.Id)
 
-	if err := updateCDPipelineStatus(txn, *cdPipelineDb, cdPipeline.Status, schemaName); err != nil {
-		err = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred while updating %v CD Pipeline Status", cdPipelineDb.Name)
-	}
+	log.Info("CD Pipeline has been saved successfully", "name", cdPipeline.Name)
+	return nil
+}
 
-	if err := updateActionLog(txn, cdPipeline, cdPipelineDb.Id, schemaName); err != nil {
-		err = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred while updating CD Pipelin %ve Action Event Log", cdPipeline.Name)
+// validateApplicationsToPromote checks that every application to promote is a codebase
+// of one of the pipeline input docker streams. Input docker streams which are not in DB yet are returned,
+// since the pipeline can't be put without them and the promoted application could be among their codebases.
+func validateApplicationsToPromote(txn *sql.Tx, cdPipeline cdpipeline.CDPipeline, schemaName string) ([]string, error) {
+	codebases := map[string]bool{}
+	var missing []string
+	for _, dockerStream := range cdPipeline.InputDockerStreams {
+		name, err := repository.GetCodebaseNameByDockerStream(txn, dockerStream, schemaName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "an error has occurred while getting codebase of docker stream %v", dockerStream)
+		}
+		if name == nil {
+			log.Info("input docker stream has not been found. Deferring the pipeline", "stream", dockerStream)
+			missing = append(missing, dockerStream)
+			continue
+		}
+		codebases[*name] = true
+	}
+	if len(missing) > 0 {
+		return missing, nil
 	}
 
-	if err := txn.Commit(); err != nil {
-		return errors.Wrap(err, "an error has occurred while closing transaction")
+	for _, app := range cdPipeline.ApplicationsToPromote {
+		if !codebases[app] {
+			return nil, fmt.Errorf("application to promote %v is not among codebases of input docker streams", app)
+		}
 	}
-	log.Info("CD Pipeline has been saved successfully", "name", cdPipelineDb.Name)
-	return nil
+	return nil, nil
 }
 
 func (s CdPipelineService) getCDPipelineOrCreate(txn *sql.Tx, cdPipeline cdpipeline.CDPipeline, schemaName string) (*model.CDPipelineDTO, error) {
@@ -97,17 +146,14 @@ func (s CdPipelineService) getCDPipelineOrCreate(txn *sql.Tx, cdPipeline cdpipel
 
 	cdPipelineDTO, err := createCDPipeline(txn, cdPipeline, schemaName)
 	if err != nil {
-		_ = txn.Rollback()
 		return nil, err
 	}
 
 	if err := createCDPipelineDockerStream(txn, cdPipelineDTO.Id, cdPipeline.InputDockerStreams, schemaName); err != nil {
-		_ = txn.Rollback()
 		return nil, err
 	}
 
 	if err := createApplicationToPromoteRow(txn, cdPipelineDTO.Id, cdPipeline.ApplicationsToPromote, schemaName); err != nil {
-		_ = txn.Rollback()
 		return nil, errors.Wrap(err, "an error has occurred while inserting record into applications_to_promote")
 	}
 	return cdPipelineDTO, nil
@@ -123,19 +169,23 @@ func updateApplicationsToPromote(tx *sql.Tx, cdPipelineId int, applicationsToPro
 	return nil
 }
 
+// createApplicationToPromoteRow inserts promotion rows for the applications to promote. Any codebase type
+// could be promoted, so codebases are looked up regardless of the type.
 func createApplicationToPromoteRow(txn *sql.Tx, cdPipelineId int, applicationsToPromote []string, schemaName string) error {
 	log.V(2).Info("try to create record in ApplicationToPromote table", "applicationsToPromote", applicationsToPromote)
 	for _, appToPromote := range applicationsToPromote {
-		id, err := repository.GetApplicationId(txn, appToPromote, schemaName)
+		id, err := repository.GetCodebaseId(txn, appToPromote, schemaName)
 		if err != nil {
 			return err
 		}
+		if id == nil {
+			return fmt.Errorf("codebase %v to promote has not been found", appToPromote)
+		}
 
 		if err := repository.CreateApplicationsToPromote(txn, cdPipelineId, *id, schemaName); err != nil {
 			return err
 		}
 	}
-
 	return nil
 }
 
@@ -145,7 +195,7 @@ func (s CdPipelineService) updateStageCodebaseDockerStreamRelations(txn *sql.Tx,
 		stages[i].Tenant = schemaName
 		stages[i].CdPipelineName = pipelineName
 
-		pipelineCR, err := stageService.GetCDPipelineCR(s.ClientSet.EDPRestClient, stages[i].CdPipelineName, stages[i].Namespace)
+		pipelineCR, err := stageService.GetCDPipelineCR(s.Client, stages[i].CdPipelineName, stages[i].Namespace)
 		if err != nil {
 			return err
 		}
@@ -211,28 +261,22 @@ func createCDPipeline(txn *sql.Tx, cdPipeline cdpipeline.CDPipeline, schemaName
 
 func updateActionLog(txn *sql.Tx, cdPipeline cdpipeline.CDPipeline, pipelineId int, schemaName string) error {
 	log.V(2).Info("start updating status of CD Pipeline", "name", cdPipeline.Name)
-	actionLogId, err := repository.CreateEventActionLog(txn, cdPipeline.ActionLog, schemaName)
-	if err != nil {
-		return errors.Wrapf(err, "cannot insert status %v", cdPipeline)
-	}
-
-	log.V(2).Info("start updating cd_pipeline_codebase_action status of code pipeline entity...")
-	if err := repository.CreateCDPipelineActionLog(txn, pipelineId, *actionLogId, schemaName); err != nil {
-		return errors.Wrapf(err, "cannot create cd_pipeline_action entity %v", cdPipeline)
+	if _, err := repository.CreateActionLogOnce(txn, repository.CDPipelineActionLogLink, pipelineId,
+		cdPipeline.ActionLog, schemaName); err != nil {
+		return errors.Wrapf(err, "cannot insert status of cd pipeline %v", cdPipeline.Name)
 	}
 	log.Info("cd_pipeline_action has been updated")
 	return nil
 }
 
-func updateCDPipelineStatus(txn *sql.Tx, cdPipelineDb model.CDPipelineDTO, status string, schemaName string) error {
-	if cdPipelineDb.Status != status {
-		log.V(2).Info("start updating status of cd pipeline",
-			"pipe name", cdPipelineDb.Name, "status", status)
-		if err := repository.UpdateCDPipelineStatus(txn, cdPipelineDb.Id, status, schemaName); err != nil {
-			return err
-		}
+func updateCDPipeline(txn *sql.Tx, cdPipelineDb model.CDPipelineDTO, cdPipeline cdpipeline.CDPipeline, schemaName string) error {
+	if cdPipelineDb.Status == cdPipeline.Status && cdPipelineDb.DeploymentType == cdPipeline.DeploymentType {
+		return nil
 	}
-	return nil
+
+	log.V(2).Info("start updating cd pipeline", "pipe name", cdPipelineDb.Name,
+		"status", cdPipeline.Status, "deployment type", cdPipeline.DeploymentType)
+	return repository.UpdateCDPipeline(txn, cdPipelineDb.Id, cdPipeline.DeploymentType, cdPipeline.Status, schemaName)
 }
 
 func createCDPipelineDockerStream(txn *sql.Tx, cdPipelineId int, dockerStreams []string, schemaName string) error {
@@ -266,22 +310,25 @@ func insertCDPipelineDockerStream(txn *sql.Tx, cdPipelineId int, dockerStreams [
 
 func (s CdPipelineService) DeleteCDPipeline(pipeName, schema string) error {
 	log.V(2).Info("start deleting cd pipeline", "name", pipeName)
-	txn, err := s.DB.Begin()
-	if err != nil {
+	if err := migration.Ensure(s.DB, schema); err != nil {
 		return err
 	}
 
-	if err := sr.DeleteCodebaseDockerStreams(txn, pipeName, schema); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "couldn't delete codebase docker streams for %v cd pipeline", pipeName)
-	}
-
-	if err := repository.DeleteCDPipeline(txn, pipeName, schema); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "couldn't delete cd pipeline %v", pipeName)
+	txn, err := s.DB.Begin()
+	if err != nil {
+		return err
 	}
 
-	if err := txn.Commit(); err != nil {
+	err = changefeed.InTx(txn, change.CDPipeline, pipeName, schema, func() error {
+		if err := sr.DeleteCodebaseDockerStreams(txn, pipeName, schema); err != nil {
+			return errors.Wrapf(err, "couldn't delete codebase docker streams for %v cd pipeline", pipeName)
+		}
+		if err := repository.DeleteCDPipeline(txn, pipeName, schema); err != nil {
+			return errors.Wrapf(err, "couldn't delete cd pipeline %v", pipeName)
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 	log.Info("cd pipeline has been deleted", "pipe name", pipeName)
@@ -0,0 +1,88 @@
+package cd_pipeline
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/cdpipeline"
+)
+
+func TestCreateApplicationToPromoteRow_AnyCodebaseTypeShouldBePromoted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select id from "schema".codebase where name=\$1;`).ExpectQuery().WithArgs("lib").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectPrepare(`insert into "schema".applications_to_promote`).ExpectExec().WithArgs(5, 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = createApplicationToPromoteRow(tx, 5, []string{"lib"}, "schema")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestValidateApplicationsToPromote_MissingDockerStreamShouldBeDeferred(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select c.name`).ExpectQuery().WithArgs("lib-master").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("lib"))
+	mock.ExpectPrepare(`select c.name`).ExpectQuery().WithArgs("app-master").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	missing, err := validateApplicationsToPromote(tx, cdpipeline.CDPipeline{
+		InputDockerStreams:    []string{"lib-master", "app-master"},
+		ApplicationsToPromote: []string{"app"},
+	}, "schema")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app-master"}, missing)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestValidateApplicationsToPromote_NotInputStreamCodebaseShouldFail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select c.name`).ExpectQuery().WithArgs("app-master").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("app"))
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = validateApplicationsToPromote(tx, cdpipeline.CDPipeline{
+		InputDockerStreams:    []string{"app-master"},
+		ApplicationsToPromote: []string{"app", "other"},
+	}, "schema")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "other")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,61 @@
+package changefeed
+
+import (
+	"sync"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+)
+
+// DefaultBus is the bus services publish committed changes to
+var DefaultBus = NewBus()
+
+// Bus delivers committed change events to in-process subscribers.
+// Subscribers which don't keep up are dropped by closing their channel, they are expected
+// to catch up from the change_event table using the id of the last processed event.
+type Bus struct {
+	mu     sync.Mutex
+	nextId int
+	subs   map[int]chan change.Event
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		subs: map[int]chan change.Event{},
+	}
+}
+
+// Subscribe returns channel of events published after the call and function cancelling the subscription
+func (b *Bus) Subscribe(buffer int) (<-chan change.Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextId
+	b.nextId++
+	ch := make(chan change.Event, buffer)
+	b.subs[id] = ch
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if ch, ok := b.subs[id]; ok {
+			delete(b.subs, id)
+			close(ch)
+		}
+	}
+}
+
+func (b *Bus) Publish(events ...change.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, e := range events {
+		for id, ch := range b.subs {
+			select {
+			case ch <- e:
+			default:
+				delete(b.subs, id)
+				close(ch)
+			}
+		}
+	}
+}
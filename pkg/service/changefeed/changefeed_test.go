@@ -0,0 +1,188 @@
+package changefeed
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+)
+
+var branchColumns = []string{"id", "name", "from_commit", "status", "version", "build_number", "last_success_build",
+	"release", "oc_image_stream_name"}
+
+func branchRow(status string) *sqlmock.Rows {
+	return sqlmock.NewRows(branchColumns).AddRow(3, "master", "", status, nil, nil, nil, false, "app-master")
+}
+
+func TestRecord_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	createdAt := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`from "schema".codebase_branch cb`).WithArgs("app", "master").
+		WillReturnRows(sqlmock.NewRows(branchColumns))
+	mock.ExpectQuery(`from "schema".codebase_branch cb`).WithArgs("app", "master").
+		WillReturnRows(branchRow("active"))
+	mock.ExpectExec(`pg_advisory_xact_lock`).WithArgs("schema.change_event").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare(`insert into "schema".change_event`).ExpectQuery().
+		WithArgs(change.CodebaseBranch, "app/master", change.Create, nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(7, createdAt))
+	mock.ExpectExec(`pg_notify`).
+		WithArgs("reconciler_schema", `{"kind":"codebase_branch","id":3,"name":"app/master","op":"create"}`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	txn, err := db.Begin()
+	assert.NoError(t, err)
+
+	tracker, err := Track(txn, change.CodebaseBranch, Name("app", "master"), "schema")
+	assert.NoError(t, err)
+	assert.True(t, tracker.IsNew())
+
+	e, err := tracker.Record(txn)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), e.Id)
+	assert.Equal(t, change.Create, e.Op)
+	assert.Equal(t, createdAt, e.CreatedAt)
+	assert.Nil(t, e.Before)
+	assert.Contains(t, string(e.After), `"status":"active"`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecord_Unchanged(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`from "schema".codebase_branch cb`).WillReturnRows(branchRow("active"))
+	mock.ExpectQuery(`from "schema".codebase_branch cb`).WillReturnRows(branchRow("active"))
+
+	txn, err := db.Begin()
+	assert.NoError(t, err)
+
+	tracker, err := Track(txn, change.CodebaseBranch, Name("app", "master"), "schema")
+	assert.NoError(t, err)
+
+	e, err := tracker.Record(txn)
+	assert.NoError(t, err)
+	assert.Nil(t, e)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInTx_RollsBackFailedChange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`from "schema".codebase_branch cb`).WillReturnRows(branchRow("active"))
+	mock.ExpectRollback()
+
+	txn, err := db.Begin()
+	assert.NoError(t, err)
+
+	failed := errors.New("failed")
+	err = InTx(txn, change.CodebaseBranch, Name("app", "master"), "schema", func() error {
+		return failed
+	})
+	assert.Equal(t, failed, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInTx_CommitsUnchangedEntity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`from "schema".codebase_branch cb`).WillReturnRows(branchRow("active"))
+	mock.ExpectQuery(`from "schema".codebase_branch cb`).WillReturnRows(branchRow("active"))
+	mock.ExpectCommit()
+
+	txn, err := db.Begin()
+	assert.NoError(t, err)
+
+	err = InTx(txn, change.CodebaseBranch, Name("app", "master"), "schema", func() error { return nil })
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordAs_Moved(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`from "schema".codebase_branch cb`).WithArgs("old", "master").
+		WillReturnRows(branchRow("active"))
+	mock.ExpectQuery(`from "schema".codebase_branch cb`).WithArgs("app", "master").
+		WillReturnRows(branchRow("active"))
+	mock.ExpectExec(`pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare(`insert into "schema".change_event`).ExpectQuery().
+		WithArgs(change.CodebaseBranch, "app/master", change.Update, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(8, time.Now()))
+	mock.ExpectExec(`pg_notify`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	txn, err := db.Begin()
+	assert.NoError(t, err)
+
+	tracker, err := Track(txn, change.CodebaseBranch, Name("old", "master"), "schema")
+	assert.NoError(t, err)
+
+	e, err := tracker.RecordAs(txn, Name("app", "master"))
+	assert.NoError(t, err)
+	assert.Equal(t, change.Update, e.Op)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecord_DeleteNotifiesIdOfDeletedEntity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`from "schema".codebase_branch cb`).WillReturnRows(branchRow("active"))
+	mock.ExpectQuery(`from "schema".codebase_branch cb`).WillReturnRows(sqlmock.NewRows(branchColumns))
+	mock.ExpectExec(`pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare(`insert into "schema".change_event`).ExpectQuery().
+		WithArgs(change.CodebaseBranch, "app/master", change.Delete, sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(9, time.Now()))
+	mock.ExpectExec(`pg_notify`).
+		WithArgs("reconciler_schema", `{"kind":"codebase_branch","id":3,"name":"app/master","op":"delete"}`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	txn, err := db.Begin()
+	assert.NoError(t, err)
+
+	tracker, err := Track(txn, change.CodebaseBranch, Name("app", "master"), "schema")
+	assert.NoError(t, err)
+
+	e, err := tracker.Record(txn)
+	assert.NoError(t, err)
+	assert.Equal(t, change.Delete, e.Op)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBus_DropsSlowSubscriber(t *testing.T) {
+	bus := NewBus()
+	fast, cancelFast := bus.Subscribe(2)
+	defer cancelFast()
+	slow, cancelSlow := bus.Subscribe(1)
+
+	bus.Publish(change.Event{Id: 1}, change.Event{Id: 2})
+
+	assert.Equal(t, int64(1), (<-fast).Id)
+	assert.Equal(t, int64(2), (<-fast).Id)
+	assert.Equal(t, int64(1), (<-slow).Id)
+	_, ok := <-slow
+	assert.False(t, ok)
+
+	cancelSlow()
+}
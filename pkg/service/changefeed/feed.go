@@ -0,0 +1,178 @@
+package changefeed
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/epam/edp-reconciler/v2/pkg/db/migration"
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+	"github.com/epam/edp-reconciler/v2/pkg/repository"
+)
+
+// ErrTenantNotFound is returned when the change feed of unknown tenant is requested
+var ErrTenantNotFound = repository.ErrTenantNotFound
+
+// ErrCursorPruned is returned when events following the cursor have been pruned by the retention policy
+var ErrCursorPruned = errors.New("events following the cursor have been pruned")
+
+const (
+	batchSize        = 500
+	subscriberBuffer = 256
+	// pollInterval bounds delay of changes made by other replicas, which aren't published to the local bus
+	pollInterval = 5 * time.Second
+)
+
+// Feed reads the tenant change feed from the DB and follows it through the bus
+type Feed struct {
+	DB  *sql.DB
+	Bus *Bus
+}
+
+// Follow sends events of the tenant following the cursor to send until ctx is done or send fails.
+// Events already stored are replayed first, then new ones are sent as they are published.
+// Zero cursor starts from the oldest retained event, ErrCursorPruned is returned if events following
+// other cursor have been pruned.
+func (f Feed) Follow(ctx context.Context, tenant string, cursor int64, send func(change.Event) error) error {
+	if cursor > 0 {
+		if err := f.checkRetained(ctx, tenant, cursor); err != nil {
+			return err
+		}
+	}
+
+	for {
+		events, cancel := f.Bus.Subscribe(subscriberBuffer)
+		last, err := f.replay(ctx, tenant, cursor, send)
+		if err != nil {
+			cancel()
+			return err
+		}
+		cursor = last
+
+		err = f.wait(ctx, tenant, events)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// wait blocks until an event of the tenant is published, the subscription is dropped or poll interval passes.
+// Events are only used as a wake-up, they are read back from the DB in order of ids afterwards.
+func (f Feed) wait(ctx context.Context, tenant string, events <-chan change.Event) error {
+	poll := time.NewTimer(pollInterval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-poll.C:
+			return nil
+		case e, ok := <-events:
+			if !ok || e.Tenant == tenant {
+				return nil
+			}
+		}
+	}
+}
+
+func (f Feed) replay(ctx context.Context, tenant string, cursor int64, send func(change.Event) error) (int64, error) {
+	for {
+		events, err := f.Events(ctx, tenant, cursor, batchSize)
+		if err != nil {
+			return cursor, err
+		}
+		for _, e := range events {
+			if err := send(e); err != nil {
+				return cursor, err
+			}
+			cursor = e.Id
+		}
+		if len(events) < batchSize {
+			return cursor, nil
+		}
+	}
+}
+
+func (f Feed) checkRetained(ctx context.Context, tenant string, cursor int64) error {
+	return f.inTenant(ctx, tenant, true, func(txn *sql.Tx) error {
+		pruned, err := repository.GetLastPrunedChangeEventId(txn, tenant)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't read retention of %v tenant change feed", tenant)
+		}
+		if cursor < pruned {
+			return errors.Wrapf(ErrCursorPruned, "cursor %v, events up to %v are pruned", cursor, pruned)
+		}
+		return nil
+	})
+}
+
+// Events returns events of the tenant following the cursor
+func (f Feed) Events(ctx context.Context, tenant string, cursor int64, limit int) ([]change.Event, error) {
+	var events []change.Event
+	err := f.inTenant(ctx, tenant, true, func(txn *sql.Tx) error {
+		var err error
+		events, err = repository.GetChangeEvents(txn, cursor, limit, tenant)
+		return errors.Wrapf(err, "couldn't read change feed of %v tenant", tenant)
+	})
+	return events, err
+}
+
+// Cursor returns id of the last event acknowledged by the subscriber of the owner, zero if there's no one
+func (f Feed) Cursor(ctx context.Context, tenant, owner, subscriber string) (int64, error) {
+	var cursor int64
+	err := f.inTenant(ctx, tenant, true, func(txn *sql.Tx) error {
+		id, err := repository.GetChangeFeedCursor(txn, subscriber, owner, tenant)
+		if err != nil || id == nil {
+			return err
+		}
+		cursor = *id
+		return nil
+	})
+	return cursor, err
+}
+
+// Ack stores id of the last event processed by the subscriber, so it could resume from it after reconnect.
+// Subscriber belongs to the owner which has acknowledged it first, others get ErrChangeFeedCursorTaken.
+func (f Feed) Ack(ctx context.Context, tenant, owner, subscriber string, eventId int64) error {
+	return f.inTenant(ctx, tenant, false, func(txn *sql.Tx) error {
+		err := repository.PutChangeFeedCursor(txn, subscriber, owner, eventId, tenant)
+		return errors.Wrapf(err, "couldn't store cursor of %v subscriber", subscriber)
+	})
+}
+
+// inTenant runs f in transaction which is committed if f succeeds. Tenant name comes from clients,
+// so the schema is checked to exist before it's migrated.
+func (f Feed) inTenant(ctx context.Context, tenant string, readOnly bool, fn func(txn *sql.Tx) error) error {
+	if err := f.checkTenant(ctx, tenant); err != nil {
+		return err
+	}
+	if err := migration.Ensure(f.DB, tenant); err != nil {
+		return err
+	}
+
+	txn, err := f.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: readOnly})
+	if err != nil {
+		return err
+	}
+	if err := fn(txn); err != nil {
+		_ = txn.Rollback()
+		return err
+	}
+	return txn.Commit()
+}
+
+func (f Feed) checkTenant(ctx context.Context, tenant string) error {
+	txn, err := f.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	return repository.CheckTenant(txn, tenant)
+}
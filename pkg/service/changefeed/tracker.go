@@ -0,0 +1,209 @@
+// Package changefeed records modifications of entities into the tenant change feed and publishes them to subscribers
+package changefeed
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+	"github.com/epam/edp-reconciler/v2/pkg/repository"
+)
+
+// snapshot returns id and read model of the entity, the model is nil if the entity doesn't exist
+type snapshot func(txn *sql.Tx, name, schema string) (int, interface{}, error)
+
+var snapshots = map[change.Kind]snapshot{
+	change.Codebase: func(txn *sql.Tx, name, schema string) (int, interface{}, error) {
+		c, err := repository.GetCodebaseView(txn, name, schema)
+		if err != nil || c == nil {
+			return 0, nil, err
+		}
+		return c.Id, c, nil
+	},
+	change.CodebaseBranch: func(txn *sql.Tx, name, schema string) (int, interface{}, error) {
+		codebase, branch := splitName(name)
+		b, err := repository.GetCodebaseBranchView(txn, codebase, branch, schema)
+		if err != nil || b == nil {
+			return 0, nil, err
+		}
+		return b.Id, b, nil
+	},
+	change.CDPipeline: func(txn *sql.Tx, name, schema string) (int, interface{}, error) {
+		p, err := repository.GetCDPipelineView(txn, name, schema)
+		if err != nil || p == nil {
+			return 0, nil, err
+		}
+		return p.Id, p, nil
+	},
+	change.CDStage: func(txn *sql.Tx, name, schema string) (int, interface{}, error) {
+		pipeline, stage := splitName(name)
+		s, err := repository.GetStageView(txn, pipeline, stage, schema)
+		if err != nil || s == nil {
+			return 0, nil, err
+		}
+		return s.Id, s, nil
+	},
+}
+
+// Name returns name of the nested entity as it's recorded in the change feed, e.g. codebase/branch
+func Name(parent, name string) string {
+	return parent + "/" + name
+}
+
+func splitName(name string) (string, string) {
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) != 2 {
+		return name, ""
+	}
+	return parts[0], parts[1]
+}
+
+// Tracker captures state of the entity before it's modified within transaction
+// and records the change once the modification is done.
+type Tracker struct {
+	kind   change.Kind
+	name   string
+	schema string
+	id     int
+	before []byte
+}
+
+// Track takes snapshot of the entity before modification
+func Track(txn *sql.Tx, kind change.Kind, name, schema string) (*Tracker, error) {
+	id, before, err := takeSnapshot(txn, kind, name, schema)
+	if err != nil {
+		return nil, err
+	}
+	return &Tracker{kind: kind, name: name, schema: schema, id: id, before: before}, nil
+}
+
+// IsNew reports whether the entity didn't exist when tracking started
+func (t *Tracker) IsNew() bool {
+	return t.before == nil
+}
+
+// Record appends the change made since Track to the change feed and notifies listeners of the tenant channel
+// in the same transaction, so the change is neither visible nor notified until it's committed.
+// It returns nil if the entity hasn't been changed.
+func (t *Tracker) Record(txn *sql.Tx) (*change.Event, error) {
+	return t.RecordAs(txn, t.name)
+}
+
+// RecordAs records the change of the entity which has been renamed or moved since Track
+func (t *Tracker) RecordAs(txn *sql.Tx, name string) (*change.Event, error) {
+	id, after, err := takeSnapshot(txn, t.kind, name, t.schema)
+	if err != nil {
+		return nil, err
+	}
+	if after == nil {
+		id = t.id
+	}
+
+	e := &change.Event{
+		Tenant: t.schema,
+		Kind:   t.kind,
+		Name:   name,
+		Before: t.before,
+		After:  after,
+	}
+	switch {
+	case t.before == nil && after == nil:
+		return nil, nil
+	case t.before == nil:
+		e.Op = change.Create
+	case after == nil:
+		e.Op = change.Delete
+	case bytes.Equal(t.before, after) && t.name == name:
+		return nil, nil
+	default:
+		e.Op = change.Update
+	}
+
+	if err := repository.CreateChangeEvent(txn, e, t.schema); err != nil {
+		return nil, errors.Wrapf(err, "couldn't record %v %v change", t.kind, name)
+	}
+	if err := notify(txn, e, id); err != nil {
+		return nil, errors.Wrapf(err, "couldn't notify %v %v change", t.kind, name)
+	}
+	return e, nil
+}
+
+func notify(txn *sql.Tx, e *change.Event, id int) error {
+	payload, err := json.Marshal(change.Notification{
+		Kind: e.Kind,
+		Id:   id,
+		Name: e.Name,
+		Op:   e.Op,
+	})
+	if err != nil {
+		return err
+	}
+	return repository.NotifyChange(txn, change.Channel(e.Tenant), payload)
+}
+
+func takeSnapshot(txn *sql.Tx, kind change.Kind, name, schema string) (int, []byte, error) {
+	f, ok := snapshots[kind]
+	if !ok {
+		return 0, nil, fmt.Errorf("%v kind isn't tracked", kind)
+	}
+
+	id, v, err := f(txn, name, schema)
+	if err != nil {
+		return 0, nil, errors.Wrapf(err, "couldn't take snapshot of %v %v", kind, name)
+	}
+	if v == nil {
+		return 0, nil, nil
+	}
+	b, err := json.Marshal(v)
+	return id, b, err
+}
+
+// InTx tracks the entity, applies fn to it within the transaction and records the change. The transaction
+// is committed and the change is published once fn and recording succeed, otherwise it's rolled back.
+func InTx(txn *sql.Tx, kind change.Kind, name, schema string, fn func() error) error {
+	tracker, err := Track(txn, kind, name, schema)
+	if err != nil {
+		_ = txn.Rollback()
+		return err
+	}
+	return tracker.InTx(txn, name, fn)
+}
+
+// InTx does the same as InTx for the entity which has been tracked already,
+// the change is recorded under the given name, e.g. the one the entity has been moved to.
+func (t *Tracker) InTx(txn *sql.Tx, name string, fn func() error) error {
+	if err := fn(); err != nil {
+		_ = txn.Rollback()
+		return err
+	}
+
+	event, err := t.RecordAs(txn, name)
+	if err != nil {
+		_ = txn.Rollback()
+		return err
+	}
+
+	if err := txn.Commit(); err != nil {
+		return errors.Wrapf(err, "couldn't commit %v %v change", t.kind, name)
+	}
+	Publish(event)
+	return nil
+}
+
+// Publish delivers committed events to subscribers of the default bus, nil events are skipped
+func Publish(events ...*change.Event) {
+	var committed []change.Event
+	for _, e := range events {
+		if e != nil {
+			committed = append(committed, *e)
+		}
+	}
+	if len(committed) > 0 {
+		DefaultBus.Publish(committed...)
+	}
+}
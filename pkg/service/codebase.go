@@ -4,77 +4,103 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 
 	codeBaseApi "github.com/epam/edp-codebase-operator/v2/pkg/apis/edp/v1"
 	"github.com/pkg/errors"
 
+	"github.com/epam/edp-reconciler/v2/pkg/db/migration"
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
 	"github.com/epam/edp-reconciler/v2/pkg/model/codebase"
 	"github.com/epam/edp-reconciler/v2/pkg/repository"
 	codebaseperfdatasourceRepo "github.com/epam/edp-reconciler/v2/pkg/repository/codebaseperfdatasource"
 	"github.com/epam/edp-reconciler/v2/pkg/repository/jenkins-slave"
 	jiraserver "github.com/epam/edp-reconciler/v2/pkg/repository/jira-server"
 	jp "github.com/epam/edp-reconciler/v2/pkg/repository/job-provisioning"
-	"github.com/epam/edp-reconciler/v2/pkg/service/codebaseperfdatasource"
-	"github.com/epam/edp-reconciler/v2/pkg/service/perfdatasource"
-	"github.com/epam/edp-reconciler/v2/pkg/service/perfserver"
+	"github.com/epam/edp-reconciler/v2/pkg/repository/perfdatasource"
+	"github.com/epam/edp-reconciler/v2/pkg/repository/perfserver"
+	"github.com/epam/edp-reconciler/v2/pkg/service/changefeed"
 )
 
 type CodebaseService struct {
-	DB                *sql.DB
-	DataSourceService perfdatasource.PerfDataSourceService
-	PerfService       perfserver.PerfServerService
-	CodebaseDsService codebaseperfdatasource.CodebasePerfDataSourceService
+	DB *sql.DB
 }
 
 func (s CodebaseService) PutCodebase(c codebase.Codebase) error {
 	log.Printf("Start creation of business entity %v...", c)
+	if err := migration.Ensure(s.DB, c.Tenant); err != nil {
+		return err
+	}
+
 	log.Println("Start transaction...")
 	txn, err := s.DB.Begin()
 	if err != nil {
 		return errors.Wrapf(err, "an error has occurred during opening transaction: %v", c.Name)
 	}
 
-	id, err := s.putCodebase(txn, c, c.Tenant)
-	if err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred during get Codebase id or create: %v", c.Name)
-	}
-	log.Printf("Id of BE to be updated: %v", *id)
+	err = changefeed.InTx(txn, change.Codebase, c.Name, c.Tenant, func() error {
+		id, err := s.putCodebase(txn, c, c.Tenant)
+		if err != nil {
+			return errors.Wrapf(err, "an error has occurred during get Codebase id or create: %v", c.Name)
+		}
+		log.Printf("Id of BE to be updated: %v", *id)
 
-	log.Println("Start update status of codebase...")
-	codebaseActionId, err := repository.CreateActionLog(txn, c.ActionLog, c.Tenant)
-	if err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred during status creation: %v", c.Name)
-	}
-	log.Println("ActionLog has been saved into the repository")
+		if err := putCodebasePerfDataSources(txn, *id, c.Perf, c.Tenant); err != nil {
+			return errors.Wrapf(err, "couldn't sync perf data sources of codebase %v", c.Name)
+		}
 
-	log.Println("Start update codebase_action status of codebase...")
-	if err := repository.CreateCodebaseAction(txn, *id, *codebaseActionId, c.Tenant); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred during codebase_action creation: %v", c.Name)
-	}
-	log.Println("codebase_action has been updated")
+		log.Println("Start update status of codebase...")
+		if _, err := repository.CreateActionLogOnce(txn, repository.CodebaseActionLogLink, *id, c.ActionLog, c.Tenant); err != nil {
+			return errors.Wrapf(err, "an error has occurred during status creation: %v", c.Name)
+		}
+		log.Println("ActionLog has been saved into the repository")
 
-	if err := repository.UpdateStatusByCodebaseId(txn, *id, c.Status, c.Tenant); err != nil {
-		log.Printf("Error has occurred during the update of codebase: %v", err)
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred during the update of codebase: %v", c.Name)
-	}
-
-	if err := txn.Commit(); err != nil {
-		return errors.Wrapf(err, "An error has occurred while ending transaction: %v", c.Name)
+		if err := repository.UpdateStatusByCodebaseId(txn, *id, c.Status, c.Tenant); err != nil {
+			log.Printf("Error has occurred during the update of codebase: %v", err)
+			return errors.Wrapf(err, "an error has occurred during the update of codebase: %v", c.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 	log.Printf("Codebase %v has been saved successfully", c.Name)
+	return nil
+}
 
-	if err := s.DataSourceService.InsertPerfDataSources(c.Perf, c.Tenant); err != nil {
-		return errors.Wrap(err, "an error has occurred during filling perf data source table")
+// putCodebasePerfDataSources makes data sources linked on behalf of the codebase spec match the spec.
+// Links of data sources removed from the spec are released, so the ones still required by data source CRs are kept.
+func putCodebasePerfDataSources(txn *sql.Tx, codebaseId int, perf *codebase.Perf, schema string) error {
+	desired := map[int]bool{}
+	if perf != nil {
+		for _, ds := range perf.DataSources {
+			dsId, err := perfdatasource.PutPerfDataSource(txn, strings.ToUpper(ds), schema)
+			if err != nil {
+				return errors.Wrapf(err, "couldn't put %v data source", ds)
+			}
+
+			if err := codebaseperfdatasourceRepo.PutCodebasePerfDataSource(txn, codebaseId, *dsId,
+				codebaseperfdatasourceRepo.SpecOrigin, schema); err != nil {
+				return errors.Wrapf(err, "couldn't link %v data source", ds)
+			}
+			desired[*dsId] = true
+		}
+	}
+
+	linked, err := codebaseperfdatasourceRepo.GetCodebasePerfDataSourceIds(txn, codebaseId,
+		codebaseperfdatasourceRepo.SpecOrigin, schema)
+	if err != nil {
+		return errors.Wrap(err, "couldn't get linked data sources")
 	}
 
-	if err := s.CodebaseDsService.InsertCodebasePerfDataSources(*id, c.Perf, c.Tenant); err != nil {
-		return errors.Wrapf(err, "couldn't create CodebasePerfDataSource record. codebase id %v", id)
+	var stale []int
+	for _, dsId := range linked {
+		if !desired[dsId] {
+			stale = append(stale, dsId)
+		}
 	}
-	return nil
+	return codebaseperfdatasourceRepo.ReleaseCodebasePerfDataSources(txn, codebaseId, stale,
+		codebaseperfdatasourceRepo.SpecOrigin, schema)
 }
 
 func (s CodebaseService) putCodebase(txn *sql.Tx, c codebase.Codebase, schema string) (*int, error) {
@@ -113,6 +139,10 @@ func updateCodebase(txn *sql.Tx, c codebase.Codebase, schema string) error {
 		return err
 	}
 
+	if err := setPerfServerId(txn, &c, schema); err != nil {
+		return err
+	}
+
 	if err := repository.Update(txn, c, schema); err != nil {
 		return errors.Wrapf(err, "couldn't update codebase %v", c.Name)
 	}
@@ -192,8 +222,8 @@ func (s CodebaseService) createBE(txn *sql.Tx, c codebase.Codebase, schema strin
 		return nil, err
 	}
 
-	if err := s.setPerfServerIdToCodebaseDto(c.Perf, schema); err != nil {
-		return nil, errors.Wrapf(err, "couldn't set %v perf server id", c.Perf.Name)
+	if err := setPerfServerId(txn, &c, schema); err != nil {
+		return nil, err
 	}
 
 	id, err = repository.CreateCodebase(txn, c, schema)
@@ -205,20 +235,21 @@ func (s CodebaseService) createBE(txn *sql.Tx, c codebase.Codebase, schema strin
 	return id, nil
 }
 
-func (s CodebaseService) setPerfServerIdToCodebaseDto(perf *codebase.Perf, tenant string) error {
-	if perf == nil {
+// setPerfServerId resolves perf server of the codebase, perf_server_id is reset when perf is disabled
+func setPerfServerId(txn *sql.Tx, c *codebase.Codebase, schema string) error {
+	if c.Perf == nil {
 		return nil
 	}
 
-	id, err := s.PerfService.GetPerfServerId(perf.Name, tenant)
+	id, err := perfserver.SelectPerfServer(txn, c.Perf.Name, schema)
 	if err != nil {
-		return err
+		return errors.Wrapf(err, "couldn't get %v perf server id", c.Perf.Name)
 	}
 
 	if id == nil {
-		return fmt.Errorf("%v perf server record doesn't exist", perf.Name)
+		return fmt.Errorf("%v perf server record doesn't exist", c.Perf.Name)
 	}
-	perf.Id = id
+	c.Perf.Id = id
 	return nil
 }
 
@@ -248,23 +279,27 @@ func getJiraServerId(txn *sql.Tx, name *string, schemaName string) (*int, error)
 
 func (s CodebaseService) Delete(perf *codeBaseApi.Perf, name, schema string) error {
 	log.Printf("start deleting %v codebase", name)
+	if err := migration.Ensure(s.DB, schema); err != nil {
+		return err
+	}
+
 	txn, err := s.DB.Begin()
 	if err != nil {
 		return errors.Wrapf(err, "couldn't open transaction while deleting codebase %v", name)
 	}
 
-	if err := deleteCodebasePerfDataSourceRecord(txn, perf, name, schema); err != nil {
+	err = changefeed.InTx(txn, change.Codebase, name, schema, func() error {
+		if err := deleteCodebasePerfDataSourceRecord(txn, perf, name, schema); err != nil {
+			return err
+		}
+		if err := repository.Delete(txn, name, schema); err != nil {
+			return errors.Wrapf(err, "couldn't delete codebase %v", name)
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
-
-	if err := repository.Delete(txn, name, schema); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "couldn't delete codebase %v", name)
-	}
-
-	if err := txn.Commit(); err != nil {
-		return errors.Wrapf(err, "couldn't close transaction while deleting codebase %v", name)
-	}
 	log.Printf("end deleting %v codebase", name)
 	return nil
 }
@@ -317,3 +317,51 @@ func TestSetGitServerId_GitServerShouldNotBeFound(t *testing.T) {
 	err = setGitServerId(tx, c, schema)
 	assert.Error(t, err)
 }
+
+func TestPutCodebasePerfDataSources_ReleasesDataSourcesRemovedFromSpec(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select exists\(select 1 from "public".perf_data_sources`).ExpectQuery().WithArgs("SONAR").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectPrepare(`select id from "public".perf_data_sources`).ExpectQuery().WithArgs("SONAR").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`update "public".codebase_perf_data_sources set spec_managed = true`).WithArgs(7, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`select data_source_id from "public".codebase_perf_data_sources where codebase_id=\$1 and spec_managed`).
+		WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"data_source_id"}).AddRow(1).AddRow(2))
+	mock.ExpectExec(`update "public".codebase_perf_data_sources set spec_managed = false`).
+		WithArgs(7, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`delete from "public".codebase_perf_data_sources`).WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	err = putCodebasePerfDataSources(tx, 7, &codebase.Perf{Name: "perf", DataSources: []string{"sonar"}}, "public")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPutCodebasePerfDataSources_PerfDisabled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select data_source_id from "public".codebase_perf_data_sources`).
+		WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"data_source_id"}).AddRow(2))
+	mock.ExpectExec(`update "public".codebase_perf_data_sources set spec_managed = false`).
+		WithArgs(7, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`delete from "public".codebase_perf_data_sources`).WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	err = putCodebasePerfDataSources(tx, 7, nil, "public")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
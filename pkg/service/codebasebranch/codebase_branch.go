@@ -3,10 +3,14 @@ package codebasebranch
 import (
 	"database/sql"
 	"fmt"
+	"github.com/epam/edp-reconciler/v2/pkg/db/migration"
+	"github.com/epam/edp-reconciler/v2/pkg/model"
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
 	"github.com/epam/edp-reconciler/v2/pkg/model/codebase"
 	"github.com/epam/edp-reconciler/v2/pkg/model/codebasebranch"
 	"github.com/epam/edp-reconciler/v2/pkg/repository"
 	cbs "github.com/epam/edp-reconciler/v2/pkg/repository/codebasebranch"
+	"github.com/epam/edp-reconciler/v2/pkg/service/changefeed"
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
@@ -19,58 +23,67 @@ type CodebaseBranchService struct {
 
 func (s CodebaseBranchService) PutCodebaseBranch(codebaseBranch codebasebranch.CodebaseBranch) error {
 	log.V(2).Info("start creation of codebase branch", "name", codebaseBranch.Name)
+	if err := migration.Ensure(s.DB, codebaseBranch.Tenant); err != nil {
+		return err
+	}
+
 	txn, err := s.DB.Begin()
 	if err != nil {
 		return errors.Wrap(err, "an error has occurred while opening transaction")
 	}
 	schemaName := codebaseBranch.Tenant
 
-	id, err := getCodebaseBranchIdOrCreate(txn, codebaseBranch, schemaName)
-	if err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred while getting Codebase Branch id or create %v",
-			"branch %v")
-	}
-
-	if err := updateCodebaseBranch(txn, codebaseBranch, *id, schemaName); err != nil {
-		_ = txn.Rollback()
-		return errors.New(fmt.Sprintf("cannot insert codebaseBranch update %v", codebaseBranch))
-	}
-	log.V(2).Info("CodebaseBranch has been updated", "name", codebaseBranch.Name)
-
-	log.V(2).Info("start update status of codebase branch...")
-	actionLogId, err := repository.CreateActionLog(txn, codebaseBranch.ActionLog, schemaName)
+	tracker, err := trackCodebaseBranch(txn, codebaseBranch, schemaName)
 	if err != nil {
 		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred during status creation %v", "name %v")
+		return err
 	}
-	log.V(2).Info("ActionLog has been saved into the repository")
 
-	log.V(2).Info("Start update codebase_branch_action status of code branch entity...")
-	cbId, err := repository.GetCodebaseId(txn, codebaseBranch.AppName, schemaName)
-	if err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred during retrieving codebase id %v", "id %v")
-	}
+	err = tracker.InTx(txn, changefeed.Name(codebaseBranch.AppName, codebaseBranch.Name), func() error {
+		if err := moveCodebaseBranchIfReparented(txn, codebaseBranch, schemaName); err != nil {
+			return errors.Wrapf(err, "an error has occurred while moving branch %v to %v codebase",
+				codebaseBranch.Name, codebaseBranch.AppName)
+		}
 
-	if err := repository.CreateCodebaseAction(txn, *cbId, *actionLogId, schemaName); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrap(err, "an error has occurred during codebase_branch_action")
-	}
-	log.V(2).Info("codebase_action has been updated")
+		id, err := getCodebaseBranchIdOrCreate(txn, codebaseBranch, schemaName)
+		if err != nil {
+			return errors.Wrapf(err, "an error has occurred while getting Codebase Branch id or create %v",
+				"branch %v")
+		}
 
-	if err := cbs.UpdateStatusByCodebaseBranchId(txn, *id, codebaseBranch.Status, codebaseBranch.Tenant); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "an error has occurred during the update of codebase branch %v", codebaseBranch.Name)
-	}
+		if err := updateCodebaseBranch(txn, codebaseBranch, *id, schemaName); err != nil {
+			return errors.Wrapf(err, "cannot update codebase branch %v", codebaseBranch.Name)
+		}
+		log.V(2).Info("CodebaseBranch has been updated", "name", codebaseBranch.Name)
 
-	if err := txn.Commit(); err != nil {
+		log.V(2).Info("start update status of codebase branch...")
+		if _, err := repository.CreateActionLogOnce(txn, repository.CodebaseBranchActionLogLink, *id,
+			codebaseBranch.ActionLog, schemaName); err != nil {
+			return errors.Wrapf(err, "an error has occurred during status creation of branch %v", codebaseBranch.Name)
+		}
+		log.V(2).Info("ActionLog has been saved into the repository")
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 	log.Info("Codebase Branch has been saved successfully", "name", codebaseBranch.Name)
 	return nil
 }
 
+// trackCodebaseBranch takes snapshot of the branch before it's saved. Branch which is being moved
+// to another codebase is looked up under the previous codebase unless it has been moved already.
+func trackCodebaseBranch(txn *sql.Tx, codebaseBranch codebasebranch.CodebaseBranch, schemaName string) (*changefeed.Tracker, error) {
+	tracker, err := changefeed.Track(txn, change.CodebaseBranch,
+		changefeed.Name(codebaseBranch.AppName, codebaseBranch.Name), schemaName)
+	if err != nil || !tracker.IsNew() ||
+		codebaseBranch.PreviousAppName == "" || codebaseBranch.PreviousAppName == codebaseBranch.AppName {
+		return tracker, err
+	}
+	return changefeed.Track(txn, change.CodebaseBranch,
+		changefeed.Name(codebaseBranch.PreviousAppName, codebaseBranch.Name), schemaName)
+}
+
 func createCodebaseBranch(txn *sql.Tx, codebaseBranch codebasebranch.CodebaseBranch, schemaName string) (*int, error) {
 	log.V(2).Info("start codebase_branch insertion", "name", codebaseBranch.Name)
 	var streamId *int = nil
@@ -127,24 +140,107 @@ func getCodebaseBranchIdOrCreate(txn *sql.Tx, codebaseBranch codebasebranch.Code
 
 func updateCodebaseBranch(txn *sql.Tx, codebaseBranch codebasebranch.CodebaseBranch, id int, schemaName string) error {
 	log.V(2).Info("start updating CodebaseBranch by id", "id", id)
-	err := cbs.UpdateCodebaseBranch(txn, id, codebaseBranch.Version, codebaseBranch.BuildNumber,
-		codebaseBranch.LastSuccessBuild, schemaName)
+	err := cbs.UpdateCodebaseBranch(txn, id, codebaseBranch.FromCommit, codebaseBranch.Status, codebaseBranch.Version,
+		codebaseBranch.BuildNumber, codebaseBranch.LastSuccessBuild, codebaseBranch.Release, schemaName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// moveCodebaseBranchIfReparented moves the branch row synced under previous codebase to the current one.
+// Output docker stream of the branch keeps its name, since CD pipelines reference it as an input by name,
+// and is created only if the new codebase is an application and the branch didn't have a stream yet.
+func moveCodebaseBranchIfReparented(txn *sql.Tx, codebaseBranch codebasebranch.CodebaseBranch, schemaName string) error {
+	if codebaseBranch.PreviousAppName == "" || codebaseBranch.PreviousAppName == codebaseBranch.AppName {
+		return nil
+	}
+
+	oldId, err := cbs.GetCodebaseBranchId(txn, codebaseBranch.PreviousAppName, codebaseBranch.Name, schemaName)
+	if err != nil {
+		return err
+	}
+	if oldId == nil {
+		log.V(2).Info("branch hasn't been synced under previous codebase. Nothing to move",
+			"codebase", codebaseBranch.PreviousAppName, "branch", codebaseBranch.Name)
+		return nil
+	}
+
+	newId, err := cbs.GetCodebaseBranchId(txn, codebaseBranch.AppName, codebaseBranch.Name, schemaName)
+	if err != nil {
+		return err
+	}
+	if newId != nil {
+		return fmt.Errorf("branch %v already exists in %v codebase", codebaseBranch.Name, codebaseBranch.AppName)
+	}
+
+	codebaseId, err := repository.GetCodebaseId(txn, codebaseBranch.AppName, schemaName)
 	if err != nil {
 		return err
 	}
+	if codebaseId == nil {
+		return fmt.Errorf("%v codebase record has not been found", codebaseBranch.AppName)
+	}
+
+	branch, err := cbs.GetCodebaseBranchById(txn, *oldId, schemaName)
+	if err != nil {
+		return err
+	}
+
+	streamId, err := moveCodebaseBranchDockerStream(txn, codebaseBranch, *branch, *codebaseId, schemaName)
+	if err != nil {
+		return err
+	}
+
+	if err := cbs.UpdateCodebaseBranchCodebase(txn, *oldId, *codebaseId, streamId, schemaName); err != nil {
+		return err
+	}
+	log.Info("codebase branch has been moved", "branch", codebaseBranch.Name,
+		"from", codebaseBranch.PreviousAppName, "to", codebaseBranch.AppName)
 	return nil
 }
 
+func moveCodebaseBranchDockerStream(txn *sql.Tx, codebaseBranch codebasebranch.CodebaseBranch,
+	branch model.CodebaseBranchReadDTO, codebaseId int, schemaName string) (*int, error) {
+	if branch.OutputStreamId != nil {
+		log.V(2).Info("codebase docker stream keeps pointing to the moved branch", "id", *branch.OutputStreamId)
+		return branch.OutputStreamId, nil
+	}
+
+	cbType, err := repository.GetCodebaseTypeById(txn, codebaseId, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	if *cbType != string(codebase.Application) {
+		return nil, nil
+	}
+
+	ocImageStreamName := fmt.Sprintf("%v-%v", codebaseBranch.AppName, codebaseBranch.Name)
+	streamId, err := repository.CreateCodebaseDockerStream(txn, schemaName, &branch.Id, ocImageStreamName)
+	if err != nil {
+		return nil, err
+	}
+	log.V(2).Info("codebase docker stream has been created", "id", streamId)
+	return streamId, nil
+}
+
 func (s *CodebaseBranchService) Delete(codebase, branch, schema string) error {
 	log.V(2).Info("start deleting codebase branch", "codebase", codebase, "branch", branch)
+	if err := migration.Ensure(s.DB, schema); err != nil {
+		return err
+	}
+
 	txn, err := s.DB.Begin()
 	if err != nil {
 		return err
 	}
-	if err := cbs.Delete(txn, codebase, branch, schema); err != nil {
-		return errors.Wrapf(err, "couldn't delete %v codebase branch", codebase)
-	}
-	if err := txn.Commit(); err != nil {
+	err = changefeed.InTx(txn, change.CodebaseBranch, changefeed.Name(codebase, branch), schema, func() error {
+		if err := cbs.Delete(txn, codebase, branch, schema); err != nil {
+			return errors.Wrapf(err, "couldn't delete %v codebase branch", codebase)
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 	log.Info("codebase branch has been deleted", "codebase", codebase, "branch", branch)
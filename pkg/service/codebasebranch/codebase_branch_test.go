@@ -0,0 +1,54 @@
+package codebasebranch
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/codebasebranch"
+)
+
+func TestMoveCodebaseBranchIfReparented_SameCodebaseShouldDoNothing(t *testing.T) {
+	err := moveCodebaseBranchIfReparented(nil, codebasebranch.CodebaseBranch{
+		Name:            "master",
+		AppName:         "app",
+		PreviousAppName: "app",
+	}, "schema")
+
+	assert.NoError(t, err)
+}
+
+func TestMoveCodebaseBranchIfReparented_BranchShouldBeMovedWithStreamName(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select cb.id as codebase_branch_id`).ExpectQuery().WithArgs("master", "old-app").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectPrepare(`select cb.id as codebase_branch_id`).ExpectQuery().WithArgs("master", "new-app").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectPrepare(`select id from "schema".codebase`).ExpectQuery().WithArgs("new-app").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectPrepare(`select cb.id, cb.codebase_id`).ExpectQuery().WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "codebase_id", "stream_id"}).AddRow(7, 1, 9))
+	mock.ExpectPrepare(`update "schema".codebase_branch set codebase_id`).ExpectExec().WithArgs(2, 9, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = moveCodebaseBranchIfReparented(tx, codebasebranch.CodebaseBranch{
+		Name:            "master",
+		AppName:         "new-app",
+		PreviousAppName: "old-app",
+	}, "schema")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
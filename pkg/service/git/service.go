@@ -3,10 +3,13 @@ package git
 import (
 	"database/sql"
 	"fmt"
-	"github.com/epam/edp-reconciler/v2/pkg/model/gitserver"
-	"github.com/epam/edp-reconciler/v2/pkg/repository"
+
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/epam/edp-reconciler/v2/pkg/db/migration"
+	"github.com/epam/edp-reconciler/v2/pkg/model/gitserver"
+	"github.com/epam/edp-reconciler/v2/pkg/repository"
 )
 
 var log = ctrl.Log.WithName("git-server-service")
@@ -16,10 +19,14 @@ type GitServerService struct {
 }
 
 // PutGitServer creates record in persistent storage, if corresponding git server does not exist already or updates
-// existing record
+// existing record, and adds the git server status to its action log history
 func (s GitServerService) PutGitServer(gitServer gitserver.GitServer) error {
 	log.Info("Start PutGitServer method", "Git host", gitServer.GitHost)
 
+	if err := migration.Ensure(s.DB, gitServer.Tenant); err != nil {
+		return err
+	}
+
 	txn, err := s.DB.Begin()
 	if err != nil {
 		return err
@@ -31,10 +38,11 @@ func (s GitServerService) PutGitServer(gitServer gitserver.GitServer) error {
 		return errors.Wrap(err, fmt.Sprintf("an error has occurred while fetching Git Server Record %v", gitServer.Name))
 	}
 
+	available := gitServer.ActionLog.Result == "success"
 	if id != nil {
 		log.Info("Start updating Git Server", "record", gitServer.Name)
 
-		err = repository.UpdateGitServer(txn, id, gitServer.ActionLog.Result == "success", gitServer.Tenant)
+		err = repository.UpdateGitServer(txn, *id, gitServer, available)
 		if err != nil {
 			_ = txn.Rollback()
 			return errors.Wrap(err, fmt.Sprintf("an error has occurred while updating Git Server Record %v", gitServer.Name))
@@ -42,13 +50,21 @@ func (s GitServerService) PutGitServer(gitServer gitserver.GitServer) error {
 	} else {
 		log.Info("Start creating Git Server", "record", gitServer.Name)
 
-		_, err = repository.CreateGitServer(txn, gitServer.Name, gitServer.GitHost, gitServer.ActionLog.Result == "success", gitServer.Tenant)
+		id, err = repository.CreateGitServer(txn, gitServer, available)
 		if err != nil {
 			_ = txn.Rollback()
 			return errors.Wrap(err, fmt.Sprintf("an error has occurred while creating Git Server Record %v", gitServer.GitHost))
 		}
 	}
 
+	if gitServer.ActionLog.Action != "" {
+		if _, err := repository.CreateActionLogOnce(txn, repository.GitServerActionLogLink, *id, gitServer.ActionLog,
+			gitServer.Tenant); err != nil {
+			_ = txn.Rollback()
+			return errors.Wrapf(err, "an error has occurred while saving status of Git Server %v", gitServer.Name)
+		}
+	}
+
 	err = txn.Commit()
 	if err != nil {
 		return err
@@ -58,3 +74,23 @@ func (s GitServerService) PutGitServer(gitServer gitserver.GitServer) error {
 
 	return nil
 }
+
+// DeleteGitServer removes the git_server record, see repository.DeleteServer
+func (s GitServerService) DeleteGitServer(name, tenant string, detach bool) error {
+	log.Info("start deleting GitServer", "name", name)
+	txn, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := repository.DeleteServer(txn, repository.GitServerReference, name, detach, tenant); err != nil {
+		_ = txn.Rollback()
+		return err
+	}
+
+	if err := txn.Commit(); err != nil {
+		return err
+	}
+	log.Info("GitServer has been deleted", "name", name)
+	return nil
+}
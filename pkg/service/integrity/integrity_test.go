@@ -0,0 +1,157 @@
+package integrity
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectChecks expects queries of all checks for the schema with a single pipeline of two stages,
+// the second stage takes the promoted app from the original stream instead of the first stage output
+func expectChecks(mock sqlmock.Sqlmock, schema string) {
+	mock.ExpectQuery(`from "` + schema + `".codebase_branch cb`).
+		WillReturnRows(sqlmock.NewRows([]string{"codebase", "branch", "branch_id", "stream_id", "stream_branch_id", "streams"}).
+			AddRow("app", "master", 1, nil, nil, 0).
+			AddRow("app", "feature", 2, 10, 3, 1))
+	mock.ExpectQuery(`select name from "` + schema + `".cd_pipeline`).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("pipe"))
+	mock.ExpectQuery(`from "` + schema + `".applications_to_promote atp`).WithArgs("pipe").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("app"))
+	mock.ExpectPrepare(`from "` + schema + `".cd_pipeline cp`).ExpectQuery().WithArgs("pipe").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "status", "trigger_type", "description", "order"}).
+			AddRow(5, "qa", "created", "manual", "", 0).
+			AddRow(6, "sit", "created", "manual", "", 1))
+	mock.ExpectPrepare(`from "` + schema + `".stage_codebase_docker_stream scds`).ExpectQuery().WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"input", "output", "id", "name"}).AddRow(20, 21, 1, "app"))
+	mock.ExpectPrepare(`select cds.id`).ExpectQuery().WithArgs("pipe", "app").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(20))
+	mock.ExpectPrepare(`from "` + schema + `".stage_codebase_docker_stream scds`).ExpectQuery().WithArgs(6).
+		WillReturnRows(sqlmock.NewRows([]string{"input", "output", "id", "name"}).AddRow(20, 22, 1, "app"))
+	mock.ExpectQuery(`where not exists`).
+		WillReturnRows(sqlmock.NewRows([]string{"pipeline", "codebase"}))
+	mock.ExpectQuery(`having min`).
+		WillReturnRows(sqlmock.NewRows([]string{"pipeline", "orders"}).AddRow("pipe", "{0,1,3}"))
+}
+
+func expectSchema(mock sqlmock.Sqlmock, schema string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select exists\(select 1 from pg_namespace`).WithArgs(schema).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+}
+
+func TestVerify_ReportsViolationsWithFixes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	expectSchema(mock, "edp")
+	expectChecks(mock, "edp")
+	mock.ExpectRollback()
+
+	found, err := Verifier{DB: db}.Verify(context.Background(), "edp")
+	assert.NoError(t, err)
+	assert.Len(t, found, 4)
+
+	assert.Equal(t, Violation{
+		Check:   BranchDockerStream,
+		Entity:  "app/master",
+		Problem: "branch has no output docker stream",
+		Fix:     "resync the branch, so docker stream app-master is created",
+	}, found[0])
+	assert.Equal(t, "update \"edp\".codebase_docker_stream set codebase_branch_id = 2 where id = 10 ;", found[1].Fix)
+
+	assert.Equal(t, StageStreamChain, found[2].Check)
+	assert.Equal(t, "pipe/sit", found[2].Entity)
+	assert.Equal(t, "input docker stream of app codebase is 20, expected 21", found[2].Problem)
+
+	assert.Equal(t, StageOrder, found[3].Check)
+	assert.Equal(t, "stage orders are [0 1 3], expected contiguous from 0", found[3].Problem)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVerify_UnknownTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select exists\(select 1 from pg_namespace`).WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	_, err = Verifier{DB: db}.Verify(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrTenantNotFound)
+
+	_, err = Verifier{DB: db}.Verify(context.Background(), `bad"name`)
+	assert.ErrorIs(t, err, ErrTenantNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// migratedVersions returns rows of reconciler_migration of the schema with all migrations applied
+func migratedVersions(t *testing.T) *sqlmock.Rows {
+	entries, err := os.ReadDir("../../db/migration/sql")
+	assert.NoError(t, err)
+
+	rows := sqlmock.NewRows([]string{"version"})
+	for _, e := range entries {
+		rows.AddRow(e.Name())
+	}
+	return rows
+}
+
+func TestJob_ExportsViolationsPerCheck(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select table_schema from information_schema.tables`).
+		WillReturnRows(sqlmock.NewRows([]string{"table_schema"}).AddRow("tenant"))
+	mock.ExpectQuery(`select version from "tenant".reconciler_migration`).
+		WillReturnRows(migratedVersions(t))
+	mock.ExpectRollback()
+	expectSchema(mock, "tenant")
+	expectChecks(mock, "tenant")
+	mock.ExpectRollback()
+
+	assert.NoError(t, NewJob(db, 0).Run(context.Background()))
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(violations.WithLabelValues("tenant", string(BranchDockerStream))))
+	assert.Equal(t, float64(1), testutil.ToFloat64(violations.WithLabelValues("tenant", string(StageStreamChain))))
+	assert.Equal(t, float64(0), testutil.ToFloat64(violations.WithLabelValues("tenant", string(PromotedApplication))))
+	assert.Equal(t, float64(1), testutil.ToFloat64(violations.WithLabelValues("tenant", string(StageOrder))))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJob_ContinuesWithNextSchemaOnFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select table_schema from information_schema.tables`).
+		WillReturnRows(sqlmock.NewRows([]string{"table_schema"}).AddRow("broken").AddRow("tenant"))
+	mock.ExpectQuery(`select version from "broken".reconciler_migration`).WillReturnRows(migratedVersions(t))
+	mock.ExpectQuery(`select version from "tenant".reconciler_migration`).WillReturnRows(migratedVersions(t))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	// the schema is dropped after it's listed
+	mock.ExpectQuery(`select exists\(select 1 from pg_namespace`).WithArgs("broken").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectRollback()
+	expectSchema(mock, "tenant")
+	expectChecks(mock, "tenant")
+	mock.ExpectRollback()
+
+	err = NewJob(db, 0).Run(context.Background())
+	assert.EqualError(t, err, "couldn't verify broken schema: tenant broken: tenant not found")
+	assert.Equal(t, float64(1), testutil.ToFloat64(failures.WithLabelValues("broken")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(violations.WithLabelValues("tenant", string(StageOrder))))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,91 @@
+package integrity
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/epam/edp-reconciler/v2/pkg/db/migration"
+)
+
+var log = ctrl.Log.WithName("integrity-verifier")
+
+// Job periodically verifies all tenant schemas, logs violations and exports their number as metrics.
+// It implements manager.Runnable and runs on the leader only.
+type Job struct {
+	Verifier Verifier
+	Interval time.Duration
+}
+
+func NewJob(db *sql.DB, interval time.Duration) *Job {
+	return &Job{
+		Verifier: Verifier{DB: db},
+		Interval: interval,
+	}
+}
+
+func (j *Job) NeedLeaderElection() bool {
+	return true
+}
+
+func (j *Job) Start(ctx context.Context) error {
+	log.Info("starting integrity verifier", "interval", j.Interval.String())
+
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+	for {
+		if err := j.Run(ctx); err != nil {
+			log.Error(err, "couldn't verify integrity")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run verifies every tenant schema once. Failure of one schema doesn't stop the others,
+// errors of all failed schemas are returned together.
+func (j *Job) Run(ctx context.Context) error {
+	schemas, err := migration.Schemas(j.Verifier.DB)
+	if err != nil {
+		return errors.Wrap(err, "couldn't get tenant schemas")
+	}
+
+	var result error
+	for _, schema := range schemas {
+		if ctx.Err() != nil {
+			return result
+		}
+		if err := j.verify(ctx, schema); err != nil {
+			log.Error(err, "couldn't verify the schema", "schema", schema)
+			failures.WithLabelValues(schema).Inc()
+			result = multierr.Append(result, err)
+		}
+	}
+	return result
+}
+
+func (j *Job) verify(ctx context.Context, schema string) error {
+	found, err := j.Verifier.Verify(ctx, schema)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't verify %v schema", schema)
+	}
+
+	counts := make(map[Check]int, len(Checks))
+	for _, v := range found {
+		counts[v.Check]++
+		log.Info("integrity violation", "schema", schema, "check", v.Check, "entity", v.Entity,
+			"problem", v.Problem, "fix", v.Fix)
+	}
+	for _, c := range Checks {
+		violations.WithLabelValues(schema, string(c)).Set(float64(counts[c]))
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package integrity
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	violations = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "reconciler_integrity_violations",
+		Help: "Number of integrity violations found by the latest verification of the tenant schema",
+	}, []string{"schema", "check"})
+
+	failures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reconciler_integrity_verification_failures_total",
+		Help: "Number of failed verifications of the tenant schema",
+	}, []string{"schema"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(violations, failures)
+}
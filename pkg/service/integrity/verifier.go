@@ -0,0 +1,220 @@
+// Package integrity verifies invariants of the tenant data the reconciler services rely on
+package integrity
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+	"github.com/epam/edp-reconciler/v2/pkg/repository"
+	sr "github.com/epam/edp-reconciler/v2/pkg/repository/stage"
+	"github.com/epam/edp-reconciler/v2/pkg/service/changefeed"
+)
+
+// ErrTenantNotFound is returned when unknown tenant is verified
+var ErrTenantNotFound = repository.ErrTenantNotFound
+
+type Check string
+
+const (
+	// BranchDockerStream checks every application branch has exactly one docker stream pointing back to it
+	BranchDockerStream Check = "branch_docker_stream"
+	// StageStreamChain checks inputs of every stage are outputs of the previous stage for applications to promote
+	// and the original pipeline streams for the rest of applications
+	StageStreamChain Check = "stage_stream_chain"
+	// PromotedApplication checks every application to promote is an input of the pipeline
+	PromotedApplication Check = "promoted_application"
+	// StageOrder checks stage orders of every pipeline are contiguous from 0
+	StageOrder Check = "stage_order"
+)
+
+// Checks lists all checks in order they are run
+var Checks = []Check{BranchDockerStream, StageStreamChain, PromotedApplication, StageOrder}
+
+// Violation is a broken invariant along with the suggested fix.
+// Branch and stage names are prefixed with the codebase or pipeline name, e.g. app/master.
+type Violation struct {
+	Check   Check  `json:"check"`
+	Entity  string `json:"entity"`
+	Problem string `json:"problem"`
+	Fix     string `json:"fix"`
+}
+
+type Verifier struct {
+	DB *sql.DB
+}
+
+// Verify runs all checks against the tenant schema in a single read-only transaction
+func (v Verifier) Verify(ctx context.Context, tenant string) ([]Violation, error) {
+	txn, err := v.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	if err := repository.CheckTenant(txn, tenant); err != nil {
+		return nil, err
+	}
+
+	checks := map[Check]func(txn *sql.Tx, schema string) ([]Violation, error){
+		BranchDockerStream:  branchDockerStreams,
+		StageStreamChain:    stageStreamChains,
+		PromotedApplication: promotedApplications,
+		StageOrder:          stageOrders,
+	}
+	result := []Violation{}
+	for _, c := range Checks {
+		violations, err := checks[c](txn, tenant)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't run %v check", c)
+		}
+		result = append(result, violations...)
+	}
+	return result, nil
+}
+
+func branchDockerStreams(txn *sql.Tx, schema string) ([]Violation, error) {
+	branches, err := repository.GetBranchDockerStreamViolations(txn, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Violation
+	for _, b := range branches {
+		v := Violation{Check: BranchDockerStream, Entity: changefeed.Name(b.Codebase, b.Branch)}
+		switch {
+		case b.StreamId == nil:
+			v.Problem = "branch has no output docker stream"
+			v.Fix = fmt.Sprintf("resync the branch, so docker stream %v-%v is created", b.Codebase, b.Branch)
+		case b.StreamBranchId == nil || *b.StreamBranchId != b.BranchId:
+			v.Problem = fmt.Sprintf("output docker stream %v doesn't point back to the branch", *b.StreamId)
+			v.Fix = fmt.Sprintf("update \"%v\".codebase_docker_stream set codebase_branch_id = %v where id = %v ;",
+				schema, b.BranchId, *b.StreamId)
+		default:
+			v.Problem = fmt.Sprintf("%v docker streams besides stage outputs point to the branch", b.Streams)
+			v.Fix = fmt.Sprintf("remove unused streams listed by: select id, oc_image_stream_name "+
+				"from \"%v\".codebase_docker_stream where codebase_branch_id = %v and id <> %v ;",
+				schema, b.BranchId, *b.StreamId)
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+// stageStreamChains walks stages of every pipeline the way relinking of stages does and compares
+// the actual stage inputs with the expected ones
+func stageStreamChains(txn *sql.Tx, schema string) ([]Violation, error) {
+	pipelines, err := repository.GetEntityNames(txn, change.CDPipeline, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Violation
+	for _, p := range pipelines {
+		promoted, err := repository.GetApplicationsToPromote(txn, p, schema)
+		if err != nil {
+			return nil, err
+		}
+		stages, err := sr.GetStages(txn, p, schema)
+		if err != nil {
+			return nil, err
+		}
+
+		var prevOutputs map[string]int
+		for _, s := range stages {
+			links, err := repository.GetStageCodebaseDockerStreams(txn, schema, s.Id)
+			if err != nil {
+				return nil, err
+			}
+
+			outputs := make(map[string]int, len(links))
+			for _, l := range links {
+				outputs[l.CodebaseName] = l.OutputStreamId
+
+				entity := changefeed.Name(p, s.Name)
+				expected, ok := prevOutputs[l.CodebaseName]
+				if !ok || !contains(promoted, l.CodebaseName) {
+					original, err := repository.GetSourceInputStream(txn, p, l.CodebaseName, schema)
+					if err != nil {
+						return nil, err
+					}
+					if original == nil {
+						result = append(result, Violation{
+							Check:   StageStreamChain,
+							Entity:  entity,
+							Problem: fmt.Sprintf("pipeline has no input docker stream of %v codebase", l.CodebaseName),
+							Fix:     fmt.Sprintf("add docker stream of %v to input docker streams of the pipeline", l.CodebaseName),
+						})
+						continue
+					}
+					expected = *original
+				}
+
+				if expected != l.InputStreamId {
+					result = append(result, Violation{
+						Check:  StageStreamChain,
+						Entity: entity,
+						Problem: fmt.Sprintf("input docker stream of %v codebase is %v, expected %v",
+							l.CodebaseName, l.InputStreamId, expected),
+						Fix: fmt.Sprintf("resync the stage or run: update \"%v\".stage_codebase_docker_stream "+
+							"set input_codebase_docker_stream_id = %v where cd_stage_id = %v and output_codebase_docker_stream_id = %v ;",
+							schema, expected, s.Id, l.OutputStreamId),
+					})
+				}
+			}
+			prevOutputs = outputs
+		}
+	}
+	return result, nil
+}
+
+func promotedApplications(txn *sql.Tx, schema string) ([]Violation, error) {
+	apps, err := repository.GetPromotedApplicationViolations(txn, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Violation
+	for _, a := range apps {
+		result = append(result, Violation{
+			Check:   PromotedApplication,
+			Entity:  a.Pipeline,
+			Problem: fmt.Sprintf("application %v is promoted but isn't an input of the pipeline", a.Codebase),
+			Fix: fmt.Sprintf("add docker stream of %v to spec.inputDockerStreams of the CD pipeline "+
+				"or remove it from spec.applicationsToPromote", a.Codebase),
+		})
+	}
+	return result, nil
+}
+
+func stageOrders(txn *sql.Tx, schema string) ([]Violation, error) {
+	pipelines, err := repository.GetStageOrderViolations(txn, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Violation
+	for _, p := range pipelines {
+		result = append(result, Violation{
+			Check:   StageOrder,
+			Entity:  p.Pipeline,
+			Problem: fmt.Sprintf("stage orders are %v, expected contiguous from 0", p.Orders),
+			Fix:     fmt.Sprintf("set spec.order of the pipeline stages to 0..%v in order of promotion", len(p.Orders)-1),
+		})
+	}
+	return result, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -2,10 +2,15 @@ package jenkins_slave
 
 import (
 	"database/sql"
+	"sort"
 
 	jenkinsApi "github.com/epam/edp-jenkins-operator/v2/pkg/apis/v2/v1"
-	"github.com/epam/edp-reconciler/v2/pkg/repository/jenkins-slave"
+	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/epam/edp-reconciler/v2/pkg/db/migration"
+	"github.com/epam/edp-reconciler/v2/pkg/model"
+	"github.com/epam/edp-reconciler/v2/pkg/repository/jenkins-slave"
 )
 
 var log = ctrl.Log.WithName("jenkins-slave-service")
@@ -14,42 +19,106 @@ type JenkinsSlaveService struct {
 	DB *sql.DB
 }
 
-func (s JenkinsSlaveService) CreateSlavesOrDoNothing(slaves []jenkinsApi.Slave, schemaName string) (err error) {
-	log.Info("Start executing CreateSlavesOrDoNothing method... ")
+// PutSlaves makes slaves of the Jenkins stored in DB match its status: new slaves are inserted,
+// slaves which are gone from Jenkins are deleted or, while codebases still use them, marked as removed.
+// Unclaimed slaves are claimed by the Jenkins which has them and are never removed.
+func (s JenkinsSlaveService) PutSlaves(jenkinsName string, slaves []jenkinsApi.Slave, schemaName string) error {
+	log.Info("Start syncing Jenkins slaves", "jenkins", jenkinsName)
+
+	if err := migration.Ensure(s.DB, schemaName); err != nil {
+		return err
+	}
 
 	txn, err := s.DB.Begin()
 	if err != nil {
 		return err
 	}
-	// TODO: lint Error return value of `txn.Rollback` is not checked (errcheck)
-	//nolint
-	defer txn.Rollback()
 
-	for _, s := range slaves {
-		if len(s.Name) == 0 {
+	if err := syncSlaves(txn, jenkinsName, slaves, schemaName); err != nil {
+		_ = txn.Rollback()
+		return err
+	}
+
+	if err := txn.Commit(); err != nil {
+		return err
+	}
+
+	log.Info("Jenkins slaves have been synced", "jenkins", jenkinsName)
+	return nil
+}
+
+func syncSlaves(txn *sql.Tx, jenkinsName string, slaves []jenkinsApi.Slave, schemaName string) error {
+	stored, err := jenkins_slave.GetJenkinsSlaves(txn, jenkinsName, schemaName)
+	if err != nil {
+		return errors.Wrap(err, "an error has occurred while fetching Jenkins slaves")
+	}
+
+	desired := map[string]bool{}
+	for _, sl := range slaves {
+		if len(sl.Name) > 0 {
+			desired[sl.Name] = true
+		}
+	}
+
+	existing := map[string]bool{}
+	for _, sl := range stored {
+		existing[sl.Name] = true
+		if desired[sl.Name] {
+			if err := jenkins_slave.UpdateJenkinsSlave(txn, sl.Id, jenkinsName, false, schemaName); err != nil {
+				return errors.Wrapf(err, "an error has occurred while updating Jenkins slave %v", sl.Name)
+			}
+			continue
+		}
+		if sl.Unclaimed {
 			continue
 		}
 
-		id, err := jenkins_slave.SelectJenkinsSlave(txn, s.Name, schemaName)
+		if err := removeSlave(txn, sl, jenkinsName, schemaName); err != nil {
+			return errors.Wrapf(err, "an error has occurred while removing Jenkins slave %v", sl.Name)
+		}
+	}
+
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		if !existing[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		id, err := jenkins_slave.SelectJenkinsSlave(txn, name, schemaName)
 		if err != nil {
 			return err
 		}
 
 		if id != nil {
-			log.Info("Jenkins Slave already exists. Skip adding into db", "name", s)
+			log.Info("Jenkins Slave belongs to another Jenkins. Skip adding into db", "name", name)
 			continue
 		}
 
-		if err := jenkins_slave.CreateJenkinsSlave(txn, s.Name, schemaName); err != nil {
-			return err
+		if err := jenkins_slave.CreateJenkinsSlave(txn, name, jenkinsName, schemaName); err != nil {
+			return errors.Wrapf(err, "an error has occurred while creating Jenkins slave %v", name)
 		}
 	}
+	return nil
+}
 
-	if err := txn.Commit(); err != nil {
+func removeSlave(txn *sql.Tx, slave model.JenkinsSlaveDTO, jenkinsName, schemaName string) error {
+	used, err := jenkins_slave.IsJenkinsSlaveUsed(txn, slave.Id, schemaName)
+	if err != nil {
 		return err
 	}
 
-	log.Info("End executing CreateSlavesOrDoNothing method... ")
+	if !used {
+		log.Info("Jenkins slave has been removed from Jenkins. Deleting", "name", slave.Name)
+		return jenkins_slave.DeleteJenkinsSlave(txn, slave.Id, schemaName)
+	}
 
-	return err
+	if slave.Removed {
+		return nil
+	}
+	log.Info("Jenkins slave has been removed from Jenkins but is still used by codebases. Marking as removed",
+		"name", slave.Name)
+	return jenkins_slave.UpdateJenkinsSlave(txn, slave.Id, jenkinsName, true, schemaName)
 }
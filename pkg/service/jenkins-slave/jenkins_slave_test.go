@@ -0,0 +1,72 @@
+package jenkins_slave
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	jenkinsApi "github.com/epam/edp-jenkins-operator/v2/pkg/apis/v2/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncSlaves(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select id, name, removed, jenkins_name is null from "schema".jenkins_slave`).ExpectQuery().WithArgs("jenkins").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "removed", "unclaimed"}).
+			AddRow(1, "gradle", false, false).
+			AddRow(2, "maven", false, false).
+			AddRow(3, "npm", false, false))
+	mock.ExpectPrepare(`update "schema".jenkins_slave`).ExpectExec().WithArgs("jenkins", false, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`select exists`).ExpectQuery().WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectPrepare(`update "schema".jenkins_slave`).ExpectExec().WithArgs("jenkins", true, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`select exists`).ExpectQuery().WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectPrepare(`delete from "schema".jenkins_slave`).ExpectExec().WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`select id from "schema".jenkins_slave`).ExpectQuery().WithArgs("python").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectPrepare(`insert into "schema".jenkins_slave`).ExpectExec().WithArgs("python", "jenkins").
+		WillReturnResult(sqlmock.NewResult(4, 1))
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	err = syncSlaves(tx, "jenkins", []jenkinsApi.Slave{{Name: "gradle"}, {Name: "python"}, {Name: ""}}, "schema")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSyncSlaves_UnclaimedSlavesOfTwoJenkins(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// both slaves have been stored before the Jenkins name was tracked, each Jenkins claims its own one only
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select id, name, removed, jenkins_name is null from "schema".jenkins_slave`).ExpectQuery().
+		WithArgs("jenkins-a").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "removed", "unclaimed"}).
+			AddRow(1, "gradle", false, true).
+			AddRow(2, "maven", false, true))
+	mock.ExpectPrepare(`update "schema".jenkins_slave`).ExpectExec().WithArgs("jenkins-a", false, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`select id, name, removed, jenkins_name is null from "schema".jenkins_slave`).ExpectQuery().
+		WithArgs("jenkins-b").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "removed", "unclaimed"}).
+			AddRow(2, "maven", false, true))
+	mock.ExpectPrepare(`update "schema".jenkins_slave`).ExpectExec().WithArgs("jenkins-b", false, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	assert.NoError(t, syncSlaves(tx, "jenkins-a", []jenkinsApi.Slave{{Name: "gradle"}}, "schema"))
+	assert.NoError(t, syncSlaves(tx, "jenkins-b", []jenkinsApi.Slave{{Name: "maven"}}, "schema"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
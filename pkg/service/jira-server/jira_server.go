@@ -3,6 +3,7 @@ package jira_server
 import (
 	"database/sql"
 	jiramodel "github.com/epam/edp-reconciler/v2/pkg/model/jira-server"
+	"github.com/epam/edp-reconciler/v2/pkg/repository"
 	jiraserver "github.com/epam/edp-reconciler/v2/pkg/repository/jira-server"
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -49,3 +50,23 @@ func tryToPutJiraServer(txn *sql.Tx, id *int, jira jiramodel.JiraServer) error {
 	log.V(2).Info("Start creating Jira Server")
 	return jiraserver.CreateJiraServer(txn, jira.Name, jira.Available, jira.Tenant)
 }
+
+// DeleteJiraServer removes the jira_server record, see repository.DeleteServer
+func (s JiraServerService) DeleteJiraServer(name, tenant string, detach bool) error {
+	log.Info("start deleting JiraServer", "name", name)
+	txn, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := repository.DeleteServer(txn, repository.JiraServerReference, name, detach, tenant); err != nil {
+		_ = txn.Rollback()
+		return err
+	}
+
+	if err := txn.Commit(); err != nil {
+		return err
+	}
+	log.Info("JiraServer has been deleted", "name", name)
+	return nil
+}
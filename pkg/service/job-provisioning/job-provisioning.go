@@ -2,11 +2,14 @@ package job_provisioning
 
 import (
 	"database/sql"
+	"sort"
 
 	jenkinsApi "github.com/epam/edp-jenkins-operator/v2/pkg/apis/v2/v1"
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	"github.com/epam/edp-reconciler/v2/pkg/db/migration"
+	"github.com/epam/edp-reconciler/v2/pkg/model"
 	jp "github.com/epam/edp-reconciler/v2/pkg/repository/job-provisioning"
 )
 
@@ -16,39 +19,115 @@ type JobProvisionService struct {
 	DB *sql.DB
 }
 
-func (s JobProvisionService) PutJobProvisions(provisions []jenkinsApi.JobProvision, schemaName string) error {
-	log.Info("Start executing PutJobProvisions method... ")
+// PutJobProvisions makes job provisioners of the Jenkins stored in DB match its status: new ones are inserted,
+// ones which are gone from Jenkins are deleted or, while codebases or stages still use them, marked as removed.
+// Unclaimed job provisioners are claimed by the Jenkins which has them and are never removed.
+func (s JobProvisionService) PutJobProvisions(jenkinsName string, provisions []jenkinsApi.JobProvision, schemaName string) error {
+	log.Info("Start syncing job provisions", "jenkins", jenkinsName)
+
+	if err := migration.Ensure(s.DB, schemaName); err != nil {
+		return err
+	}
 
 	txn, err := s.DB.Begin()
 	if err != nil {
 		return err
 	}
 
+	if err := syncJobProvisions(txn, jenkinsName, provisions, schemaName); err != nil {
+		_ = txn.Rollback()
+		return err
+	}
+
+	if err := txn.Commit(); err != nil {
+		return err
+	}
+
+	log.Info("Job provisions have been synced", "jenkins", jenkinsName)
+	return nil
+}
+
+type provisionKey struct {
+	scope string
+	name  string
+}
+
+func syncJobProvisions(txn *sql.Tx, jenkinsName string, provisions []jenkinsApi.JobProvision, schemaName string) error {
+	stored, err := jp.GetJobProvisions(txn, jenkinsName, schemaName)
+	if err != nil {
+		return errors.Wrap(err, "an error has occurred while fetching job provisions")
+	}
+
+	desired := map[provisionKey]bool{}
 	for _, p := range provisions {
-		id, err := jp.SelectJobProvision(txn, p.Name, p.Scope, schemaName)
+		desired[provisionKey{scope: p.Scope, name: p.Name}] = true
+	}
+
+	existing := map[provisionKey]bool{}
+	for _, p := range stored {
+		key := provisionKey{scope: p.Scope, name: p.Name}
+		existing[key] = true
+		if desired[key] {
+			if err := jp.UpdateJobProvision(txn, p.Id, jenkinsName, false, schemaName); err != nil {
+				return errors.Wrapf(err, "an error has occurred while updating job provision %v", p.Name)
+			}
+			continue
+		}
+		if p.Unclaimed {
+			continue
+		}
+
+		if err := removeJobProvision(txn, p, jenkinsName, schemaName); err != nil {
+			return errors.Wrapf(err, "an error has occurred while removing job provision %v", p.Name)
+		}
+	}
+
+	keys := make([]provisionKey, 0, len(desired))
+	for key := range desired {
+		if !existing[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].scope != keys[j].scope {
+			return keys[i].scope < keys[j].scope
+		}
+		return keys[i].name < keys[j].name
+	})
+
+	for _, key := range keys {
+		id, err := jp.SelectJobProvision(txn, key.name, key.scope, schemaName)
 		if err != nil {
-			_ = txn.Rollback()
-			return errors.Wrapf(err, "an error has occurred while selecting job provision %v", p.Name)
+			return errors.Wrapf(err, "an error has occurred while selecting job provision %v", key.name)
 		}
 
 		if id != nil {
-			log.Info("Job Provision already exists. Skip adding into db", "name", p)
+			log.Info("Job Provision belongs to another Jenkins. Skip adding into db", "name", key.name, "scope", key.scope)
 			continue
 		}
 
-		err = jp.CreateJobProvision(txn, p.Name, p.Scope, schemaName)
-		if err != nil {
-			_ = txn.Rollback()
-			return errors.Wrapf(err, "an error has occurred while creating job provision %v", p.Name)
+		if err := jp.CreateJobProvision(txn, key.name, key.scope, jenkinsName, schemaName); err != nil {
+			return errors.Wrapf(err, "an error has occurred while creating job provision %v", key.name)
 		}
 	}
+	return nil
+}
 
-	err = txn.Commit()
+func removeJobProvision(txn *sql.Tx, p model.JobProvisionDTO, jenkinsName, schemaName string) error {
+	used, err := jp.IsJobProvisionUsed(txn, p.Id, schemaName)
 	if err != nil {
 		return err
 	}
 
-	log.Info("End executing PutJobProvisions method... ")
+	if !used {
+		log.Info("Job provision has been removed from Jenkins. Deleting", "name", p.Name, "scope", p.Scope)
+		return jp.DeleteJobProvision(txn, p.Id, schemaName)
+	}
 
-	return err
+	if p.Removed {
+		return nil
+	}
+	log.Info("Job provision has been removed from Jenkins but is still in use. Marking as removed",
+		"name", p.Name, "scope", p.Scope)
+	return jp.UpdateJobProvision(txn, p.Id, jenkinsName, true, schemaName)
 }
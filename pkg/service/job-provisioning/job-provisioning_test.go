@@ -0,0 +1,95 @@
+package job_provisioning
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	jenkinsApi "github.com/epam/edp-jenkins-operator/v2/pkg/apis/v2/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncJobProvisions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select id, name, scope, removed, jenkins_name is null from "schema".job_provisioning`).
+		ExpectQuery().WithArgs("jenkins").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "scope", "removed", "unclaimed"}).
+			AddRow(1, "default", "ci", false, false).
+			AddRow(2, "legacy", "ci", false, false).
+			AddRow(3, "old", "ci", false, false).
+			AddRow(4, "default", "cd", false, true))
+	mock.ExpectPrepare(`update "schema".job_provisioning`).ExpectExec().WithArgs("jenkins", false, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`select exists`).ExpectQuery().WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectPrepare(`update "schema".job_provisioning`).ExpectExec().WithArgs("jenkins", true, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`select exists`).ExpectQuery().WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectPrepare(`delete from "schema".job_provisioning`).ExpectExec().WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`select id from "schema".job_provisioning`).ExpectQuery().WithArgs("custom", "cd").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectPrepare(`select id from "schema".job_provisioning`).ExpectQuery().WithArgs("custom", "ci").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectPrepare(`insert into "schema".job_provisioning`).ExpectExec().WithArgs("custom", "ci", "jenkins").
+		WillReturnResult(sqlmock.NewResult(8, 1))
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	// custom cd job provisioner belongs to another Jenkins, so only the ci one is inserted
+	err = syncJobProvisions(tx, "jenkins", []jenkinsApi.JobProvision{
+		{Name: "default", Scope: "ci"},
+		{Name: "custom", Scope: "ci"},
+		{Name: "custom", Scope: "cd"},
+	}, "schema")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSyncJobProvisions_KeepsRemovedJobProvisionInUse(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select id, name, scope, removed, jenkins_name is null from "schema".job_provisioning`).
+		ExpectQuery().WithArgs("jenkins").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "scope", "removed", "unclaimed"}).
+			AddRow(2, "legacy", "ci", true, false))
+	mock.ExpectPrepare(`select exists`).ExpectQuery().WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	assert.NoError(t, syncJobProvisions(tx, "jenkins", nil, "schema"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSyncJobProvisions_Error(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select id, name, scope, removed, jenkins_name is null from "schema".job_provisioning`).
+		ExpectQuery().WithArgs("jenkins").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "scope", "removed", "unclaimed"}))
+	mock.ExpectPrepare(`select id from "schema".job_provisioning`).ExpectQuery().WithArgs("default", "ci").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectPrepare(`insert into "schema".job_provisioning`).ExpectExec().WithArgs("default", "ci", "jenkins").
+		WillReturnError(errors.New("duplicate key"))
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	err = syncJobProvisions(tx, "jenkins", []jenkinsApi.JobProvision{{Name: "default", Scope: "ci"}}, "schema")
+	assert.EqualError(t, err, "an error has occurred while creating job provision default: duplicate key")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,141 @@
+// Package lineage builds the promotion graph of docker streams of the CD pipeline
+package lineage
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/view"
+)
+
+type NodeKind string
+
+const (
+	// Branch is the docker stream built from the codebase branch which the pipeline takes as input
+	Branch NodeKind = "branch"
+	// Stage is the verified docker stream produced by the stage, named <pipeline>-<stage>-<codebase>-verified
+	Stage NodeKind = "stage"
+)
+
+// Node is a docker stream identified by its name
+type Node struct {
+	Id       string   `json:"id"`
+	Kind     NodeKind `json:"kind"`
+	Codebase string   `json:"codebase,omitempty"`
+	Branch   string   `json:"branch,omitempty"`
+	Stage    string   `json:"stage,omitempty"`
+}
+
+// Edge is a promotion of the codebase image by the stage from one docker stream to another.
+// Promoted edges come from the previous stage output, which is the case for the applications to promote
+// beyond the first stage. The rest of edges come from the branch streams.
+type Edge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Stage    string `json:"stage"`
+	Codebase string `json:"codebase,omitempty"`
+	Promoted bool   `json:"promoted"`
+}
+
+type Graph struct {
+	Pipeline              string   `json:"pipeline"`
+	ApplicationsToPromote []string `json:"applicationsToPromote"`
+	Nodes                 []Node   `json:"nodes"`
+	Edges                 []Edge   `json:"edges"`
+}
+
+// Build makes the graph of the pipeline from its input streams and links of stage streams ordered by stage
+func Build(pipeline string, inputs []view.InputDockerStream, links []view.StageDockerStream, promoted []string) *Graph {
+	g := &Graph{Pipeline: pipeline, ApplicationsToPromote: append([]string{}, promoted...), Nodes: []Node{}, Edges: []Edge{}}
+	kinds := map[string]NodeKind{}
+	add := func(n Node) {
+		if _, ok := kinds[n.Id]; !ok {
+			kinds[n.Id] = n.Kind
+			g.Nodes = append(g.Nodes, n)
+		}
+	}
+
+	for _, in := range inputs {
+		add(Node{Id: in.Stream, Kind: Branch, Codebase: deref(in.Codebase), Branch: deref(in.Branch)})
+	}
+
+	for _, l := range links {
+		if l.OutputStream == nil {
+			continue
+		}
+		codebase := deref(l.Codebase)
+		add(Node{Id: *l.OutputStream, Kind: Stage, Codebase: codebase, Stage: l.Stage})
+		if l.InputStream == nil {
+			continue
+		}
+		add(Node{Id: *l.InputStream, Kind: Branch, Codebase: codebase})
+		g.Edges = append(g.Edges, Edge{
+			From:     *l.InputStream,
+			To:       *l.OutputStream,
+			Stage:    l.Stage,
+			Codebase: codebase,
+			Promoted: kinds[*l.InputStream] == Stage,
+		})
+	}
+	return g
+}
+
+// WriteDOT renders the graph in Graphviz DOT language. Outputs of every stage are grouped into a cluster,
+// promoted edges are solid and edges from the branch streams are dashed.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var err error
+	printf := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+
+	printf("digraph %v {\n", strconv.Quote(g.Pipeline))
+	printf("  rankdir=LR;\n")
+	printf("  node [shape=box];\n")
+
+	var stages []string
+	byStage := map[string][]Node{}
+	for _, n := range g.Nodes {
+		if n.Kind == Branch {
+			label := n.Id
+			if n.Codebase != "" {
+				label = fmt.Sprintf("%v\n%v/%v", n.Id, n.Codebase, n.Branch)
+			}
+			printf("  %v [label=%v];\n", strconv.Quote(n.Id), strconv.Quote(label))
+			continue
+		}
+		if _, ok := byStage[n.Stage]; !ok {
+			stages = append(stages, n.Stage)
+		}
+		byStage[n.Stage] = append(byStage[n.Stage], n)
+	}
+
+	for i, s := range stages {
+		printf("  subgraph %v {\n", strconv.Quote(fmt.Sprintf("cluster_%v", i)))
+		printf("    label=%v;\n", strconv.Quote(s))
+		for _, n := range byStage[s] {
+			printf("    %v [shape=ellipse];\n", strconv.Quote(n.Id))
+		}
+		printf("  }\n")
+	}
+
+	for _, e := range g.Edges {
+		style := "dashed"
+		if e.Promoted {
+			style = "solid"
+		}
+		printf("  %v -> %v [label=%v, style=%v];\n", strconv.Quote(e.From), strconv.Quote(e.To),
+			strconv.Quote(e.Stage), style)
+	}
+	printf("}\n")
+	return err
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
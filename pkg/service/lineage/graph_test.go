@@ -0,0 +1,83 @@
+package lineage
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/view"
+)
+
+func str(s string) *string {
+	return &s
+}
+
+// pipelineGraph builds graph of pipe with qa and sit stages, app is promoted and lib is taken from its branch on every stage
+func pipelineGraph() *Graph {
+	inputs := []view.InputDockerStream{
+		{Stream: "app-master", Codebase: str("app"), Branch: str("master")},
+		{Stream: "lib-master", Codebase: str("lib"), Branch: str("master")},
+	}
+	links := []view.StageDockerStream{
+		{Stage: "qa", StageOrder: 0, Codebase: str("app"), InputStream: str("app-master"), OutputStream: str("pipe-qa-app-verified")},
+		{Stage: "qa", StageOrder: 0, Codebase: str("lib"), InputStream: str("lib-master"), OutputStream: str("pipe-qa-lib-verified")},
+		{Stage: "sit", StageOrder: 1, Codebase: str("app"), InputStream: str("pipe-qa-app-verified"), OutputStream: str("pipe-sit-app-verified")},
+		{Stage: "sit", StageOrder: 1, Codebase: str("lib"), InputStream: str("lib-master"), OutputStream: str("pipe-sit-lib-verified")},
+	}
+	return Build("pipe", inputs, links, []string{"app"})
+}
+
+func TestBuild(t *testing.T) {
+	g := pipelineGraph()
+
+	assert.Equal(t, []string{"app"}, g.ApplicationsToPromote)
+	assert.Len(t, g.Nodes, 6)
+	assert.Equal(t, Node{Id: "app-master", Kind: Branch, Codebase: "app", Branch: "master"}, g.Nodes[0])
+	assert.Equal(t, Node{Id: "pipe-sit-app-verified", Kind: Stage, Codebase: "app", Stage: "sit"}, g.Nodes[4])
+
+	assert.Equal(t, []Edge{
+		{From: "app-master", To: "pipe-qa-app-verified", Stage: "qa", Codebase: "app"},
+		{From: "lib-master", To: "pipe-qa-lib-verified", Stage: "qa", Codebase: "lib"},
+		{From: "pipe-qa-app-verified", To: "pipe-sit-app-verified", Stage: "sit", Codebase: "app", Promoted: true},
+		{From: "lib-master", To: "pipe-sit-lib-verified", Stage: "sit", Codebase: "lib"},
+	}, g.Edges)
+}
+
+func TestBuild_SkipsIncompleteLinks(t *testing.T) {
+	g := Build("pipe", nil, []view.StageDockerStream{
+		{Stage: "qa", Codebase: str("app"), OutputStream: str("pipe-qa-app-verified")},
+		{Stage: "qa", Codebase: str("lib"), InputStream: str("lib-master")},
+	}, nil)
+
+	assert.Equal(t, []string{}, g.ApplicationsToPromote)
+	assert.Equal(t, []Node{{Id: "pipe-qa-app-verified", Kind: Stage, Codebase: "app", Stage: "qa"}}, g.Nodes)
+	assert.Empty(t, g.Edges)
+}
+
+func TestWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, pipelineGraph().WriteDOT(&buf))
+
+	assert.Equal(t, `digraph "pipe" {
+  rankdir=LR;
+  node [shape=box];
+  "app-master" [label="app-master\napp/master"];
+  "lib-master" [label="lib-master\nlib/master"];
+  subgraph "cluster_0" {
+    label="qa";
+    "pipe-qa-app-verified" [shape=ellipse];
+    "pipe-qa-lib-verified" [shape=ellipse];
+  }
+  subgraph "cluster_1" {
+    label="sit";
+    "pipe-sit-app-verified" [shape=ellipse];
+    "pipe-sit-lib-verified" [shape=ellipse];
+  }
+  "app-master" -> "pipe-qa-app-verified" [label="qa", style=dashed];
+  "lib-master" -> "pipe-qa-lib-verified" [label="qa", style=dashed];
+  "pipe-qa-app-verified" -> "pipe-sit-app-verified" [label="sit", style=solid];
+  "lib-master" -> "pipe-sit-lib-verified" [label="sit", style=dashed];
+}
+`, buf.String())
+}
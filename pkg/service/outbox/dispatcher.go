@@ -0,0 +1,274 @@
+// Package outbox delivers changes of the reconciled entities to external sinks.
+// The tenant change feed is the transactional outbox: events are written in the same transaction
+// as the entity changes, so only committed changes are delivered and none of them is lost.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/epam/edp-reconciler/v2/pkg/db/migration"
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+	"github.com/epam/edp-reconciler/v2/pkg/repository"
+	"github.com/epam/edp-reconciler/v2/pkg/service/changefeed"
+)
+
+var log = ctrl.Log.WithName("outbox-dispatcher")
+
+// Dispatcher follows change feeds of all tenants and delivers events to the sinks with at-least-once semantics.
+// Events accepted by a sink are queued in the tenant schema together with moving the sink cursor, then delivered
+// in order per entity: the next change of the entity isn't attempted until the previous one has been delivered.
+// Failed deliveries are retried with exponential backoff until they're out of attempts or rejected by the sink,
+// then they're parked to unblock the entity. It implements manager.Runnable and runs on the leader only.
+type Dispatcher struct {
+	DB      *sql.DB
+	Options Options
+	Bus     *changefeed.Bus
+	sinks   map[string]Sink
+	order   []Sink
+	now     func() time.Time
+}
+
+func NewDispatcher(db *sql.DB, options Options, sinks ...Sink) *Dispatcher {
+	d := &Dispatcher{
+		DB:      db,
+		Options: options.WithDefaults(),
+		Bus:     changefeed.DefaultBus,
+		sinks:   map[string]Sink{},
+		order:   sinks,
+		now:     time.Now,
+	}
+	for _, s := range sinks {
+		d.sinks[s.Name()] = s
+	}
+	return d
+}
+
+func (d *Dispatcher) NeedLeaderElection() bool {
+	return true
+}
+
+func (d *Dispatcher) Start(ctx context.Context) error {
+	log.Info("starting outbox dispatcher", "sinks", len(d.order))
+
+	ticker := time.NewTicker(d.Options.Interval)
+	defer ticker.Stop()
+	events, cancel := d.Bus.Subscribe(1)
+	defer func() { cancel() }()
+
+	for {
+		if err := d.Dispatch(ctx); err != nil {
+			log.Error(err, "couldn't dispatch outbox")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case _, ok := <-events:
+			if !ok {
+				events, cancel = d.Bus.Subscribe(1)
+			}
+		}
+	}
+}
+
+// Dispatch queues new events of all tenants and attempts due deliveries. Failure of one tenant doesn't stop
+// the others, errors of all failed tenants are returned together.
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	schemas, err := migration.Schemas(d.DB)
+	if err != nil {
+		return errors.Wrap(err, "couldn't get tenant schemas")
+	}
+
+	var result error
+	for _, schema := range schemas {
+		if ctx.Err() != nil {
+			return result
+		}
+		if err := d.dispatchSchema(ctx, schema); err != nil {
+			log.Error(err, "couldn't dispatch outbox of the schema", "schema", schema)
+			failures.WithLabelValues(schema).Inc()
+			result = multierr.Append(result, err)
+		}
+	}
+	return result
+}
+
+func (d *Dispatcher) dispatchSchema(ctx context.Context, schema string) error {
+	for _, s := range d.order {
+		if err := d.enqueue(schema, s); err != nil {
+			return errors.Wrapf(err, "couldn't queue events of %v schema for %v sink", schema, s.Name())
+		}
+	}
+
+	if err := d.deliver(ctx, schema); err != nil {
+		return errors.Wrapf(err, "couldn't deliver outbox of %v schema", schema)
+	}
+	return nil
+}
+
+// enqueue moves events of the change feed following the sink cursor to the outbox batch by batch.
+// Sink which has no cursor yet starts with the events made after it has been configured.
+func (d *Dispatcher) enqueue(schema string, sink Sink) error {
+	for {
+		txn, err := d.DB.Begin()
+		if err != nil {
+			return err
+		}
+		n, err := d.enqueueBatch(txn, schema, sink)
+		if err != nil {
+			_ = txn.Rollback()
+			return err
+		}
+		if err := txn.Commit(); err != nil {
+			return err
+		}
+		if n < d.Options.BatchSize {
+			return nil
+		}
+	}
+}
+
+func (d *Dispatcher) enqueueBatch(txn *sql.Tx, schema string, sink Sink) (int, error) {
+	cursor, err := repository.GetOutboxCursor(txn, sink.Name(), schema)
+	if err != nil {
+		return 0, err
+	}
+	if cursor == nil {
+		latest, err := repository.GetLatestChangeEventId(txn, schema)
+		if err != nil {
+			return 0, err
+		}
+		return 0, repository.PutOutboxCursor(txn, sink.Name(), latest, schema)
+	}
+
+	events, err := repository.GetChangeEvents(txn, *cursor, d.Options.BatchSize, schema)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+
+	for _, e := range events {
+		if !sink.Accepts(e) {
+			continue
+		}
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return 0, err
+		}
+		if err := repository.CreateOutboxDelivery(txn, sink.Name(), e.Id, string(e.Kind), e.Name, payload,
+			schema); err != nil {
+			return 0, err
+		}
+	}
+	return len(events), repository.PutOutboxCursor(txn, sink.Name(), events[len(events)-1].Id, schema)
+}
+
+// deliver sends due deliveries of the schema until there are none left, every result is stored
+// in its own transaction. Delivered event unblocks the next change of its entity for the following round.
+// Queues of sinks which have been removed from the config are kept until they're configured again.
+func (d *Dispatcher) deliver(ctx context.Context, schema string) error {
+	for ctx.Err() == nil {
+		due, err := d.dueDeliveries(schema)
+		if err != nil || len(due) == 0 {
+			return err
+		}
+
+		for _, dl := range due {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			sink := d.sinks[dl.Sink]
+			var e change.Event
+			var sendErr error
+			if err := json.Unmarshal(dl.Payload, &e); err != nil {
+				sendErr = Permanent(err)
+			} else {
+				sendErr = sink.Deliver(ctx, e)
+			}
+			if err := d.complete(schema, dl, sendErr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) dueDeliveries(schema string) ([]repository.OutboxDelivery, error) {
+	txn, err := d.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = txn.Rollback() }()
+
+	names := make([]string, 0, len(d.order))
+	for _, s := range d.order {
+		names = append(names, s.Name())
+	}
+	return repository.GetDueOutboxDeliveries(txn, names, d.Options.BatchSize, schema)
+}
+
+// complete removes delivered event from the outbox, schedules the next attempt or parks the failed delivery
+func (d *Dispatcher) complete(schema string, dl repository.OutboxDelivery, sendErr error) error {
+	txn, err := d.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	result, reason := "delivered", ""
+	if sendErr == nil {
+		err = repository.DeleteOutboxDelivery(txn, dl.Id, schema)
+	} else {
+		attempts := dl.Attempts + 1
+		var permanent *PermanentError
+		switch {
+		case errors.As(sendErr, &permanent):
+			reason = "rejected"
+		case attempts >= d.Options.MaxAttempts:
+			reason = "out_of_attempts"
+		}
+
+		result = "retried"
+		if reason != "" {
+			result = "parked"
+			log.Error(sendErr, "outbox delivery has been parked", "schema", schema, "sink", dl.Sink,
+				"event", dl.EventId, "attempts", attempts, "reason", reason)
+		} else {
+			log.Info("outbox delivery has failed", "schema", schema, "sink", dl.Sink, "event", dl.EventId,
+				"attempts", attempts, "error", sendErr.Error())
+		}
+		err = repository.FailOutboxDelivery(txn, dl.Id, sendErr.Error(), d.now().Add(d.backoff(attempts)),
+			reason != "", schema)
+	}
+	if err != nil {
+		_ = txn.Rollback()
+		return err
+	}
+	if err := txn.Commit(); err != nil {
+		return err
+	}
+	deliveries.WithLabelValues(schema, dl.Sink, result).Inc()
+	if reason != "" {
+		parkedDeliveries.WithLabelValues(schema, dl.Sink, reason).Inc()
+	}
+	return nil
+}
+
+// backoff returns delay before the next attempt after the given number of failed ones
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.Options.Backoff
+	for i := 1; i < attempts && delay < d.Options.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.Options.MaxBackoff {
+		return d.Options.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,209 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+)
+
+var dueColumns = []string{"id", "sink", "event_id", "payload", "attempts"}
+
+type fakeSink struct {
+	name      string
+	kinds     map[change.Kind]bool
+	fail      map[int64]bool
+	reject    map[int64]bool
+	delivered []int64
+}
+
+func (s *fakeSink) Name() string {
+	return s.name
+}
+
+func (s *fakeSink) Accepts(e change.Event) bool {
+	return len(s.kinds) == 0 || s.kinds[e.Kind]
+}
+
+func (s *fakeSink) Deliver(_ context.Context, e change.Event) error {
+	if s.fail[e.Id] {
+		return errors.New("unavailable")
+	}
+	if s.reject[e.Id] {
+		return Permanent(errors.New("rejected"))
+	}
+	s.delivered = append(s.delivered, e.Id)
+	return nil
+}
+
+func newTestDispatcher(t *testing.T, sinks ...Sink) (*Dispatcher, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	d := NewDispatcher(db, Options{}, sinks...)
+	d.now = func() time.Time { return time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC) }
+	return d, mock
+}
+
+func payload(t *testing.T, id int64, name string) []byte {
+	b, err := json.Marshal(change.Event{Id: id, Tenant: "edp", Kind: change.Codebase, Name: name, Op: change.Update})
+	assert.NoError(t, err)
+	return b
+}
+
+func TestDispatcher_DeliversUntilOutboxIsDrained(t *testing.T) {
+	sink := &fakeSink{name: "bot", fail: map[int64]bool{6: true}}
+	d, mock := newTestDispatcher(t, sink)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`from "edp".outbox_delivery d`).WithArgs(sqlmock.AnyArg(), 100).
+		WillReturnRows(sqlmock.NewRows(dueColumns).
+			AddRow(1, "bot", 5, payload(t, 5, "app"), 0).
+			AddRow(2, "bot", 6, payload(t, 6, "lib"), 1))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec(`delete from "edp".outbox_delivery`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`update "edp".outbox_delivery`).
+		WithArgs(2, "unavailable", d.now().Add(20*time.Second), false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// the next change of app becomes due once the previous one has been delivered
+	mock.ExpectBegin()
+	mock.ExpectQuery(`from "edp".outbox_delivery d`).
+		WillReturnRows(sqlmock.NewRows(dueColumns).AddRow(3, "bot", 7, payload(t, 7, "app"), 0))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec(`delete from "edp".outbox_delivery`).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`from "edp".outbox_delivery d`).WillReturnRows(sqlmock.NewRows(dueColumns))
+	mock.ExpectRollback()
+
+	assert.NoError(t, d.deliver(context.Background(), "edp"))
+	assert.Equal(t, []int64{5, 7}, sink.delivered)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDispatcher_ParksRejectedAndExhaustedDeliveries(t *testing.T) {
+	sink := &fakeSink{name: "bot", fail: map[int64]bool{6: true}, reject: map[int64]bool{5: true}}
+	d, mock := newTestDispatcher(t, sink)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`from "edp".outbox_delivery d`).
+		WillReturnRows(sqlmock.NewRows(dueColumns).
+			AddRow(1, "bot", 5, payload(t, 5, "app"), 0).
+			AddRow(2, "bot", 6, payload(t, 6, "lib"), defaultMaxAttempts-1))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec(`update "edp".outbox_delivery`).WithArgs(1, "rejected", sqlmock.AnyArg(), true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`update "edp".outbox_delivery`).WithArgs(2, "unavailable", sqlmock.AnyArg(), true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`from "edp".outbox_delivery d`).WillReturnRows(sqlmock.NewRows(dueColumns))
+	mock.ExpectRollback()
+
+	assert.NoError(t, d.deliver(context.Background(), "edp"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(parkedDeliveries.WithLabelValues("edp", "bot", "rejected")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(parkedDeliveries.WithLabelValues("edp", "bot", "out_of_attempts")))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// migratedVersions returns rows of reconciler_migration of the schema with all migrations applied
+func migratedVersions(t *testing.T) *sqlmock.Rows {
+	entries, err := os.ReadDir("../../db/migration/sql")
+	assert.NoError(t, err)
+
+	rows := sqlmock.NewRows([]string{"version"})
+	for _, e := range entries {
+		rows.AddRow(e.Name())
+	}
+	return rows
+}
+
+func TestDispatcher_ContinuesWithNextSchemaOnFailure(t *testing.T) {
+	sink := &fakeSink{name: "bot"}
+	d, mock := newTestDispatcher(t, sink)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select table_schema from information_schema.tables`).
+		WillReturnRows(sqlmock.NewRows([]string{"table_schema"}).AddRow("broken").AddRow("edp"))
+	mock.ExpectQuery(`select version from "broken".reconciler_migration`).WillReturnRows(migratedVersions(t))
+	mock.ExpectQuery(`select version from "edp".reconciler_migration`).WillReturnRows(migratedVersions(t))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`from "broken".outbox_cursor`).WillReturnError(errors.New("locked"))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`from "edp".outbox_cursor`).WillReturnRows(sqlmock.NewRows([]string{"last_event_id"}).AddRow(3))
+	mock.ExpectQuery(`from "edp".change_event`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`from "edp".outbox_delivery d`).WillReturnRows(sqlmock.NewRows(dueColumns))
+	mock.ExpectRollback()
+
+	err := d.Dispatch(context.Background())
+	assert.EqualError(t, err, "couldn't queue events of broken schema for bot sink: locked")
+	assert.Equal(t, float64(1), testutil.ToFloat64(failures.WithLabelValues("broken")))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDispatcher_EnqueuesAcceptedEvents(t *testing.T) {
+	sink := &fakeSink{name: "bot", kinds: map[change.Kind]bool{change.CodebaseBranch: true}}
+	d, mock := newTestDispatcher(t, sink)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`from "edp".outbox_cursor`).WithArgs("bot").
+		WillReturnRows(sqlmock.NewRows([]string{"last_event_id"}).AddRow(3))
+	mock.ExpectQuery(`from "edp".change_event`).WithArgs(3, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "name", "op", "before", "after", "created_at"}).
+			AddRow(4, "codebase", "app", "update", `{}`, `{}`, time.Now()).
+			AddRow(5, "codebase_branch", "app/master", "update", `{}`, `{}`, time.Now()))
+	mock.ExpectExec(`insert into "edp".outbox_delivery`).
+		WithArgs("bot", 5, "codebase_branch", "app/master", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`insert into "edp".outbox_cursor`).WithArgs("bot", 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, d.enqueue("edp", sink))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDispatcher_StartsNewSinkFromLatestEvent(t *testing.T) {
+	sink := &fakeSink{name: "bot"}
+	d, mock := newTestDispatcher(t, sink)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`from "edp".outbox_cursor`).WillReturnRows(sqlmock.NewRows([]string{"last_event_id"}))
+	mock.ExpectQuery(`select coalesce\(max\(id\), 0\) from "edp".change_event`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+	mock.ExpectExec(`insert into "edp".outbox_cursor`).WithArgs("bot", 42).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, d.enqueue("edp", sink))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	d := NewDispatcher(nil, Options{Backoff: time.Second, MaxBackoff: 5 * time.Second})
+
+	assert.Equal(t, time.Second, d.backoff(1))
+	assert.Equal(t, 4*time.Second, d.backoff(3))
+	assert.Equal(t, 5*time.Second, d.backoff(10))
+}
@@ -0,0 +1,27 @@
+package outbox
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	deliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reconciler_outbox_deliveries_total",
+		Help: "Number of outbox delivery attempts by result: delivered, retried or parked",
+	}, []string{"schema", "sink", "result"})
+
+	parkedDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reconciler_outbox_parked_deliveries_total",
+		Help: "Number of outbox deliveries given up by reason: rejected by the sink or out of attempts",
+	}, []string{"schema", "sink", "reason"})
+
+	failures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reconciler_outbox_dispatch_failures_total",
+		Help: "Number of failed outbox dispatches of the tenant schema",
+	}, []string{"schema"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(deliveries, parkedDeliveries, failures)
+}
@@ -0,0 +1,86 @@
+package outbox
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+)
+
+const (
+	defaultInterval    = 10 * time.Second
+	defaultBackoff     = 10 * time.Second
+	defaultMaxBackoff  = time.Hour
+	defaultMaxAttempts = 10
+	defaultBatchSize   = 100
+)
+
+// WebhookSinkPrefix starts names of the webhook sinks
+const WebhookSinkPrefix = "webhook/"
+
+// IsReservedName reports whether the name is reserved for outbox sinks, change feed subscribers can't take it
+func IsReservedName(name string) bool {
+	return strings.HasPrefix(name, WebhookSinkPrefix)
+}
+
+// Sink receives change events from the outbox. Events of the same entity are delivered one by one in order
+// they have been made, an event is delivered again until Deliver succeeds, so sinks must tolerate duplicates.
+// The event is parked once it runs out of attempts or Deliver returns an error made with Permanent.
+type Sink interface {
+	// Name identifies the sink in the outbox and its change feed cursor, it must be stable across restarts
+	Name() string
+	// Accepts returns true if the event should be delivered to the sink
+	Accepts(e change.Event) bool
+	Deliver(ctx context.Context, e change.Event) error
+}
+
+// Options control how often the outbox is processed and how failed deliveries are retried
+type Options struct {
+	// Interval is a period the outbox is checked with when there are no new changes
+	Interval time.Duration
+	// Backoff is a delay before the first retry, it's doubled with every failed attempt up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// MaxAttempts is the number of attempts the delivery is parked after
+	MaxAttempts int
+	BatchSize   int
+}
+
+// PermanentError is the delivery error retrying of which won't help, e.g. the event has been rejected by the sink
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent marks the delivery error as non-retryable
+func Permanent(err error) error {
+	return &PermanentError{Err: err}
+}
+
+// WithDefaults returns options with zero values replaced by defaults
+func (o Options) WithDefaults() Options {
+	if o.Interval == 0 {
+		o.Interval = defaultInterval
+	}
+	if o.Backoff == 0 {
+		o.Backoff = defaultBackoff
+	}
+	if o.MaxBackoff == 0 {
+		o.MaxBackoff = defaultMaxBackoff
+	}
+	if o.MaxAttempts == 0 {
+		o.MaxAttempts = defaultMaxAttempts
+	}
+	if o.BatchSize == 0 {
+		o.BatchSize = defaultBatchSize
+	}
+	return o
+}
@@ -2,10 +2,15 @@ package perfdatasource
 
 import (
 	"database/sql"
-	"github.com/epam/edp-reconciler/v2/pkg/model/codebase"
-	"github.com/epam/edp-reconciler/v2/pkg/repository/perfdatasource"
-	ctrl "sigs.k8s.io/controller-runtime"
 	"strings"
+
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/epam/edp-reconciler/v2/pkg/db/migration"
+	"github.com/epam/edp-reconciler/v2/pkg/repository"
+	"github.com/epam/edp-reconciler/v2/pkg/repository/codebaseperfdatasource"
+	"github.com/epam/edp-reconciler/v2/pkg/repository/perfdatasource"
 )
 
 type PerfDataSourceService struct {
@@ -14,70 +19,73 @@ type PerfDataSourceService struct {
 
 var log = ctrl.Log.WithName("perf-data-source-service")
 
-func (s PerfDataSourceService) perfDataSourceExists(dsType, tenant string) (bool, error) {
-	log.Info("checking for existence record data source", "type", dsType)
+// ErrCodebaseNotFound is returned when data source is put for codebase which isn't stored in DB yet
+var ErrCodebaseNotFound = errors.New("codebase of perf data source has not been found")
+
+// RemoveCodebaseDataSource drops the link of the data source CR to the codebase.
+// The link itself is kept while the data source is still listed in the codebase spec.
+func (s PerfDataSourceService) RemoveCodebaseDataSource(codebase, dataSource, tenant string) error {
+	rLog := log.WithValues("codebase", codebase, "data source", dataSource)
+	rLog.Info("removing codebase_perf_data_source record")
+	if err := migration.Ensure(s.DB, tenant); err != nil {
+		return err
+	}
+
 	txn, err := s.DB.Begin()
 	if err != nil {
-		return false, err
+		return err
 	}
 
-	exists, err := perfdatasource.PerfDataSourceExists(txn, strings.ToUpper(dsType), tenant)
-	if err != nil {
-		return false, err
+	if err := removeCodebaseDataSource(txn, codebase, strings.ToUpper(dataSource), tenant); err != nil {
+		_ = txn.Rollback()
+		return err
 	}
 
 	if err := txn.Commit(); err != nil {
-		return false, err
+		return err
 	}
-
-	return exists, nil
+	rLog.Info("codebase_perf_data_source record has been removed")
+	return nil
 }
 
-func (s PerfDataSourceService) InsertPerfDataSources(perf *codebase.Perf, tenant string) error {
-	if perf == nil {
+func removeCodebaseDataSource(txn *sql.Tx, codebaseName, dsType, tenant string) error {
+	cbId, err := repository.GetCodebaseId(txn, codebaseName, tenant)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't get id of codebase %v", codebaseName)
+	}
+	if cbId == nil {
 		return nil
 	}
-	log.Info("start inserting data source records")
 
-	txn, err := s.DB.Begin()
+	exists, err := perfdatasource.PerfDataSourceExists(txn, dsType, tenant)
 	if err != nil {
-		return err
+		return errors.Wrapf(err, "couldn't check existence of %v data source", dsType)
+	}
+	if !exists {
+		return nil
 	}
 
-	for _, ds := range perf.DataSources {
-		exists, err := s.perfDataSourceExists(ds, tenant)
-		if err != nil {
-			return err
-		}
-
-		if exists {
-			log.Info("data source already exists. skip creating", "type", ds)
-			continue
-		}
-
-		if err := perfdatasource.InsertPerfDataSource(txn, strings.ToUpper(ds), tenant); err != nil {
-			_ = txn.Rollback()
-			return err
-		}
-		log.Info("data sources has been added to table", "type", ds)
+	dsId, err := perfdatasource.GetDataSourceId(txn, dsType, tenant)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't get id of %v data source", dsType)
 	}
+	return codebaseperfdatasource.ReleaseCodebasePerfDataSources(txn, *cbId, []int{*dsId}, codebaseperfdatasource.CROrigin, tenant)
+}
 
-	if err := txn.Commit(); err != nil {
+// PutCodebaseDataSource links the data source of the type to the codebase creating the data source record if needed
+func (s PerfDataSourceService) PutCodebaseDataSource(codebaseName, dataSource, tenant string) error {
+	rLog := log.WithValues("codebase", codebaseName, "data source", dataSource)
+	rLog.Info("putting codebase_perf_data_source record")
+	if err := migration.Ensure(s.DB, tenant); err != nil {
 		return err
 	}
 
-	return nil
-}
-
-func (s PerfDataSourceService) RemoveCodebaseDataSource(codebase, dataSource, tenant string) error {
-	rLog := log.WithValues("codebase", codebase, "data source", dataSource)
-	rLog.Info("removing codebase_perf_data_source record")
 	txn, err := s.DB.Begin()
 	if err != nil {
 		return err
 	}
 
-	if err := perfdatasource.RemoveCodebaseDataSource(txn, codebase, dataSource, tenant); err != nil {
+	if err := putCodebaseDataSource(txn, codebaseName, strings.ToUpper(dataSource), tenant); err != nil {
 		_ = txn.Rollback()
 		return err
 	}
@@ -85,6 +93,22 @@ func (s PerfDataSourceService) RemoveCodebaseDataSource(codebase, dataSource, te
 	if err := txn.Commit(); err != nil {
 		return err
 	}
-	rLog.Info("codebase_perf_data_source record has been removed")
+	rLog.Info("codebase_perf_data_source record has been put")
 	return nil
 }
+
+func putCodebaseDataSource(txn *sql.Tx, codebaseName, dsType, tenant string) error {
+	cbId, err := repository.GetCodebaseId(txn, codebaseName, tenant)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't get id of codebase %v", codebaseName)
+	}
+	if cbId == nil {
+		return errors.Wrapf(ErrCodebaseNotFound, "codebase %v", codebaseName)
+	}
+
+	dsId, err := perfdatasource.PutPerfDataSource(txn, dsType, tenant)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't put %v data source", dsType)
+	}
+	return codebaseperfdatasource.PutCodebasePerfDataSource(txn, *cbId, *dsId, codebaseperfdatasource.CROrigin, tenant)
+}
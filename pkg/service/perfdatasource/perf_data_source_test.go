@@ -0,0 +1,78 @@
+package perfdatasource
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPutCodebaseDataSource_CreatesDataSourceAndLink(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select id from "schema".codebase where name`).ExpectQuery().WithArgs("app").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectPrepare(`select exists\(select 1 from "schema".perf_data_sources`).ExpectQuery().WithArgs("GITLAB").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectPrepare(`insert into "schema".perf_data_sources`).ExpectExec().WithArgs("GITLAB").
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectPrepare(`select id from "schema".perf_data_sources`).ExpectQuery().WithArgs("GITLAB").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectExec(`update "schema".codebase_perf_data_sources set cr_managed = true`).WithArgs(3, 5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare(`insert into "schema".codebase_perf_data_sources`).ExpectExec().WithArgs(3, 5, false, true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	err = putCodebaseDataSource(tx, "app", "GITLAB", "schema")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPutCodebaseDataSource_CodebaseNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select id from "schema".codebase where name`).ExpectQuery().WithArgs("app").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	err = putCodebaseDataSource(tx, "app", "SONAR", "schema")
+	assert.Equal(t, ErrCodebaseNotFound, errors.Cause(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRemoveCodebaseDataSource_KeepsLinkRequiredBySpec(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select id from "schema".codebase where name`).ExpectQuery().WithArgs("app").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectPrepare(`select exists\(select 1 from "schema".perf_data_sources`).ExpectQuery().WithArgs("SONAR").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectPrepare(`select id from "schema".perf_data_sources`).ExpectQuery().WithArgs("SONAR").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectExec(`update "schema".codebase_perf_data_sources set cr_managed = false`).
+		WithArgs(3, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`delete from "schema".codebase_perf_data_sources where codebase_id=\$1 and not spec_managed and not cr_managed`).
+		WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	err = removeCodebaseDataSource(tx, "app", "SONAR", "schema")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
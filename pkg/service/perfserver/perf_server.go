@@ -3,6 +3,7 @@ package perfserver
 import (
 	"database/sql"
 	"github.com/epam/edp-reconciler/v2/pkg/model/perfserver"
+	"github.com/epam/edp-reconciler/v2/pkg/repository"
 	perfServerRepo "github.com/epam/edp-reconciler/v2/pkg/repository/perfserver"
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -48,21 +49,22 @@ func tryToPutPerfServer(txn *sql.Tx, id *int, server perfserver.PerfServer, sche
 	return perfServerRepo.CreatePerfServer(txn, server.Name, server.Available, schema)
 }
 
-func (s PerfServerService) GetPerfServerId(name, tenant string) (*int, error) {
-	log.Info("getting perf server id", "name", name)
+// DeletePerfServer removes the perf_server record, see repository.DeleteServer
+func (s PerfServerService) DeletePerfServer(name, tenant string, detach bool) error {
+	log.Info("start deleting PerfServer", "name", name)
 	txn, err := s.DB.Begin()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	id, err := perfServerRepo.SelectPerfServer(txn, name, tenant)
-	if err != nil {
-		return nil, errors.Wrapf(err, "an error has occurred while fetching PerfServer %v", name)
+	if err := repository.DeleteServer(txn, repository.PerfServerReference, name, detach, tenant); err != nil {
+		_ = txn.Rollback()
+		return err
 	}
 
 	if err := txn.Commit(); err != nil {
-		return nil, err
+		return err
 	}
-
-	return id, nil
+	log.Info("PerfServer has been deleted", "name", name)
+	return nil
 }
@@ -0,0 +1,225 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/cdpipeline"
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+	"github.com/epam/edp-reconciler/v2/pkg/model/codebase"
+	"github.com/epam/edp-reconciler/v2/pkg/model/codebasebranch"
+	"github.com/epam/edp-reconciler/v2/pkg/model/stage"
+	"github.com/epam/edp-reconciler/v2/pkg/model/view"
+	"github.com/epam/edp-reconciler/v2/pkg/repository"
+	"github.com/epam/edp-reconciler/v2/pkg/service/changefeed"
+)
+
+// SyncStatus compares the entity converted from its CR with the row stored in the tenant schema.
+// Row is empty if the entity hasn't been synced yet.
+type SyncStatus struct {
+	Kind       change.Kind     `json:"kind"`
+	Name       string          `json:"name"`
+	Tenant     string          `json:"tenant"`
+	Row        interface{}     `json:"row,omitempty"`
+	LastAction *view.ActionLog `json:"lastAction,omitempty"`
+	Drift      []Drift         `json:"drift"`
+}
+
+// Drift is a field whose stored value differs from the value expected by the CR
+type Drift struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+func (s QueryService) CodebaseStatus(ctx context.Context, c codebase.Codebase) (*SyncStatus, error) {
+	status := &SyncStatus{Kind: change.Codebase, Name: c.Name, Tenant: c.Tenant, Drift: []Drift{}}
+	err := s.read(ctx, c.Tenant, func(txn *sql.Tx) error {
+		row, err := repository.GetCodebaseView(txn, c.Name, c.Tenant)
+		if err != nil || row == nil {
+			return err
+		}
+		status.Row = row
+		status.Drift = codebaseDrift(c, *row)
+		status.LastAction, err = lastActionLog(txn, repository.CodebaseActionLogLink, row.Id, c.Tenant)
+		return err
+	})
+	return status, err
+}
+
+func (s QueryService) CodebaseBranchStatus(ctx context.Context, b codebasebranch.CodebaseBranch) (*SyncStatus, error) {
+	status := &SyncStatus{Kind: change.CodebaseBranch, Name: changefeed.Name(b.AppName, b.Name), Tenant: b.Tenant,
+		Drift: []Drift{}}
+	err := s.read(ctx, b.Tenant, func(txn *sql.Tx) error {
+		row, err := repository.GetCodebaseBranchView(txn, b.AppName, b.Name, b.Tenant)
+		if err != nil || row == nil {
+			return err
+		}
+		status.Row = row
+		status.Drift = codebaseBranchDrift(b, *row)
+		status.LastAction, err = lastActionLog(txn, repository.CodebaseBranchActionLogLink, row.Id, b.Tenant)
+		return err
+	})
+	return status, err
+}
+
+func (s QueryService) CDPipelineStatus(ctx context.Context, p cdpipeline.CDPipeline) (*SyncStatus, error) {
+	status := &SyncStatus{Kind: change.CDPipeline, Name: p.Name, Tenant: p.Tenant, Drift: []Drift{}}
+	err := s.read(ctx, p.Tenant, func(txn *sql.Tx) error {
+		row, err := repository.GetCDPipelineView(txn, p.Name, p.Tenant)
+		if err != nil || row == nil {
+			return err
+		}
+		status.Row = row
+		status.Drift = cdPipelineDrift(p, *row)
+		status.LastAction, err = lastActionLog(txn, repository.CDPipelineActionLogLink, row.Id, p.Tenant)
+		return err
+	})
+	return status, err
+}
+
+// StageStatus compares the stage with its row, stages have no action logs of their own
+func (s QueryService) StageStatus(ctx context.Context, st stage.Stage) (*SyncStatus, error) {
+	status := &SyncStatus{Kind: change.CDStage, Name: changefeed.Name(st.CdPipelineName, st.Name), Tenant: st.Tenant,
+		Drift: []Drift{}}
+	err := s.read(ctx, st.Tenant, func(txn *sql.Tx) error {
+		row, err := repository.GetStageView(txn, st.CdPipelineName, st.Name, st.Tenant)
+		if err != nil || row == nil {
+			return err
+		}
+		status.Row = row
+		status.Drift = stageDrift(st, *row)
+		return nil
+	})
+	return status, err
+}
+
+// Orphans returns names of the entities stored in the tenant schema which have no CR among the existing ones.
+// Branch and stage names are prefixed with the codebase or pipeline name, e.g. app/master.
+func (s QueryService) Orphans(ctx context.Context, tenant string, kind change.Kind, existing []string) ([]string, error) {
+	result := []string{}
+	err := s.read(ctx, tenant, func(txn *sql.Tx) error {
+		names, err := repository.GetEntityNames(txn, kind, tenant)
+		if err != nil {
+			return err
+		}
+
+		known := make(map[string]bool, len(existing))
+		for _, n := range existing {
+			known[n] = true
+		}
+		for _, n := range names {
+			if !known[n] {
+				result = append(result, n)
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+func lastActionLog(txn *sql.Tx, link repository.ActionLogLink, id int, tenant string) (*view.ActionLog, error) {
+	logs, err := repository.ListActionLogs(txn, link, id, view.Page{Limit: 1}, tenant)
+	if err != nil || len(logs) == 0 {
+		return nil, err
+	}
+	return &logs[0], nil
+}
+
+type drift []Drift
+
+func (d drift) compare(field, expected, actual string) drift {
+	if expected != actual {
+		return append(d, Drift{Field: field, Expected: expected, Actual: actual})
+	}
+	return d
+}
+
+func codebaseDrift(c codebase.Codebase, row view.Codebase) []Drift {
+	perf := ""
+	if c.Perf != nil {
+		perf = c.Perf.Name
+	}
+	return drift{}.
+		compare("type", c.Type, row.Type).
+		compare("language", strings.ToLower(c.Language), deref(row.Language)).
+		compare("framework", deref(c.Framework), deref(row.Framework)).
+		compare("buildTool", strings.ToLower(c.BuildTool), deref(row.BuildTool)).
+		compare("strategy", strings.ToLower(c.Strategy), deref(row.Strategy)).
+		compare("repositoryUrl", c.RepositoryUrl, deref(row.RepositoryUrl)).
+		compare("status", c.Status, deref(row.Status)).
+		compare("description", c.Description, deref(row.Description)).
+		compare("versioningType", c.VersioningType, deref(row.VersioningType)).
+		compare("defaultBranch", c.DefaultBranch, deref(row.DefaultBranch)).
+		compare("ciTool", c.CiTool, deref(row.CiTool)).
+		compare("gitServer", c.GitServer, deref(row.GitServer)).
+		compare("jenkinsSlave", deref(c.JenkinsSlave), deref(row.JenkinsSlave)).
+		compare("jobProvisioning", deref(c.JobProvisioning), deref(row.JobProvisioning)).
+		compare("jiraServer", deref(c.JiraServer), deref(row.JiraServer)).
+		compare("perfServer", perf, deref(row.PerfServer))
+}
+
+func codebaseBranchDrift(b codebasebranch.CodebaseBranch, row view.CodebaseBranch) []Drift {
+	return drift{}.
+		compare("fromCommit", b.FromCommit, deref(row.FromCommit)).
+		compare("status", b.Status, deref(row.Status)).
+		compare("version", deref(b.Version), deref(row.Version)).
+		compare("buildNumber", deref(b.BuildNumber), deref(row.BuildNumber)).
+		compare("lastSuccessBuild", deref(b.LastSuccessBuild), deref(row.LastSuccessBuild)).
+		compare("release", strconv.FormatBool(b.Release), strconv.FormatBool(row.Release))
+}
+
+func cdPipelineDrift(p cdpipeline.CDPipeline, row view.CDPipeline) []Drift {
+	return drift{}.
+		compare("deploymentType", p.DeploymentType, deref(row.DeploymentType)).
+		compare("status", p.Status, deref(row.Status)).
+		compare("inputDockerStreams", sortedList(p.InputDockerStreams), sortedList(row.InputStreams))
+}
+
+func stageDrift(s stage.Stage, row view.Stage) []Drift {
+	var library, libraryBranch string
+	if s.Source.Type != "default" {
+		library, libraryBranch = s.Source.Library.Name, s.Source.Library.Branch
+	}
+
+	var expectedGates, actualGates []string
+	for _, g := range s.QualityGates {
+		expectedGates = append(expectedGates, qualityGate(g.QualityGate, g.JenkinsStepName, g.AutotestName, g.BranchName))
+	}
+	for _, g := range row.QualityGates {
+		actualGates = append(actualGates, qualityGate(g.QualityGate, g.JenkinsStepName, g.Autotest, g.Branch))
+	}
+
+	return drift{}.
+		compare("description", s.Description, deref(row.Description)).
+		compare("triggerType", s.TriggerType, deref(row.TriggerType)).
+		compare("order", strconv.Itoa(s.Order), strconv.Itoa(row.Order)).
+		compare("status", s.Status, deref(row.Status)).
+		compare("jobProvisioning", s.JobProvisioning, deref(row.JobProvisioning)).
+		compare("library", library, deref(row.Library)).
+		compare("libraryBranch", libraryBranch, deref(row.LibraryBranch)).
+		compare("qualityGates", sortedList(expectedGates), sortedList(actualGates))
+}
+
+func qualityGate(gate, step string, autotest, branch *string) string {
+	if autotest == nil {
+		return gate + ":" + step
+	}
+	return gate + ":" + step + ":" + changefeed.Name(*autotest, deref(branch))
+}
+
+func sortedList(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,93 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+	"github.com/epam/edp-reconciler/v2/pkg/model/codebasebranch"
+)
+
+func expectTenant(mock sqlmock.Sqlmock, tenant string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select exists\(select 1 from pg_namespace`).WithArgs(tenant).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+}
+
+func TestCodebaseBranchStatus_ReportsDrift(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	expectTenant(mock, "edp")
+	mock.ExpectQuery(`from "edp".codebase_branch cb`).WithArgs("app", "master").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "from_commit", "status", "version", "build_number",
+			"last_success_build", "release", "oc_image_stream_name"}).
+			AddRow(3, "master", "", "created", "1.0.0", "4", "3", false, "app-master"))
+	mock.ExpectQuery(`join "edp".codebase_branch_action_log l`).WithArgs(3, 1, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "detailed_message", "username", "updated_at", "action",
+			"action_message", "result"}).
+			AddRow(7, "", "admin", time.Now(), "codebase_branch_registration", "registered", "success"))
+	mock.ExpectRollback()
+
+	version, build, lastSuccess := "1.0.0", "5", "5"
+	status, err := QueryService{DB: db}.CodebaseBranchStatus(context.Background(), codebasebranch.CodebaseBranch{
+		Name:             "master",
+		Tenant:           "edp",
+		AppName:          "app",
+		Version:          &version,
+		BuildNumber:      &build,
+		LastSuccessBuild: &lastSuccess,
+		Status:           "created",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "app/master", status.Name)
+	assert.NotNil(t, status.Row)
+	assert.Equal(t, 7, status.LastAction.Id)
+	assert.Equal(t, []Drift{
+		{Field: "buildNumber", Expected: "5", Actual: "4"},
+		{Field: "lastSuccessBuild", Expected: "5", Actual: "3"},
+	}, status.Drift)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCodebaseBranchStatus_NotSynced(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	expectTenant(mock, "edp")
+	mock.ExpectQuery(`from "edp".codebase_branch cb`).WithArgs("app", "feature").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	status, err := QueryService{DB: db}.CodebaseBranchStatus(context.Background(), codebasebranch.CodebaseBranch{
+		Name:    "feature",
+		Tenant:  "edp",
+		AppName: "app",
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, status.Row)
+	assert.Empty(t, status.Drift)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOrphans(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	expectTenant(mock, "edp")
+	mock.ExpectQuery(`select cp.name \|\| '/' \|\| cs.name as name from "edp".cd_stage cs`).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("pipe/qa").AddRow("pipe/sit"))
+	mock.ExpectRollback()
+
+	orphans, err := QueryService{DB: db}.Orphans(context.Background(), "edp", change.CDStage, []string{"pipe/qa"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pipe/sit"}, orphans)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,189 @@
+// Package query provides read-only access to the tenant data stored by the reconciler
+package query
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/view"
+	"github.com/epam/edp-reconciler/v2/pkg/repository"
+	"github.com/epam/edp-reconciler/v2/pkg/repository/codebasebranch"
+	"github.com/epam/edp-reconciler/v2/pkg/service/lineage"
+)
+
+// ErrNotFound is returned when the requested entity doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// ErrTenantNotFound is returned when the tenant doesn't exist
+var ErrTenantNotFound = repository.ErrTenantNotFound
+
+type QueryService struct {
+	DB *sql.DB
+}
+
+func (s QueryService) Codebases(ctx context.Context, tenant string, page view.Page) ([]view.Codebase, error) {
+	var result []view.Codebase
+	err := s.read(ctx, tenant, func(txn *sql.Tx) (err error) {
+		result, err = repository.ListCodebases(txn, page, tenant)
+		return err
+	})
+	return result, err
+}
+
+func (s QueryService) CodebaseBranches(ctx context.Context, tenant, codebase string, page view.Page) ([]view.CodebaseBranch, error) {
+	var result []view.CodebaseBranch
+	err := s.read(ctx, tenant, func(txn *sql.Tx) error {
+		if _, err := codebaseId(txn, codebase, tenant); err != nil {
+			return err
+		}
+
+		branches, err := repository.ListCodebaseBranches(txn, codebase, page, tenant)
+		result = branches
+		return err
+	})
+	return result, err
+}
+
+func (s QueryService) CDPipelines(ctx context.Context, tenant string, page view.Page) ([]view.CDPipeline, error) {
+	var result []view.CDPipeline
+	err := s.read(ctx, tenant, func(txn *sql.Tx) (err error) {
+		result, err = repository.ListCDPipelines(txn, page, tenant)
+		return err
+	})
+	return result, err
+}
+
+func (s QueryService) CDPipelineStages(ctx context.Context, tenant, pipeline string, page view.Page) ([]view.Stage, error) {
+	var result []view.Stage
+	err := s.read(ctx, tenant, func(txn *sql.Tx) error {
+		if _, err := cdPipelineId(txn, pipeline, tenant); err != nil {
+			return err
+		}
+
+		stages, err := repository.ListCDPipelineStages(txn, pipeline, page, tenant)
+		result = stages
+		return err
+	})
+	return result, err
+}
+
+func (s QueryService) CDPipelineDockerStreams(ctx context.Context, tenant, pipeline string, page view.Page) ([]view.StageDockerStream, error) {
+	var result []view.StageDockerStream
+	err := s.read(ctx, tenant, func(txn *sql.Tx) error {
+		if _, err := cdPipelineId(txn, pipeline, tenant); err != nil {
+			return err
+		}
+
+		streams, err := repository.ListCDPipelineDockerStreams(txn, pipeline, page, tenant)
+		result = streams
+		return err
+	})
+	return result, err
+}
+
+// CDPipelineLineage returns promotion graph of the docker streams from the pipeline inputs through its stages
+func (s QueryService) CDPipelineLineage(ctx context.Context, tenant, pipeline string) (*lineage.Graph, error) {
+	var result *lineage.Graph
+	err := s.read(ctx, tenant, func(txn *sql.Tx) error {
+		if _, err := cdPipelineId(txn, pipeline, tenant); err != nil {
+			return err
+		}
+
+		inputs, err := repository.GetCDPipelineInputStreams(txn, pipeline, tenant)
+		if err != nil {
+			return err
+		}
+		links, err := repository.GetCDPipelineDockerStreams(txn, pipeline, tenant)
+		if err != nil {
+			return err
+		}
+		promoted, err := repository.GetApplicationsToPromote(txn, pipeline, tenant)
+		if err != nil {
+			return err
+		}
+		result = lineage.Build(pipeline, inputs, links, promoted)
+		return nil
+	})
+	return result, err
+}
+
+func (s QueryService) CodebaseActionLogs(ctx context.Context, tenant, codebase string, page view.Page) ([]view.ActionLog, error) {
+	return s.actionLogs(ctx, tenant, repository.CodebaseActionLogLink, page, func(txn *sql.Tx) (int, error) {
+		return codebaseId(txn, codebase, tenant)
+	})
+}
+
+func (s QueryService) CodebaseBranchActionLogs(ctx context.Context, tenant, codebase, branch string, page view.Page) ([]view.ActionLog, error) {
+	return s.actionLogs(ctx, tenant, repository.CodebaseBranchActionLogLink, page, func(txn *sql.Tx) (int, error) {
+		id, err := codebasebranch.GetCodebaseBranchId(txn, codebase, branch, tenant)
+		if err != nil {
+			return 0, errors.Wrapf(err, "couldn't get id of branch %v", branch)
+		}
+		if id == nil {
+			return 0, errors.Wrapf(ErrNotFound, "branch %v of codebase %v", branch, codebase)
+		}
+		return *id, nil
+	})
+}
+
+func (s QueryService) CDPipelineActionLogs(ctx context.Context, tenant, pipeline string, page view.Page) ([]view.ActionLog, error) {
+	return s.actionLogs(ctx, tenant, repository.CDPipelineActionLogLink, page, func(txn *sql.Tx) (int, error) {
+		return cdPipelineId(txn, pipeline, tenant)
+	})
+}
+
+func (s QueryService) actionLogs(ctx context.Context, tenant string, link repository.ActionLogLink, page view.Page,
+	entityId func(txn *sql.Tx) (int, error)) ([]view.ActionLog, error) {
+	var result []view.ActionLog
+	err := s.read(ctx, tenant, func(txn *sql.Tx) error {
+		id, err := entityId(txn)
+		if err != nil {
+			return err
+		}
+
+		logs, err := repository.ListActionLogs(txn, link, id, page, tenant)
+		result = logs
+		return err
+	})
+	return result, err
+}
+
+// read runs f in read-only transaction after checking the tenant schema exists
+func (s QueryService) read(ctx context.Context, tenant string, f func(txn *sql.Tx) error) error {
+	txn, err := s.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	if err := repository.CheckTenant(txn, tenant); err != nil {
+		return err
+	}
+	return f(txn)
+}
+
+func codebaseId(txn *sql.Tx, codebase, tenant string) (int, error) {
+	id, err := repository.GetCodebaseId(txn, codebase, tenant)
+	if err != nil {
+		return 0, errors.Wrapf(err, "couldn't get id of codebase %v", codebase)
+	}
+	if id == nil {
+		return 0, errors.Wrapf(ErrNotFound, "codebase %v", codebase)
+	}
+	return *id, nil
+}
+
+func cdPipelineId(txn *sql.Tx, pipeline, tenant string) (int, error) {
+	p, err := repository.GetCDPipeline(txn, pipeline, tenant)
+	if err != nil {
+		return 0, errors.Wrapf(err, "couldn't get CD pipeline %v", pipeline)
+	}
+	if p == nil {
+		return 0, errors.Wrapf(ErrNotFound, "CD pipeline %v", pipeline)
+	}
+	return p.Id, nil
+}
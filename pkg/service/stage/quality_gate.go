@@ -0,0 +1,130 @@
+package stage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/stage"
+	sr "github.com/epam/edp-reconciler/v2/pkg/repository/stage"
+)
+
+const autotestsQualityGate = "autotests"
+
+// syncQualityGates makes quality_gate_stage rows of the stage match the desired gates:
+// new gates are inserted, gates with changed step name are updated and removed ones are deleted.
+// Autotests gates which reference codebase or branch missing in DB are skipped and
+// returned as unresolved, so they could be inserted during one of the next reconciliations.
+func syncQualityGates(tx *sql.Tx, cdStageId int, desired []stage.QualityGate, schemaName string) ([]string, error) {
+	stored, err := sr.GetQualityGates(tx, cdStageId, schemaName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't get quality gates for stage %v", cdStageId)
+	}
+
+	toInsert, toUpdate, toDelete := diffQualityGates(stored, desired)
+
+	for _, gate := range toDelete {
+		if err := sr.DeleteQualityGate(tx, gate.Id, schemaName); err != nil {
+			return nil, errors.Wrapf(err, "couldn't delete quality gate %v", gate.Id)
+		}
+		log.V(2).Info("quality gate has been deleted", "stage id", cdStageId, "id", gate.Id)
+	}
+
+	for _, gate := range toUpdate {
+		if err := sr.UpdateQualityGateStepName(tx, gate.Id, gate.JenkinsStepName, schemaName); err != nil {
+			return nil, errors.Wrapf(err, "couldn't update quality gate %v", gate.Id)
+		}
+		log.V(2).Info("quality gate step name has been updated", "stage id", cdStageId, "id", gate.Id)
+	}
+
+	var unresolved []string
+	for _, gate := range toInsert {
+		resolved, err := insertQualityGate(tx, cdStageId, gate, schemaName)
+		if err != nil {
+			return nil, err
+		}
+		if !resolved {
+			unresolved = append(unresolved, fmt.Sprintf("%v/%v", *gate.AutotestName, *gate.BranchName))
+		}
+	}
+	return unresolved, nil
+}
+
+// diffQualityGates pairs stored gates with desired ones by type and autotest reference.
+// Paired gates with different step names are returned to be updated, not paired desired gates
+// to be inserted and not paired stored gates to be deleted.
+func diffQualityGates(stored, desired []stage.QualityGate) (toInsert, toUpdate, toDelete []stage.QualityGate) {
+	storedByKey := map[string][]stage.QualityGate{}
+	for _, gate := range stored {
+		key := qualityGateKey(gate)
+		storedByKey[key] = append(storedByKey[key], gate)
+	}
+
+	for _, gate := range desired {
+		key := qualityGateKey(gate)
+		candidates := storedByKey[key]
+		if len(candidates) == 0 {
+			toInsert = append(toInsert, gate)
+			continue
+		}
+
+		match := candidates[0]
+		storedByKey[key] = candidates[1:]
+		if match.JenkinsStepName != gate.JenkinsStepName {
+			match.JenkinsStepName = gate.JenkinsStepName
+			toUpdate = append(toUpdate, match)
+		}
+	}
+
+	for _, gate := range stored {
+		key := qualityGateKey(gate)
+		if len(storedByKey[key]) > 0 && storedByKey[key][0].Id == gate.Id {
+			toDelete = append(toDelete, gate)
+			storedByKey[key] = storedByKey[key][1:]
+		}
+	}
+	return
+}
+
+func qualityGateKey(gate stage.QualityGate) string {
+	if gate.QualityGate != autotestsQualityGate {
+		return gate.QualityGate
+	}
+	return fmt.Sprintf("%v/%v/%v", gate.QualityGate, stringOrEmpty(gate.AutotestName), stringOrEmpty(gate.BranchName))
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func insertQualityGate(tx *sql.Tx, cdStageId int, gate stage.QualityGate, schemaName string) (bool, error) {
+	if gate.QualityGate != autotestsQualityGate {
+		if _, err := sr.CreateQualityGate(tx, gate.QualityGate, gate.JenkinsStepName, cdStageId, nil, nil, schemaName); err != nil {
+			return false, errors.Wrapf(err, "couldn't create quality gate %v", gate.JenkinsStepName)
+		}
+		return true, nil
+	}
+
+	if gate.AutotestName == nil || gate.BranchName == nil {
+		return false, fmt.Errorf("autotest and branch names must be specified for %v quality gate", gate.JenkinsStepName)
+	}
+
+	ids, err := sr.GetCodebaseAndBranchIds(tx, *gate.AutotestName, *gate.BranchName, schemaName)
+	if err != nil {
+		return false, errors.Wrapf(err, "couldn't get ids of %v autotest and %v branch", *gate.AutotestName, *gate.BranchName)
+	}
+	if ids == nil {
+		log.Info("autotest of quality gate has not been found yet", "autotest", *gate.AutotestName,
+			"branch", *gate.BranchName)
+		return false, nil
+	}
+
+	if _, err := sr.CreateQualityGate(tx, gate.QualityGate, gate.JenkinsStepName, cdStageId, &ids.CodebaseId, &ids.BranchId, schemaName); err != nil {
+		return false, errors.Wrapf(err, "couldn't create quality gate %v", gate.JenkinsStepName)
+	}
+	return true, nil
+}
@@ -0,0 +1,66 @@
+package stage
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model"
+	"github.com/epam/edp-reconciler/v2/pkg/repository"
+	sr "github.com/epam/edp-reconciler/v2/pkg/repository/stage"
+)
+
+// relinkPipelineStages walks all stages of the pipeline in their order and points input streams
+// of every stage either to output streams of the previous stage (for applications to promote)
+// or to the original pipeline streams. It keeps the chain consistent once a stage has been
+// inserted between existing ones or stages have been reordered.
+func relinkPipelineStages(tx *sql.Tx, cdPipelineName, schemaName string, applicationsToPromote []string) error {
+	stages, err := sr.GetStages(tx, cdPipelineName, schemaName)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't get stages of %v pipeline", cdPipelineName)
+	}
+
+	var prevOutputs map[string]int
+	for _, s := range stages {
+		links, err := repository.GetStageCodebaseDockerStreams(tx, schemaName, s.Id)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't get docker streams of stage %v", s.Id)
+		}
+
+		outputs := make(map[string]int, len(links))
+		for _, l := range links {
+			outputs[l.CodebaseName] = l.OutputStreamId
+			if err := relinkStageStream(tx, s.Id, l, prevOutputs, cdPipelineName, schemaName, applicationsToPromote); err != nil {
+				return err
+			}
+		}
+		prevOutputs = outputs
+	}
+	return nil
+}
+
+func relinkStageStream(tx *sql.Tx, stageId int, link model.StageCodebaseDockerStreamDTO, prevOutputs map[string]int,
+	cdPipelineName, schemaName string, applicationsToPromote []string) error {
+	inputId, ok := prevOutputs[link.CodebaseName]
+	if !ok || !include(applicationsToPromote, link.CodebaseName) {
+		originalId, err := getOriginalInputImageStream(tx, cdPipelineName, link.CodebaseName, schemaName)
+		if err != nil {
+			return err
+		}
+		if originalId == nil {
+			return errors.Errorf("original input stream of %v codebase has not been found", link.CodebaseName)
+		}
+		inputId = *originalId
+	}
+
+	if inputId == link.InputStreamId {
+		return nil
+	}
+
+	if err := repository.UpdateStageCodebaseDockerStreamInputId(tx, schemaName, stageId, link.OutputStreamId, inputId); err != nil {
+		return errors.Wrapf(err, "couldn't relink output stream %v of stage %v", link.OutputStreamId, stageId)
+	}
+	log.Info("input stream of stage has been relinked", "stage id", stageId,
+		"output", link.OutputStreamId, "input", inputId)
+	return nil
+}
@@ -0,0 +1,80 @@
+package stage
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelinkPipelineStages_InsertedStageShouldBecomeInputOfNextStage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`select cs.id, cs.name`).ExpectQuery().WithArgs("pipe").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "status", "trigger_type", "description", "order"}).
+			AddRow(1, "qa", "created", "manual", "", 0).
+			AddRow(3, "perf", "created", "manual", "", 1).
+			AddRow(2, "prod", "created", "manual", "", 2))
+	mock.ExpectPrepare(`from "schema".stage_codebase_docker_stream scds`).ExpectQuery().WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"input", "output", "codebase_id", "codebase_name"}).
+			AddRow(10, 11, 1, "app"))
+	mock.ExpectPrepare(`select cds.id`).ExpectQuery().WithArgs("pipe", "app").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	mock.ExpectPrepare(`from "schema".stage_codebase_docker_stream scds`).ExpectQuery().WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"input", "output", "codebase_id", "codebase_name"}).
+			AddRow(11, 13, 1, "app"))
+	mock.ExpectPrepare(`from "schema".stage_codebase_docker_stream scds`).ExpectQuery().WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"input", "output", "codebase_id", "codebase_name"}).
+			AddRow(11, 12, 1, "app"))
+	mock.ExpectPrepare(`update "schema".stage_codebase_docker_stream`).ExpectExec().WithArgs(13, 2, 12).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = relinkPipelineStages(tx, "pipe", "schema", []string{"app"})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteStageDockerStreams_AllOutputStreamsShouldBeDeleted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`from "schema".stage_codebase_docker_stream scds`).ExpectQuery().WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"input", "output", "codebase_id", "codebase_name"}).
+			AddRow(10, 20, 1, "app").
+			AddRow(11, 21, 2, "lib"))
+	mock.ExpectPrepare(`update "schema".stage_codebase_docker_stream`).ExpectExec().WithArgs(10, 20).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`update "schema".stage_codebase_docker_stream`).ExpectExec().WithArgs(11, 21).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`delete`).ExpectQuery().WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(20).AddRow(21))
+	mock.ExpectExec(`delete from "schema".codebase_docker_stream`).WithArgs(20).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`delete from "schema".codebase_docker_stream`).WithArgs(21).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = deleteStageDockerStreams(tx, 2, "schema")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -7,64 +7,167 @@ import (
 
 	cdPipeApi "github.com/epam/edp-cd-pipeline-operator/v2/pkg/apis/edp/v1"
 	"github.com/pkg/errors"
-	"k8s.io/client-go/rest"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/epam/edp-reconciler/v2/pkg/db/migration"
 	"github.com/epam/edp-reconciler/v2/pkg/model"
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
 	"github.com/epam/edp-reconciler/v2/pkg/model/stage"
-	"github.com/epam/edp-reconciler/v2/pkg/platform"
 	"github.com/epam/edp-reconciler/v2/pkg/repository"
 	"github.com/epam/edp-reconciler/v2/pkg/repository/codebasebranch"
+	jp "github.com/epam/edp-reconciler/v2/pkg/repository/job-provisioning"
 	sr "github.com/epam/edp-reconciler/v2/pkg/repository/stage"
+	"github.com/epam/edp-reconciler/v2/pkg/service/changefeed"
 )
 
 var log = ctrl.Log.WithName("cd_stage_service")
 
+// ErrUnresolvedQualityGates is returned by PutStage when the stage has been saved but some of
+// its autotests quality gates reference codebase or branch which are not in DB yet
+var ErrUnresolvedQualityGates = errors.New("autotests of quality gates have not been found")
+
 type StageService struct {
-	DB        *sql.DB
-	ClientSet platform.ClientSet
+	DB     *sql.DB
+	Client client.Client
 }
 
 //PutStage creates record in DB for Stage.
 //The main cases which method do:
 //	- checks if stage can be created (checks if previous stage has been added)
-//	- update stage status
-//	- add record to Action Log for last operation
+//	- creates stage or updates its row if spec has been changed
+//	- syncs quality gates of the stage
 func (s StageService) PutStage(stage stage.Stage) error {
 	log.V(2).Info("start putting stage into db", "name", stage.Name)
+	if err := migration.Ensure(s.DB, stage.Tenant); err != nil {
+		return err
+	}
+
 	txn, err := s.DB.Begin()
 	if err != nil {
 		return errors.New("error has occurred during opening transaction")
 	}
 
-	if !canStageBeCreated(txn, stage) {
-		_ = txn.Rollback()
-		return fmt.Errorf("previous stage has not been added yet for stage %v", stage.Name)
+	var unresolved []string
+	err = changefeed.InTx(txn, change.CDStage, changefeed.Name(stage.CdPipelineName, stage.Name), stage.Tenant, func() error {
+		if err := setJobProvisioningId(txn, &stage); err != nil {
+			return err
+		}
+
+		u, err := createOrUpdateStage(txn, s.Client, stage)
+		if err != nil {
+			return errors.Wrapf(err, "cannot put stage %v", stage.Name)
+		}
+		unresolved = u
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(unresolved) > 0 {
+		return errors.Wrapf(ErrUnresolvedQualityGates, "stage %v references %v", stage.Name, unresolved)
 	}
 
-	id, err := getStageIdOrCreate(txn, s.ClientSet.EDPRestClient, stage)
+	log.Info("stage has been inserted successfully", "name", stage.Name)
+	return nil
+}
+
+func setJobProvisioningId(txn *sql.Tx, stage *stage.Stage) error {
+	if stage.JobProvisioning == "" {
+		return nil
+	}
+
+	jpId, err := jp.SelectJobProvision(txn, stage.JobProvisioning, "cd", stage.Tenant)
 	if err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "cannot create stage %v", stage.Name)
+		return errors.Wrapf(err, "couldn't get job provisioning id: %v", stage.JobProvisioning)
+	}
+	if jpId == nil {
+		return fmt.Errorf("job provisioning %v has not been found", stage.JobProvisioning)
 	}
+	stage.JobProvisioningId = jpId
+	return nil
+}
 
-	log.Info("start update stage trigger type", "name", stage.Name)
-	if err := updateStageTriggerType(txn, id, stage.TriggerType, stage.Tenant); err != nil {
-		err = txn.Rollback()
-		return errors.Wrapf(err, "cannot update stage trigger type %v", stage.Name)
+func createOrUpdateStage(tx *sql.Tx, c client.Client, stage stage.Stage) ([]string, error) {
+	dto, err := sr.GetStage(tx, stage.Tenant, stage.Name, stage.CdPipelineName)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := updateStageStatus(txn, id, stage); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "cannot create stage %v", stage.Name)
+	var applicationsToPromote []string
+	if dto == nil || dto.Order != stage.Order {
+		pipelineCR, err := GetCDPipelineCR(c, stage.CdPipelineName, stage.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		applicationsToPromote = pipelineCR.Spec.ApplicationsToPromote
 	}
 
-	_ = txn.Commit()
+	var id int
+	if dto == nil {
+		if !canStageBeCreated(tx, stage) {
+			return nil, fmt.Errorf("previous stage has not been added yet for stage %v", stage.Name)
+		}
 
-	log.Info("stage has been inserted successfully", "name", stage.Name)
+		createdId, err := createStage(tx, stage, applicationsToPromote)
+		if err != nil {
+			return nil, err
+		}
+		id = *createdId
+	} else {
+		if err := updateStage(tx, *dto, stage); err != nil {
+			return nil, err
+		}
+		id = dto.Id
+	}
+
+	if dto == nil || dto.Order != stage.Order {
+		if err := relinkPipelineStages(tx, stage.CdPipelineName, stage.Tenant, applicationsToPromote); err != nil {
+			return nil, errors.Wrapf(err, "couldn't relink docker streams of %v pipeline stages", stage.CdPipelineName)
+		}
+	}
+
+	unresolved, err := syncQualityGates(tx, id, stage.QualityGates, stage.Tenant)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't sync quality gates for stage %v", stage.Name)
+	}
+	return unresolved, nil
+}
+
+func updateStage(tx *sql.Tx, dto model.StageDTO, stage stage.Stage) error {
+	log.V(2).Info("stage is already presented. Checking for changes", "name", stage.Name, "id", dto.Id)
+	if err := setLibraryIdOrDoNothing(tx, &stage.Source, stage.Tenant); err != nil {
+		return err
+	}
+
+	stage.Id = dto.Id
+	if stageChanged(dto, stage) {
+		if err := sr.UpdateStage(tx, stage); err != nil {
+			return errors.Wrapf(err, "couldn't update stage %v", stage.Name)
+		}
+		log.Info("stage row has been updated", "id", dto.Id)
+	}
 	return nil
 }
 
+func stageChanged(dto model.StageDTO, stage stage.Stage) bool {
+	return dto.Description != stage.Description ||
+		dto.TriggerType != stage.TriggerType ||
+		dto.Status != stage.Status ||
+		dto.Order != stage.Order ||
+		!intPtrEqual(dto.CodebaseBranchId, stage.Source.LibraryBranchId()) ||
+		!intPtrEqual(dto.JobProvisioningId, stage.JobProvisioningId)
+}
+
+func intPtrEqual(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
 func createCodebaseDockerStreams(tx *sql.Tx, id int, stage stage.Stage, applicationsToApprove []string) error {
 	log.V(2).Info("start creating docker streams for stage", "id", id)
 	inputDockerStreams, err := getInputDockerStreams(tx, id, stage)
@@ -207,10 +310,10 @@ func getOriginalInputImageStream(tx *sql.Tx, cdPipelineName, codebaseName, schem
 	return originalInputStream, nil
 }
 
-func GetCDPipelineCR(edpRestClient *rest.RESTClient, crName string, namespace string) (*cdPipeApi.CDPipeline, error) {
+func GetCDPipelineCR(c client.Client, crName string, namespace string) (*cdPipeApi.CDPipeline, error) {
 	log.V(2).Info("trying to fetch CD Pipeline to get Applications To Promote", "pipe name", crName)
 	cdPipeline := &cdPipeApi.CDPipeline{}
-	err := edpRestClient.Get().Namespace(namespace).Resource("cdpipelines").Name(crName).Do(context.TODO()).Into(cdPipeline)
+	err := c.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: crName}, cdPipeline)
 	if err != nil {
 		return nil, errors.Wrapf(err, "an error has occurred while getting CD Pipeline CR from cluster")
 	}
@@ -229,17 +332,30 @@ func include(applicationsToPromote []string, application string) bool {
 
 func getInputDockerStreams(tx *sql.Tx, id int, stage stage.Stage) ([]model.CodebaseDockerStreamReadDTO, error) {
 	log.V(2).Info("start reading input docker streams for stage", "stage id", id)
-	if stage.Order == 0 {
+	prevId, err := sr.GetPreviousStageId(tx, stage.Tenant, stage.CdPipelineName, stage.Order, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't get previous stage for stage %v", id)
+	}
+	if prevId == nil {
 		return getInputDockerStreamsForFirstStage(tx, id, stage)
 	}
-	return getInputDockerStreamsForArbitraryStage(tx, id, stage)
+	return getInputDockerStreamsForArbitraryStage(tx, id, *prevId, stage)
 }
 
-func getInputDockerStreamsForArbitraryStage(tx *sql.Tx, id int, stage stage.Stage) ([]model.CodebaseDockerStreamReadDTO, error) {
-	log.V(2).Info("start reading input docker streams for the arbitrary stage with id: %v", id)
-	streams, err := repository.GetDockerStreamsByPipelineNameAndStageOrder(tx, stage.Tenant, stage.CdPipelineName, stage.Order-1)
+func getInputDockerStreamsForArbitraryStage(tx *sql.Tx, id int, prevId int, stage stage.Stage) ([]model.CodebaseDockerStreamReadDTO, error) {
+	log.V(2).Info("start reading input docker streams for the arbitrary stage", "stage id", id, "previous stage id", prevId)
+	links, err := repository.GetStageCodebaseDockerStreams(tx, stage.Tenant, prevId)
 	if err != nil {
-		return nil, errors.Wrapf(err, "an error has been occurred during the read docker streams %v", "stage order %v")
+		return nil, errors.Wrapf(err, "an error has been occurred during the read docker streams of stage %v", prevId)
+	}
+
+	var streams []model.CodebaseDockerStreamReadDTO
+	for _, l := range links {
+		streams = append(streams, model.CodebaseDockerStreamReadDTO{
+			CodebaseDockerStreamId: l.OutputStreamId,
+			CodebaseId:             l.CodebaseId,
+			CodebaseName:           l.CodebaseName,
+		})
 	}
 	log.V(2).Info("streams have been successfully retrieved", "streams", streams)
 	return streams, nil
@@ -266,7 +382,7 @@ func canStageBeCreated(tx *sql.Tx, stage stage.Stage) bool {
 
 func prevStageAdded(tx *sql.Tx, stage stage.Stage) bool {
 	log.V(2).Info("check previous stage fot stage", "name", stage.Name)
-	stageId, err := sr.GetStageIdByPipelineNameAndOrder(tx, stage.Tenant, stage.CdPipelineName, stage.Order-1)
+	stageId, err := sr.GetPreviousStageId(tx, stage.Tenant, stage.CdPipelineName, stage.Order, 0)
 	if err != nil {
 		log.Error(err, "an error has been occurred while retrieving prev stage id : %v", stageId)
 		return false
@@ -279,29 +395,7 @@ func prevStageAdded(tx *sql.Tx, stage stage.Stage) bool {
 	return true
 }
 
-func updateStageStatus(tx *sql.Tx, id *int, stage stage.Stage) error {
-	log.V(2).Info("start updating status for stage", "id", *id, "status", stage.Status)
-	err := sr.UpdateStageStatus(tx, stage.Tenant, *id, stage.Status)
-	if err != nil {
-		return errors.Wrapf(err, "an error has been occurred while updating stage status: %v", stage.Name)
-	}
-	log.V(2).Info("status has been updated", "id", *id, "status", stage.Status)
-	return nil
-}
-
-func getStageIdOrCreate(tx *sql.Tx, edpRestClient *rest.RESTClient, stage stage.Stage) (*int, error) {
-	id, err := sr.GetStageId(tx, stage.Tenant, stage.Name, stage.CdPipelineName)
-	if err != nil {
-		return nil, err
-	}
-	if id != nil {
-		log.V(2).Info("stage is already presented. Returning id", "name", stage, "id", *id)
-		return id, err
-	}
-	return createStage(tx, edpRestClient, stage)
-}
-
-func createStage(tx *sql.Tx, edpRestClient *rest.RESTClient, stage stage.Stage) (*int, error) {
+func createStage(tx *sql.Tx, stage stage.Stage, applicationsToPromote []string) (*int, error) {
 	log.V(2).Info("start creating stage in db", "name", stage.Name)
 	cdPipeline, err := repository.GetCDPipeline(tx, stage.CdPipelineName, stage.Tenant)
 	if err != nil {
@@ -320,18 +414,10 @@ func createStage(tx *sql.Tx, edpRestClient *rest.RESTClient, stage stage.Stage)
 		return nil, errors.Wrap(err, "couldn't create stage id db")
 	}
 
-	pipelineCR, err := GetCDPipelineCR(edpRestClient, stage.CdPipelineName, stage.Namespace)
-	if err != nil {
-		return nil, err
-	}
-
-	if err = createCodebaseDockerStreams(tx, *id, stage, pipelineCR.Spec.ApplicationsToPromote); err != nil {
+	if err = createCodebaseDockerStreams(tx, *id, stage, applicationsToPromote); err != nil {
 		return nil, errors.Wrapf(err, "couldn't create docker stream for stage %v in CD Pipeline", stage.Name)
 	}
 
-	if err = insertQualityGateRow(tx, *id, stage.QualityGates, stage.Tenant); err != nil {
-		return nil, errors.Wrapf(err, "couldn't create quality gate for stage %v", *id)
-	}
 	log.Info("stage has been created in db", "id", *id)
 	return id, nil
 }
@@ -364,86 +450,73 @@ func setLibraryIdOrDoNothing(txn *sql.Tx, source *stage.Source, schemaName strin
 	return nil
 }
 
-func insertQualityGateRow(tx *sql.Tx, cdStageId int, gates []stage.QualityGate, schemaName string) error {
-	for _, gate := range gates {
-		if gate.QualityGate == "autotests" {
-			err := insertAutotestQualityGate(tx, cdStageId, gate, schemaName)
-			if err != nil {
-				return err
-			}
-
-			continue
-		}
-
-		err := insertManualQualityGate(tx, cdStageId, gate, schemaName)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-func insertAutotestQualityGate(tx *sql.Tx, cdStageId int, gate stage.QualityGate, schemaName string) error {
-	entityIdsDTO, err := sr.GetCodebaseAndBranchIds(tx, *gate.AutotestName, *gate.BranchName, schemaName)
-	if err != nil {
+func (s StageService) DeleteCDStage(pipeName, stageName, schema string) error {
+	log.V(2).Info("start deleting cd stage", "pipe name", pipeName, "name", stageName)
+	if err := migration.Ensure(s.DB, schema); err != nil {
 		return err
 	}
 
-	_, err = sr.CreateQualityGate(tx, gate.QualityGate, gate.JenkinsStepName, cdStageId, &entityIdsDTO.CodebaseId, &entityIdsDTO.BranchId, schemaName)
-
-	return err
-}
-
-func insertManualQualityGate(tx *sql.Tx, cdStageId int, gate stage.QualityGate, schemaName string) error {
-	_, err := sr.CreateQualityGate(tx, gate.QualityGate, gate.JenkinsStepName, cdStageId, nil, nil, schemaName)
-
-	return err
-}
-
-func (s StageService) DeleteCDStage(pipeName, stageName, schema string) error {
-	log.V(2).Info("start deleting cd stage", "pipe name", pipeName, "name", stageName)
 	txn, err := s.DB.Begin()
 	if err != nil {
 		return errors.New("error has occurred during opening transaction")
 	}
 
-	id, err := sr.SelectCodebaseDockerStreamId(txn, pipeName, stageName, schema)
-	if err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "couldn't get codebase docker stream id by cd stage %v for cd pipeline", stageName)
-	}
+	err = changefeed.InTx(txn, change.CDStage, changefeed.Name(pipeName, stageName), schema, func() error {
+		dto, err := sr.GetStage(txn, schema, stageName, pipeName)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't get cd stage %v", stageName)
+		}
 
-	if id == nil {
-		_ = txn.Rollback()
-		log.V(2).Info("docker stream has been deleted", "pipe", pipeName, "stage", stageName)
-		return nil
-	}
+		if dto == nil {
+			log.V(2).Info("cd stage has been already deleted", "pipe", pipeName, "stage", stageName)
+			return nil
+		}
 
-	if err := sr.DeleteCodebaseDockerStream(txn, *id, schema); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "couldn't delete codebase docker stream with %v id", *id)
-	}
+		if err := deleteStageDockerStreams(txn, dto.Id, schema); err != nil {
+			return errors.Wrapf(err, "couldn't delete docker streams of cd stage %v", stageName)
+		}
 
-	if err := sr.DeleteCDStage(txn, pipeName, stageName, schema); err != nil {
-		_ = txn.Rollback()
-		return errors.Wrapf(err, "couldn't delete cd stage %v for cd pipeline", stageName)
-	}
+		if err := sr.DeleteQualityGates(txn, dto.Id, schema); err != nil {
+			return errors.Wrapf(err, "couldn't delete quality gates of cd stage %v", stageName)
+		}
 
-	if err := txn.Commit(); err != nil {
+		if err := sr.DeleteCDStage(txn, pipeName, stageName, schema); err != nil {
+			return errors.Wrapf(err, "couldn't delete cd stage %v for cd pipeline", stageName)
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 	log.Info("cd stage was deleted", "pipe name", pipeName, "name", stageName)
 	return nil
 }
 
-func updateStageTriggerType(tx *sql.Tx, id *int, triggerType, tenant string) error {
-	log := log.WithValues("id", *id, "trigger_type", triggerType)
-	log.Info("start updating trigger type for stage")
-	err := sr.UpdateStageTriggerType(tx, *id, tenant, triggerType)
+// deleteStageDockerStreams removes links and output streams of the stage. Stages which consumed
+// output streams of the deleted one are relinked to its input streams beforehand, so the next stage
+// gets outputs of the previous stage (or the original pipeline streams) as its inputs.
+func deleteStageDockerStreams(txn *sql.Tx, stageId int, schema string) error {
+	links, err := repository.GetStageCodebaseDockerStreams(txn, schema, stageId)
 	if err != nil {
-		return errors.Wrapf(err, "an error has been occurred while updating stage trigger type: %v", id)
+		return errors.Wrapf(err, "couldn't get docker streams of stage %v", stageId)
+	}
+
+	for _, l := range links {
+		if err := repository.ReplaceStageCodebaseDockerStreamInputId(txn, schema, l.OutputStreamId, l.InputStreamId); err != nil {
+			return errors.Wrapf(err, "couldn't relink consumers of %v docker stream", l.OutputStreamId)
+		}
+	}
+
+	outputIds, err := repository.DeleteStageCodebaseDockerStream(txn, stageId, schema)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't delete docker stream links of stage %v", stageId)
+	}
+
+	for _, id := range outputIds {
+		if err := sr.DeleteCodebaseDockerStream(txn, id, schema); err != nil {
+			return errors.Wrapf(err, "couldn't delete codebase docker stream with %v id", id)
+		}
 	}
-	log.Info("trigger type has been updated")
+	log.V(2).Info("docker streams of stage have been deleted", "stage id", stageId, "streams", outputIds)
 	return nil
 }
@@ -0,0 +1,65 @@
+package stage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model"
+	"github.com/epam/edp-reconciler/v2/pkg/model/stage"
+)
+
+func TestStageChanged_SameValuesShouldReturnFalse(t *testing.T) {
+	jpId := 1
+	dto := model.StageDTO{
+		Description:       "desc",
+		TriggerType:       "manual",
+		Status:            "active",
+		JobProvisioningId: &jpId,
+	}
+	s := stage.Stage{
+		Description:       "desc",
+		TriggerType:       "manual",
+		Status:            "active",
+		Source:            stage.Source{Type: "default"},
+		JobProvisioningId: &jpId,
+	}
+
+	assert.False(t, stageChanged(dto, s))
+}
+
+func TestStageChanged_LibraryBranchChangedShouldReturnTrue(t *testing.T) {
+	oldId, newId := 1, 2
+	dto := model.StageDTO{CodebaseBranchId: &oldId}
+	s := stage.Stage{
+		Source: stage.Source{
+			Type:    "library",
+			Library: stage.Library{BranchId: &newId},
+		},
+	}
+
+	assert.True(t, stageChanged(dto, s))
+}
+
+func TestDiffQualityGates(t *testing.T) {
+	autotest, branch, otherBranch := "autotest", "master", "develop"
+	stored := []stage.QualityGate{
+		{Id: 1, QualityGate: "manual", JenkinsStepName: "approve"},
+		{Id: 2, QualityGate: "autotests", JenkinsStepName: "tests", AutotestName: &autotest, BranchName: &branch},
+		{Id: 3, QualityGate: "autotests", JenkinsStepName: "smoke", AutotestName: &autotest, BranchName: &otherBranch},
+	}
+	desired := []stage.QualityGate{
+		{QualityGate: "manual", JenkinsStepName: "approve"},
+		{QualityGate: "autotests", JenkinsStepName: "regression", AutotestName: &autotest, BranchName: &branch},
+		{QualityGate: "manual", JenkinsStepName: "sign-off"},
+	}
+
+	toInsert, toUpdate, toDelete := diffQualityGates(stored, desired)
+
+	assert.Equal(t, []stage.QualityGate{desired[2]}, toInsert)
+	assert.Len(t, toUpdate, 1)
+	assert.Equal(t, 2, toUpdate[0].Id)
+	assert.Equal(t, "regression", toUpdate[0].JenkinsStepName)
+	assert.Len(t, toDelete, 1)
+	assert.Equal(t, 3, toDelete[0].Id)
+}
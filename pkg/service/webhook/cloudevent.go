@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+)
+
+const (
+	cloudEventsContentType = "application/cloudevents+json"
+	signatureHeader        = "X-Reconciler-Signature"
+	typePrefix             = "com.epam.edp.reconciler."
+)
+
+// CloudEvent is CloudEvents v1.0 envelope of the change event in structured content mode
+type CloudEvent struct {
+	SpecVersion     string       `json:"specversion"`
+	Id              string       `json:"id"`
+	Source          string       `json:"source"`
+	Type            string       `json:"type"`
+	Subject         string       `json:"subject"`
+	Time            time.Time    `json:"time"`
+	DataContentType string       `json:"datacontenttype"`
+	Data            change.Event `json:"data"`
+}
+
+func newCloudEvent(e change.Event) CloudEvent {
+	return CloudEvent{
+		SpecVersion:     "1.0",
+		Id:              fmt.Sprintf("%v-%v", e.Tenant, e.Id),
+		Source:          "/reconciler/tenants/" + e.Tenant,
+		Type:            eventType(e),
+		Subject:         e.Name,
+		Time:            e.CreatedAt,
+		DataContentType: "application/json",
+		Data:            e,
+	}
+}
+
+// eventType names what has happened to the entity, e.g. com.epam.edp.reconciler.codebase_branch.build_succeeded.
+// Updates of the last successful build or status of the entity get their own types so receivers could react
+// on them without comparing snapshots.
+func eventType(e change.Event) string {
+	action := "updated"
+	switch e.Op {
+	case change.Create:
+		action = "created"
+	case change.Delete:
+		action = "deleted"
+	default:
+		if changed(e, "lastSuccessBuild") {
+			action = "build_succeeded"
+		} else if changed(e, "status") {
+			action = "status_changed"
+		}
+	}
+	return typePrefix + string(e.Kind) + "." + action
+}
+
+func changed(e change.Event, field string) bool {
+	var before, after map[string]interface{}
+	if json.Unmarshal(e.Before, &before) != nil || json.Unmarshal(e.After, &after) != nil {
+		return false
+	}
+	v, ok := after[field]
+	return ok && v != nil && fmt.Sprint(before[field]) != fmt.Sprint(v)
+}
+
+// sign returns signature of the body which is sent in X-Reconciler-Signature header
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,179 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+	"github.com/epam/edp-reconciler/v2/pkg/service/outbox"
+)
+
+const (
+	configFileEnv = "WEBHOOK_CONFIG_FILE"
+
+	defaultTimeout = 10 * time.Second
+)
+
+// Config lists webhook endpoints change events are delivered to and options of the outbox they're delivered from
+type Config struct {
+	Endpoints []Endpoint `json:"endpoints"`
+	// Interval is a period the outbox is checked with when there are no new changes
+	Interval Duration `json:"interval,omitempty"`
+	// Timeout limits a single delivery attempt
+	Timeout Duration `json:"timeout,omitempty"`
+	// Backoff is a delay before the first retry, it's doubled with every failed attempt up to MaxBackoff.
+	// Later changes of the entity wait for the delivery until it succeeds or is parked after MaxAttempts.
+	// Events rejected by the endpoint with 4xx status other than 408 and 429 are parked at once.
+	Backoff     Duration `json:"backoff,omitempty"`
+	MaxBackoff  Duration `json:"maxBackoff,omitempty"`
+	MaxAttempts int      `json:"maxAttempts,omitempty"`
+	BatchSize   int      `json:"batchSize,omitempty"`
+}
+
+// Endpoint receives change events as CloudEvents. Empty Kinds or Tenants match all kinds or tenants.
+// If Secret is set, every request is signed with HMAC-SHA256 of the body.
+type Endpoint struct {
+	Name    string        `json:"name"`
+	URL     string        `json:"url"`
+	Secret  string        `json:"secret,omitempty"`
+	Kinds   []change.Kind `json:"kinds,omitempty"`
+	Tenants []string      `json:"tenants,omitempty"`
+}
+
+// Duration is time.Duration which is read from string like 10s
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// Enabled returns true if there are endpoints to deliver events to
+func (c Config) Enabled() bool {
+	return len(c.Endpoints) > 0
+}
+
+// Options returns options of the outbox dispatcher delivering events to the endpoints
+func (c Config) Options() outbox.Options {
+	return outbox.Options{
+		Interval:    c.Interval.Duration,
+		Backoff:     c.Backoff.Duration,
+		MaxBackoff:  c.MaxBackoff.Duration,
+		MaxAttempts: c.MaxAttempts,
+		BatchSize:   c.BatchSize,
+	}.WithDefaults()
+}
+
+// Sinks returns outbox sinks of the endpoints
+func (c Config) Sinks() []outbox.Sink {
+	client := &http.Client{Timeout: c.Timeout.Duration}
+	sinks := make([]outbox.Sink, 0, len(c.Endpoints))
+	for _, e := range c.Endpoints {
+		sinks = append(sinks, &Sink{Endpoint: e, client: client})
+	}
+	return sinks
+}
+
+// AcceptsTenant returns true if events of the tenant are delivered to the endpoint
+func (e Endpoint) AcceptsTenant(tenant string) bool {
+	if len(e.Tenants) == 0 {
+		return true
+	}
+	for _, t := range e.Tenants {
+		if t == tenant {
+			return true
+		}
+	}
+	return false
+}
+
+// Accepts returns true if the event passes filters of the endpoint
+func (e Endpoint) Accepts(ev change.Event) bool {
+	if !e.AcceptsTenant(ev.Tenant) {
+		return false
+	}
+	if len(e.Kinds) == 0 {
+		return true
+	}
+	for _, k := range e.Kinds {
+		if k == ev.Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigFromEnv reads webhook config from the file set in WEBHOOK_CONFIG_FILE env variable.
+// Empty config is returned if the variable isn't set.
+func ConfigFromEnv() (*Config, error) {
+	path := os.Getenv(configFileEnv)
+	if path == "" {
+		c := &Config{}
+		c.setDefaults()
+		return c, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't read webhook config %v", path)
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig reads webhook config from YAML or JSON
+func ParseConfig(data []byte) (*Config, error) {
+	c := &Config{}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, errors.Wrap(err, "couldn't parse webhook config")
+	}
+	c.setDefaults()
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Config) setDefaults() {
+	if c.Timeout.Duration == 0 {
+		c.Timeout.Duration = defaultTimeout
+	}
+}
+
+func (c Config) validate() error {
+	if c.Interval.Duration < 0 || c.Timeout.Duration < 0 || c.Backoff.Duration < 0 || c.MaxBackoff.Duration < 0 ||
+		c.MaxAttempts < 0 || c.BatchSize < 0 {
+		return errors.New("webhook intervals and limits must not be negative")
+	}
+
+	names := map[string]bool{}
+	for _, e := range c.Endpoints {
+		if e.Name == "" || e.URL == "" {
+			return errors.New("webhook endpoint name and url are required")
+		}
+		if names[e.Name] {
+			return errors.Errorf("webhook endpoint %v is duplicated", e.Name)
+		}
+		names[e.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Package webhook delivers changes of the reconciled entities to HTTP endpoints as CloudEvents
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+	"github.com/epam/edp-reconciler/v2/pkg/service/outbox"
+)
+
+// Sink delivers change events to the endpoint as CloudEvents in structured content mode
+type Sink struct {
+	Endpoint Endpoint
+	client   *http.Client
+}
+
+func (s *Sink) Name() string {
+	return outbox.WebhookSinkPrefix + s.Endpoint.Name
+}
+
+func (s *Sink) Accepts(e change.Event) bool {
+	return s.Endpoint.Accepts(e)
+}
+
+func (s *Sink) Deliver(ctx context.Context, e change.Event) error {
+	payload, err := json.Marshal(newCloudEvent(e))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Endpoint.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", cloudEventsContentType)
+	if s.Endpoint.Secret != "" {
+		req.Header.Set(signatureHeader, sign(s.Endpoint.Secret, payload))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err := fmt.Errorf("endpoint has responded with %v", resp.Status)
+		if rejected(resp.StatusCode) {
+			return outbox.Permanent(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// rejected returns true if the endpoint won't accept the event on retry. Client errors other than
+// timeouts and rate limiting mean the event itself is refused.
+func rejected(status int) bool {
+	return status >= 400 && status <= 499 &&
+		status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
+}
@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/epam/edp-reconciler/v2/pkg/model/change"
+	"github.com/epam/edp-reconciler/v2/pkg/service/outbox"
+)
+
+func TestSink_DeliversSignedCloudEvent(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer receiver.Close()
+
+	sinks := Config{Endpoints: []Endpoint{{Name: "bot", URL: receiver.URL, Secret: "s3cr3t"}}}.Sinks()
+	assert.Equal(t, "webhook/bot", sinks[0].Name())
+
+	err := sinks[0].Deliver(context.Background(), change.Event{Id: 5, Tenant: "edp", Kind: change.Codebase,
+		Name: "app", Op: change.Create, After: json.RawMessage(`{"name":"app"}`)})
+	assert.NoError(t, err)
+
+	assert.Equal(t, cloudEventsContentType, received.Header.Get("Content-Type"))
+	assert.Equal(t, sign("s3cr3t", body), received.Header.Get(signatureHeader))
+	var ce CloudEvent
+	assert.NoError(t, json.Unmarshal(body, &ce))
+	assert.Equal(t, "1.0", ce.SpecVersion)
+	assert.Equal(t, "edp-5", ce.Id)
+	assert.Equal(t, "/reconciler/tenants/edp", ce.Source)
+	assert.Equal(t, "com.epam.edp.reconciler.codebase.created", ce.Type)
+	assert.Equal(t, "app", ce.Subject)
+}
+
+func TestSink_FailsOnErrorResponse(t *testing.T) {
+	tests := []struct {
+		status    int
+		permanent bool
+	}{
+		{status: http.StatusServiceUnavailable},
+		{status: http.StatusTooManyRequests},
+		{status: http.StatusBadRequest, permanent: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer receiver.Close()
+
+			sinks := Config{Endpoints: []Endpoint{{Name: "bot", URL: receiver.URL}}}.Sinks()
+			err := sinks[0].Deliver(context.Background(), change.Event{Id: 5, Tenant: "edp", Kind: change.Codebase})
+			assert.EqualError(t, err, fmt.Sprintf("endpoint has responded with %v %v", tt.status,
+				http.StatusText(tt.status)))
+			var permanent *outbox.PermanentError
+			assert.Equal(t, tt.permanent, errors.As(err, &permanent))
+		})
+	}
+}
+
+func TestEventType(t *testing.T) {
+	branch := func(before, after string) change.Event {
+		return change.Event{Kind: change.CodebaseBranch, Op: change.Update,
+			Before: json.RawMessage(before), After: json.RawMessage(after)}
+	}
+
+	assert.Equal(t, "com.epam.edp.reconciler.codebase_branch.build_succeeded",
+		eventType(branch(`{"status":"created"}`, `{"status":"created","lastSuccessBuild":"3"}`)))
+	assert.Equal(t, "com.epam.edp.reconciler.codebase_branch.status_changed",
+		eventType(branch(`{"status":"created"}`, `{"status":"failed"}`)))
+	assert.Equal(t, "com.epam.edp.reconciler.codebase_branch.updated",
+		eventType(branch(`{"version":"1"}`, `{"version":"2"}`)))
+	assert.Equal(t, "com.epam.edp.reconciler.cd_pipeline.deleted",
+		eventType(change.Event{Kind: change.CDPipeline, Op: change.Delete}))
+}
+
+func TestParseConfig(t *testing.T) {
+	c, err := ParseConfig([]byte(`
+interval: 30s
+endpoints:
+  - name: bot
+    url: http://bot
+    kinds: [codebase_branch]
+    tenants: [edp]
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, c.Options().Interval)
+	assert.Equal(t, defaultTimeout, c.Timeout.Duration)
+	assert.True(t, c.Endpoints[0].Accepts(change.Event{Tenant: "edp", Kind: change.CodebaseBranch}))
+	assert.False(t, c.Endpoints[0].Accepts(change.Event{Tenant: "other", Kind: change.CodebaseBranch}))
+	assert.False(t, c.Endpoints[0].Accepts(change.Event{Tenant: "edp", Kind: change.Codebase}))
+
+	_, err = ParseConfig([]byte(`endpoints: [{name: bot, url: http://a}, {name: bot, url: http://b}]`))
+	assert.Error(t, err)
+}